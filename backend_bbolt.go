@@ -0,0 +1,49 @@
+//go:build vboltbbolt && !js && !vboltmem
+
+package vbolt
+
+// The maintained fork of bolt, selected with the vboltbbolt build tag:
+//
+//	go get go.etcd.io/bbolt
+//	go build -tags vboltbbolt ./...
+//
+// vbolt itself doesn't require it, so the application's go.mod must.
+// The file format is the same, so existing files open with either backend.
+
+import (
+	"io"
+	"os"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type DB = bolt.DB
+type Tx = bolt.Tx
+type BBucket = bolt.Bucket
+type Cursor = bolt.Cursor
+type Options = bolt.Options
+
+const BackendName = "bbolt"
+
+var ErrTxNotWritable = bolt.ErrTxNotWritable
+var ErrTxClosed = bolt.ErrTxClosed
+var ErrOpenTimeout = bolt.ErrTimeout
+
+func _OpenBackend(filename string, mode os.FileMode, options *Options) (*DB, error) {
+	return bolt.Open(filename, mode, options)
+}
+
+func _SetFreelistType(options *Options, freelistType string) {
+	options.FreelistType = bolt.FreelistType(freelistType)
+}
+
+func _PageSize(db *DB) int {
+	return db.Info().PageSize
+}
+
+// the database file can be copied as is (see BackupHandler)
+const _CanWriteTo = true
+
+func _TxWriteTo(tx *Tx, w io.Writer) (int64, error) {
+	return tx.WriteTo(w)
+}
@@ -0,0 +1,40 @@
+//go:build !js && !vboltmem && !vboltbbolt
+
+package vbolt
+
+import (
+	"io"
+	"os"
+
+	"github.com/boltdb/bolt"
+)
+
+type DB = bolt.DB
+type Tx = bolt.Tx
+type BBucket = bolt.Bucket
+type Cursor = bolt.Cursor
+type Options = bolt.Options
+
+const BackendName = "bolt"
+
+var ErrTxNotWritable = bolt.ErrTxNotWritable
+var ErrTxClosed = bolt.ErrTxClosed
+var ErrOpenTimeout = bolt.ErrTimeout
+
+func _OpenBackend(filename string, mode os.FileMode, options *Options) (*DB, error) {
+	return bolt.Open(filename, mode, options)
+}
+
+// only bbolt has a choice of freelist type
+func _SetFreelistType(options *Options, freelistType string) {}
+
+func _PageSize(db *DB) int {
+	return db.Info().PageSize
+}
+
+// the database file can be copied as is (see BackupHandler)
+const _CanWriteTo = true
+
+func _TxWriteTo(tx *Tx, w io.Writer) (int64, error) {
+	return tx.WriteTo(w)
+}
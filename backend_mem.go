@@ -0,0 +1,52 @@
+//go:build js || vboltmem
+
+package vbolt
+
+// bolt relies on mmap and file locks, which are not available on js/wasm, so
+// we use an in-memory store that implements the same api. Nothing is persisted.
+//
+// The in-memory store can also be selected with the vboltmem build tag, which
+// is useful for running tests without touching the disk:
+//
+//	go test -tags vboltmem ./...
+//
+// The backend is chosen for the whole build: DB and Tx are aliases, so bolt
+// and the in-memory store can't be mixed in one binary.
+
+import (
+	"io"
+	"os"
+
+	"go.hasen.dev/vbolt/memstore"
+)
+
+type DB = memstore.DB
+type Tx = memstore.Tx
+type BBucket = memstore.Bucket
+type Cursor = memstore.Cursor
+type Options = memstore.Options
+
+const BackendName = "memstore"
+
+var ErrTxNotWritable = memstore.ErrTxNotWritable
+var ErrTxClosed = memstore.ErrTxClosed
+var ErrOpenTimeout = memstore.ErrTimeout
+
+func _OpenBackend(filename string, mode os.FileMode, options *Options) (*DB, error) {
+	return memstore.Open(filename, mode, options)
+}
+
+// only bbolt has a choice of freelist type
+func _SetFreelistType(options *Options, freelistType string) {}
+
+// the in-memory store has no pages
+func _PageSize(db *DB) int {
+	return 0
+}
+
+// there's no file to copy; BackupHandler falls back to the backup format
+const _CanWriteTo = false
+
+func _TxWriteTo(tx *Tx, w io.Writer) (int64, error) {
+	return 0, nil
+}
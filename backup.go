@@ -3,16 +3,50 @@ package vbolt
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"time"
 
 	"go.hasen.dev/generic"
 )
 
 const BUCKET_HEADER byte = 0x01
 const ITEM_HEADER byte = 0x02
+const DELETE_HEADER byte = 0x03 // only in differential backups (BackupBucketsSince)
+const BUCKET_END byte = 0x04    // followed by the number of records in the bucket
+const CHECKSUM byte = 0x05      // followed by the sha256 of everything before it (including this byte)
+const FORMAT_HEADER byte = 0x06 // first in the stream, followed by the BackupHeader as json
+
+// Version 1 had no header, bucket counts, or checksum
+const BackupFormatVersion = 2
+
+// describes the backup; written at the start of the stream
+type BackupHeader struct {
+	Version  int
+	Created  time.Time
+	PageSize int // of the source database; 0 for the in-memory store
+
+	// the schema of the source, when known (BackupDB, BackupScrubbed);
+	// otherwise Buckets lists the buckets in the stream
+	Buckets     []string
+	Indexes     []string
+	Collections []string
+
+	// set for differential backups (BackupBucketsSince); Base is when the
+	// base backup was created, zero if it had no header
+	Delta bool
+	Base  time.Time
+
+	// the position of the journal when the backup was taken; see RestoreToPoint
+	JournalSeq uint64
+}
 
 func ChannelError(target *error, err error) {
 	if err != nil {
@@ -24,6 +58,10 @@ func ChannelError(target *error, err error) {
 type _BackupBuilder struct {
 	Output *bufio.Writer
 	Error  error
+
+	Hash     hash.Hash // of everything written so far
+	InBucket bool
+	Count    int // records written since the last bucket header
 }
 
 func _BackupIoWrite(builder *_BackupBuilder, p []byte) {
@@ -32,6 +70,7 @@ func _BackupIoWrite(builder *_BackupBuilder, p []byte) {
 	}
 	_, err := builder.Output.Write(p)
 	ChannelError(&builder.Error, err)
+	_BackupHash(builder).Write(p)
 }
 
 func _BackupWriteByte(builder *_BackupBuilder, b byte) {
@@ -40,7 +79,14 @@ func _BackupWriteByte(builder *_BackupBuilder, b byte) {
 	}
 	err := builder.Output.WriteByte(b)
 	ChannelError(&builder.Error, err)
-	// _BackupIoWrite(builder, []byte{b})
+	_BackupHash(builder).Write([]byte{b})
+}
+
+func _BackupHash(builder *_BackupBuilder) hash.Hash {
+	if builder.Hash == nil {
+		builder.Hash = sha256.New()
+	}
+	return builder.Hash
 }
 
 func _BackupWriteBuffer(builder *_BackupBuilder, buf []byte) {
@@ -49,27 +95,90 @@ func _BackupWriteBuffer(builder *_BackupBuilder, buf []byte) {
 	_BackupIoWrite(builder, buf)
 }
 
+func _BackupWriteFormatHeader(builder *_BackupBuilder, header *BackupHeader) {
+	data, err := json.Marshal(header)
+	ChannelError(&builder.Error, err)
+	_BackupWriteByte(builder, FORMAT_HEADER)
+	_BackupWriteBuffer(builder, data)
+}
+
+// the header for a backup of bucketNames from db, read through tx; dbInfo is optional
+func _NewBackupHeader(db *DB, tx *Tx, dbInfo *Info, bucketNames []string) *BackupHeader {
+	header := &BackupHeader{
+		Version:    BackupFormatVersion,
+		Created:    Now(),
+		PageSize:   _PageSize(db),
+		Buckets:    bucketNames,
+		JournalSeq: _JournalSeq(tx),
+	}
+	if dbInfo != nil {
+		header.Buckets = dbInfo.BucketList
+		header.Indexes = dbInfo.IndexList
+		header.Collections = dbInfo.CollectionList
+	}
+	return header
+}
+
 func _BackupWriteBucketHeader(builder *_BackupBuilder, bucketNameBytes []byte) {
+	_BackupWriteBucketEnd(builder)
 	_BackupWriteByte(builder, BUCKET_HEADER)
 	_BackupWriteBuffer(builder, bucketNameBytes)
+	builder.InBucket = true
 }
 
 func _BackupWriteItem(builder *_BackupBuilder, key []byte, value []byte) {
 	_BackupWriteByte(builder, ITEM_HEADER)
 	_BackupWriteBuffer(builder, key)
 	_BackupWriteBuffer(builder, value)
+	builder.Count++
+}
+
+func _BackupWriteDelete(builder *_BackupBuilder, key []byte) {
+	_BackupWriteByte(builder, DELETE_HEADER)
+	_BackupWriteBuffer(builder, key)
+	builder.Count++
+}
+
+func _BackupWriteBucketEnd(builder *_BackupBuilder) {
+	if !builder.InBucket {
+		return
+	}
+	_BackupWriteByte(builder, BUCKET_END)
+	_BackupWriteBuffer(builder, binary.AppendUvarint(nil, uint64(builder.Count)))
+	builder.InBucket = false
+	builder.Count = 0
+}
+
+// ends the last bucket, writes the checksum, and flushes
+func _BackupFinish(builder *_BackupBuilder) {
+	_BackupWriteBucketEnd(builder)
+	_BackupWriteByte(builder, CHECKSUM)
+	_BackupWriteBuffer(builder, _BackupHash(builder).Sum(nil))
+	if builder.Error == nil {
+		ChannelError(&builder.Error, builder.Output.Flush())
+	}
 }
 
 type _BackupReader struct {
-	Input *bytes.Reader
+	Input *bufio.Reader
 	Error error
+	Hash  hash.Hash // of everything read so far
+}
+
+// io.ByteReader, so binary.ReadUvarint goes through the hash too
+func (reader *_BackupReader) ReadByte() (byte, error) {
+	b, err := reader.Input.ReadByte()
+	if err == nil {
+		reader.Hash.Write([]byte{b})
+	}
+	return b, err
 }
 
 func _BackupReadByte(reader *_BackupReader) byte {
 	if reader.Error != nil {
 		return 0
 	}
-	b, err := reader.Input.ReadByte()
+	b, err := reader.ReadByte()
 	ChannelError(&reader.Error, err)
 	return b
 }
@@ -81,23 +190,204 @@ func _BackupReadBuffer(reader *_BackupReader) []byte {
 	if reader.Error != nil {
 		return nil
 	}
-	sizeu64, err := binary.ReadUvarint(reader.Input)
-	// fmt.Println("size:", sizeu64)
+	size, err := binary.ReadUvarint(reader)
 	ChannelError(&reader.Error, err)
-	size := int(sizeu64)
-	buffer := make([]byte, size)
-	n, err := reader.Input.Read(buffer)
+	if reader.Error != nil {
+		return nil
+	}
+	// read through a limit reader instead of allocating size bytes upfront, so
+	// a corrupt size does not allocate a huge buffer
+	buffer, err := io.ReadAll(io.LimitReader(reader.Input, int64(size)))
 	ChannelError(&reader.Error, err)
-	buffer = buffer[:n]
+	reader.Hash.Write(buffer)
+	if reader.Error == nil && uint64(len(buffer)) != size {
+		reader.Error = io.ErrUnexpectedEOF
+	}
 	return buffer
 }
 
-func BackupBuckets(db *DB, out *bufio.Writer, bucketNames ...string) error {
+type BackupRecord struct {
+	Kind   byte   // FORMAT_HEADER, BUCKET_HEADER, ITEM_HEADER or DELETE_HEADER
+	Bucket []byte // for items and deletes, the bucket they belong to
+	Key    []byte // items and deletes only
+	Value  []byte // items only
+
+	Header *BackupHeader // FORMAT_HEADER only
+}
+
+var ErrBackupFormat = errors.New("invalid backup format")
+var ErrBackupCorrupt = errors.New("backup is corrupt or truncated")
+var ErrBackupVersion = errors.New("unsupported backup format version")
+
+// ReadBackup parses a stream produced by BackupBuckets (or BackupBucketsGzip;
+// compressed streams are detected by their magic bytes), calling visit for every
+// bucket header and every item. Returns nil when the stream ends cleanly, or
+// the first error returned by visit.
+//
+// The buffers in the records are freshly allocated and owned by the caller.
+//
+// The bucket counts and the checksum at the end of the stream are verified as
+// they are reached, so a truncated or corrupt stream returns an error wrapping
+// ErrBackupCorrupt, but only after the records before the damage were visited.
+// Backups from older versions have neither and are read without checks.
+//
+// Backups from a newer format version are refused with ErrBackupVersion. The
+// header (absent from older backups) is passed to visit as a FORMAT_HEADER
+// record before anything else.
+func ReadBackup(r io.Reader, visit func(rec BackupRecord) error) error {
+	var reader _BackupReader
+	reader.Input = bufio.NewReader(r)
+	if magic, _ := reader.Input.Peek(len(_gzipMagic)); bytes.Equal(magic, _gzipMagic) {
+		gz, err := gzip.NewReader(reader.Input)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader.Input = bufio.NewReader(gz)
+	}
+	reader.Hash = sha256.New()
+	var bucketName []byte
+	var count int      // records since the bucket header
+	var hasCounts bool // older backups have no counts or checksum
+	for first := true; ; first = false {
+		b := _BackupReadByte(&reader)
+		if reader.Error == io.EOF {
+			if hasCounts {
+				return fmt.Errorf("%w: missing checksum", ErrBackupCorrupt)
+			}
+			return nil
+		}
+		var rec BackupRecord
+		rec.Kind = b
+		switch b {
+		case FORMAT_HEADER:
+			if !first {
+				return fmt.Errorf("%w: header in the middle of the stream", ErrBackupFormat)
+			}
+			data := _BackupReadBuffer(&reader)
+			if reader.Error == nil {
+				rec.Header = new(BackupHeader)
+				if err := json.Unmarshal(data, rec.Header); err != nil {
+					return fmt.Errorf("%w: header: %v", ErrBackupFormat, err)
+				}
+				if rec.Header.Version > BackupFormatVersion {
+					return fmt.Errorf("%w: %d (this version reads up to %d)", ErrBackupVersion, rec.Header.Version, BackupFormatVersion)
+				}
+			}
+			hasCounts = true
+		case BUCKET_HEADER:
+			bucketName = _BackupReadBuffer(&reader)
+			rec.Bucket = bucketName
+			count = 0
+		case BUCKET_END, CHECKSUM:
+			var expected []byte
+			if b == CHECKSUM {
+				expected = reader.Hash.Sum(nil)
+			} else {
+				expected = binary.AppendUvarint(nil, uint64(count))
+				hasCounts = true
+			}
+			if got := _BackupReadBuffer(&reader); reader.Error == nil && !bytes.Equal(got, expected) {
+				return fmt.Errorf("%w: bucket %q: count or checksum mismatch", ErrBackupCorrupt, bucketName)
+			}
+			if b == CHECKSUM && reader.Error == nil {
+				if _BackupReadByte(&reader); reader.Error != io.EOF {
+					return fmt.Errorf("%w: data after checksum", ErrBackupFormat)
+				}
+				return nil
+			}
+			if reader.Error == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			if reader.Error != nil {
+				return reader.Error
+			}
+			continue // not passed to visit
+		case ITEM_HEADER:
+			if bucketName == nil {
+				return fmt.Errorf("%w: item before bucket header", ErrBackupFormat)
+			}
+			rec.Bucket = bucketName
+			rec.Key = _BackupReadBuffer(&reader)
+			rec.Value = _BackupReadBuffer(&reader)
+			count++
+		case DELETE_HEADER:
+			if bucketName == nil {
+				return fmt.Errorf("%w: delete before bucket header", ErrBackupFormat)
+			}
+			rec.Bucket = bucketName
+			rec.Key = _BackupReadBuffer(&reader)
+			count++
+		default:
+			if reader.Error != nil {
+				return reader.Error
+			}
+			return fmt.Errorf("%w: unknown record header %d", ErrBackupFormat, b)
+		}
+		if reader.Error == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if reader.Error != nil {
+			return reader.Error
+		}
+		if err := visit(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// BackupBuckets streams the given buckets to out (a file, a network
+// connection, an upload body ..) in the backup format. Output is buffered
+// internally and flushed before returning, so out need not be buffered.
+func BackupBuckets(db *DB, out io.Writer, bucketNames ...string) error {
+	return _BackupBucketsCore(db, out, nil, bucketNames)
+}
+
+// the first bytes of a gzip stream; a plain backup starts with FORMAT_HEADER
+var _gzipMagic = []byte{0x1f, 0x8b}
+
+// BackupBucketsGzip is like BackupBuckets, but gzip compresses the stream.
+// RestoreBuckets and ReadBackup detect and decompress it automatically.
+func BackupBucketsGzip(db *DB, out io.Writer, bucketNames ...string) error {
+	zw := gzip.NewWriter(out)
+	err := _BackupBucketsCore(db, zw, nil, bucketNames)
+	ChannelError(&err, zw.Close())
+	return err
+}
+
+// BackupDB is BackupBuckets for all the buckets, indexes and collections of
+// dbInfo, which are also recorded in the header
+func BackupDB(db *DB, dbInfo *Info, out io.Writer) error {
+	var names []string
+	names = append(names, dbInfo.BucketList...)
+	names = append(names, dbInfo.IndexList...)
+	names = append(names, dbInfo.CollectionList...)
+	return _BackupBucketsCore(db, out, dbInfo, names)
+}
+
+// ReadBackupHeader reads only the header at the start of the backup. Returns
+// nil (without an error) for backups from before the header was added.
+func ReadBackupHeader(in io.Reader) (*BackupHeader, error) {
+	var header *BackupHeader
+	errStop := errors.New("stop")
+	err := ReadBackup(in, func(rec BackupRecord) error {
+		header = rec.Header
+		return errStop
+	})
+	if err == errStop {
+		err = nil
+	}
+	return header, err
+}
+
+// dbInfo is used to find scrubbers and for the header; nil writes the values as is
+func _BackupBucketsCore(db *DB, out io.Writer, dbInfo *Info, bucketNames []string) error {
 	tx := ReadTx(db)
 	defer TxClose(tx)
 
 	var backup _BackupBuilder
-	backup.Output = out
+	backup.Output = bufio.NewWriter(out) // reuses out if it's already a *bufio.Writer
+	_BackupWriteFormatHeader(&backup, _NewBackupHeader(db, tx, dbInfo, bucketNames))
 
 	for _, bucketName := range bucketNames {
 		if backup.Error != nil {
@@ -110,23 +400,123 @@ func BackupBuckets(db *DB, out *bufio.Writer, bucketNames ...string) error {
 			continue
 		}
 		_BackupWriteBucketHeader(&backup, bucketNameBytes)
+		scrub := _Scrubber(dbInfo, bucketName)
 		bkt.ForEach(func(key []byte, value []byte) error {
+			if scrub != nil {
+				value = scrub(key, value)
+			}
 			_BackupWriteItem(&backup, key, value)
 			return backup.Error
 		})
 	}
 
+	_BackupFinish(&backup)
+	if backup.Error == nil {
+		_lastBackupTime.Store(Now().Unix())
+	}
 	return backup.Error
 }
 
-func RestoreBuckets(db *DB, in *bytes.Reader) error {
-	var reader = new(_BackupReader)
-	reader.Input = in
-	var bucketName []byte
-	var key []byte
-	var value []byte
+// RestoreBuckets reads the backup from in as a stream (a file, an http
+// request body ..), committing every few thousand items, so the backup never
+// needs to fit in memory.
+//
+// Nothing is written unless the whole stream checks out (structure, bucket
+// counts and checksum). If in is an io.ReadSeeker, it's verified first and
+// then read again; otherwise it's staged in a temp database while it's
+// verified, and copied from there. A failure while writing to db (e.g. a
+// full disk) can still leave the batches before it committed.
+func RestoreBuckets(db *DB, in io.Reader) error {
+	if rs, ok := in.(io.ReadSeeker); ok {
+		start, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		if err = VerifyBackup(rs); err != nil {
+			return err
+		}
+		if _, err = rs.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		return _RestoreRecords(db, func(visit func(rec BackupRecord) error) error {
+			return ReadBackup(rs, visit)
+		})
+	}
 
-	tx := WriteTx(db)
+	stage, remove := OpenTemp()
+	defer remove()
+	buckets, err := _StageBackup(stage, in)
+	if err != nil {
+		return err
+	}
+	tx := ReadTx(stage)
+	defer TxClose(tx)
+	return _RestoreRecords(db, func(visit func(rec BackupRecord) error) error {
+		for _, name := range buckets {
+			if err := visit(BackupRecord{Kind: BUCKET_HEADER, Bucket: name}); err != nil {
+				return err
+			}
+			err := tx.Bucket(name).ForEach(func(key []byte, value []byte) error {
+				rec := BackupRecord{Kind: DELETE_HEADER, Bucket: name, Key: bytes.Clone(key)}
+				if value[0] == ITEM_HEADER {
+					rec.Kind = ITEM_HEADER
+					rec.Value = bytes.Clone(value[1:])
+				}
+				return visit(rec)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writes the records of the backup to the same buckets in stage, each value
+// prefixed by the kind of the record. Returns the buckets in stream order
+func _StageBackup(stage *DB, in io.Reader) (buckets [][]byte, err error) {
+	tx := WriteTx(stage)
+	defer func() { // the tx gets replaced after every batch
+		TxClose(tx)
+	}()
+
+	var bucket *BBucket
+	var writesCount int
+	const txThreshold = 1024 * 4
+
+	err = ReadBackup(in, func(rec BackupRecord) error {
+		switch rec.Kind {
+		case BUCKET_HEADER:
+			buckets = append(buckets, rec.Bucket)
+			bucket = TxRawBucket(tx, generic.UnsafeString(rec.Bucket))
+		case ITEM_HEADER, DELETE_HEADER:
+			if err := RawPut(bucket, rec.Key, append([]byte{rec.Kind}, rec.Value...)); err != nil {
+				return err
+			}
+			writesCount++
+			if writesCount > txThreshold {
+				if err := TxCommitE(tx); err != nil {
+					return err
+				}
+				tx = WriteTx(stage)
+				writesCount = 0
+				bucket = TxRawBucket(tx, generic.UnsafeString(rec.Bucket))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buckets, TxCommitE(tx)
+}
+
+// writes the records produced by read to db in batches
+func _RestoreRecords(db *DB, read func(visit func(rec BackupRecord) error) error) error {
+	tx, err := WriteTxE(db)
+	if err != nil {
+		return err
+	}
 	defer func() { // this is to prevent the defer from fixating on the current tx
 		// and allow it to work with whatever tx is at the end of the function ..
 		TxClose(tx)
@@ -138,47 +528,163 @@ func RestoreBuckets(db *DB, in *bytes.Reader) error {
 
 	var totalCount int
 
-	for {
-		b := _BackupReadByte(reader)
-		switch b {
+	err = read(func(rec BackupRecord) (err error) {
+		switch rec.Kind {
 		case BUCKET_HEADER:
-			// fmt.Println("Restoring bucket", generic.UnsafeString(bucketName))
-			bucketName = _BackupReadBuffer(reader)
-			bucket = TxRawBucket(tx, generic.UnsafeString(bucketName))
-		case ITEM_HEADER:
-			key = _BackupReadBuffer(reader)
-			value = _BackupReadBuffer(reader)
-			RawMustPut(bucket, key, value)
+			bucket = TxRawBucket(tx, generic.UnsafeString(rec.Bucket))
+		case ITEM_HEADER, DELETE_HEADER:
+			if rec.Kind == ITEM_HEADER {
+				err = RawPut(bucket, rec.Key, rec.Value)
+			} else {
+				err = _Del(tx, bucket, string(rec.Bucket), rec.Key)
+				_MarkDirty(tx, string(rec.Bucket))
+			}
+			if err != nil {
+				return err
+			}
 			totalCount++
 			writesCount++
-			fmt.Printf("%d     \r", totalCount)
 			if writesCount > txThreshold {
-				TxCommit(tx)
-				tx = WriteTx(db)
+				if err = TxCommitE(tx); err != nil {
+					return err
+				}
+				if tx, err = WriteTxE(db); err != nil {
+					return err
+				}
 				writesCount = 0
-				bucket = TxRawBucket(tx, generic.UnsafeString(bucketName))
+				bucket = TxRawBucket(tx, generic.UnsafeString(rec.Bucket))
 			}
-		default:
-			fmt.Println("Total restored items:", totalCount)
-			TxCommit(tx)
-			if reader.Error == io.EOF {
-				return nil
+		}
+		return nil
+	})
+	if err != nil {
+		// the last batch is dropped, but earlier ones are already committed
+		return err
+	}
+	if err = TxCommitE(tx); err != nil {
+		return err
+	}
+	Logf("vbolt: restored %d items into %s", totalCount, db.Path())
+	return nil
+}
+
+// VerifyBackup reads the whole backup and checks its bucket counts and
+// checksum, without writing anything.
+func VerifyBackup(in io.Reader) error {
+	return ReadBackup(in, func(rec BackupRecord) error { return nil })
+}
+
+type RestoreBucketReport struct {
+	Name      string
+	NewBucket bool // not in the database yet
+	Puts      int
+	Deletes   int // only in differential backups
+	Overwrite int // puts and deletes of keys that exist in the database with a different value
+}
+
+type RestoreReport struct {
+	Header  *BackupHeader // nil for older backups
+	Buckets []RestoreBucketReport
+	Total   int
+}
+
+// RestoreDryRun reads the whole backup like RestoreBuckets would, checking its
+// structure, counts and checksum, but only reports what would be written,
+// comparing against db in a read transaction. db can be nil to only check
+// the backup and count its records.
+//
+// The report covers what was read before any error.
+func RestoreDryRun(db *DB, in io.Reader) (report RestoreReport, err error) {
+	var tx *Tx
+	if db != nil {
+		tx = ReadTx(db)
+		defer TxClose(tx)
+	}
+
+	var bkt *BBucket
+	var current *RestoreBucketReport
+	err = ReadBackup(in, func(rec BackupRecord) error {
+		switch rec.Kind {
+		case FORMAT_HEADER:
+			report.Header = rec.Header
+		case BUCKET_HEADER:
+			bkt = nil
+			if tx != nil {
+				bkt = tx.Bucket(rec.Bucket)
+			}
+			report.Buckets = append(report.Buckets, RestoreBucketReport{
+				Name:      string(rec.Bucket),
+				NewBucket: tx != nil && bkt == nil,
+			})
+			current = &report.Buckets[len(report.Buckets)-1]
+		case ITEM_HEADER, DELETE_HEADER:
+			if rec.Kind == ITEM_HEADER {
+				current.Puts++
 			} else {
-				fmt.Println("Final value is:", b)
-				fmt.Println("Error:", reader.Error)
-				return reader.Error
+				current.Deletes++
+			}
+			report.Total++
+			if bkt != nil {
+				if existing := bkt.Get(rec.Key); existing != nil && (rec.Kind == DELETE_HEADER || !bytes.Equal(existing, rec.Value)) {
+					current.Overwrite++
+				}
 			}
 		}
-	}
+		return nil
+	})
+	return
+}
+
+// one line of DumpBucketJSON
+type JSONRecord[K, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
 }
 
+// DumpBucketJSON writes every record of the bucket as a line of json
+// ({"key": .., "value": ..}), each prefixed by label. With an empty label the
+// output is plain json lines that LoadBucketJSON can read back.
 func DumpBucketJSON[K, V any](db *DB, out *bufio.Writer, label string, bucket *BucketInfo[K, V]) {
 	tx := ReadTx(db)
 	defer TxClose(tx)
 	enc := json.NewEncoder(out)
 	IterateAll(tx, bucket, func(key K, value V) bool {
 		out.WriteString(label)
-		enc.Encode(value)
+		enc.Encode(JSONRecord[K, V]{Key: key, Value: value})
 		return true
 	})
 }
+
+// LoadBucketJSON writes the records produced by DumpBucketJSON (with an empty
+// label) to the bucket, committing in batches. Records that don't fit K and V
+// are reported in the result and skipped, while malformed json stops the load;
+// see ImportRecords.
+func LoadBucketJSON[K comparable, V any](db *DB, in io.Reader, bucket *BucketInfo[K, V]) (ImportResult, error) {
+	return ImportRecords(db, bucket, in, ImportJSON, func(row *ImportRow) (key K, value V, err error) {
+		var rec JSONRecord[K, V]
+		err = json.Unmarshal(row.JSON, &rec)
+		return rec.Key, rec.Value, err
+	}, 0, ImportOptions[K, V]{})
+}
+
+// DumpBucketCSV writes the bucket as csv: a header row with "key" followed by
+// the columns from headerFn, then a row for every record, with the key
+// (formatted with fmt) followed by the fields from rowFn.
+//
+// ImportRecords with ImportCSV reads it back, given a matching mapRow.
+func DumpBucketCSV[K comparable, V any](db *DB, out io.Writer, bucket *BucketInfo[K, V], headerFn func() []string, rowFn func(value *V) []string) error {
+	tx := ReadTx(db)
+	defer TxClose(tx)
+
+	w := csv.NewWriter(out)
+	err := w.Write(append([]string{"key"}, headerFn()...))
+	IterateAll(tx, bucket, func(key K, value V) bool {
+		if err == nil {
+			err = w.Write(append([]string{fmt.Sprint(key)}, rowFn(&value)...))
+		}
+		return err == nil
+	})
+	w.Flush()
+	ChannelError(&err, w.Error())
+	return err
+}
@@ -0,0 +1,109 @@
+package vbolt_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestBackupRestore(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+	tags := vbolt.Index(&dbInfo, "tags", vpack.StringZ, vpack.FInt)
+
+	db := vbolttest.NewTestDB(t, &dbInfo)
+	vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+		for id := 1; id <= 50; id++ {
+			content := "post " + string(rune('a'+id%26))
+			vbolt.Write(tx, posts, id, &content)
+			vbolt.SetTargetTermsPlain(tx, tags, id, []string{"all", content})
+		}
+	})
+
+	// flips the last byte, which is part of the checksum (or, for gzip, the
+	// compressed trailer)
+	corrupt := func(data []byte) []byte {
+		data = bytes.Clone(data)
+		data[len(data)-1] ^= 0xff
+		return data
+	}
+	// hides Seek, so RestoreBuckets has to stage the stream
+	plain := func(data []byte) io.Reader {
+		return struct{ io.Reader }{bytes.NewReader(data)}
+	}
+	seeker := func(data []byte) io.Reader {
+		return bytes.NewReader(data)
+	}
+
+	cases := []struct {
+		name    string
+		gzip    bool
+		reader  func(data []byte) io.Reader
+		corrupt bool
+	}{
+		{"plain", false, seeker, false},
+		{"plain staged", false, plain, false},
+		{"gzip", true, seeker, false},
+		{"gzip staged", true, plain, false},
+		{"corrupt", false, seeker, true},
+		{"corrupt staged", false, plain, true},
+		{"corrupt gzip", true, seeker, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			backup := vbolt.BackupBuckets
+			if c.gzip {
+				backup = vbolt.BackupBucketsGzip
+			}
+			if err := backup(db, &out, "posts", "tags"); err != nil {
+				t.Fatalf("backup: %v", err)
+			}
+			data := out.Bytes()
+			if c.corrupt {
+				data = corrupt(data)
+			}
+
+			target := vbolttest.NewTestDB(t, &dbInfo)
+			err := vbolt.RestoreBuckets(target, c.reader(data))
+			if c.corrupt {
+				if err == nil {
+					t.Fatalf("restored a corrupt backup")
+				}
+				if !c.gzip && !errors.Is(err, vbolt.ErrBackupCorrupt) {
+					t.Errorf("expected ErrBackupCorrupt, got: %v", err)
+				}
+				vbolttest.View(target, func(tx *vbolt.Tx) {
+					if bkt := vbolt.TxRawBucket(tx, "posts"); bkt != nil && bkt.Stats().KeyN > 0 {
+						t.Errorf("a corrupt backup committed %d items", bkt.Stats().KeyN)
+					}
+				})
+				return
+			}
+			if err != nil {
+				t.Fatalf("restore: %v", err)
+			}
+
+			vbolttest.View(target, func(tx *vbolt.Tx) {
+				for id := 1; id <= 50; id++ {
+					var content string
+					if !vbolt.Read(tx, posts, id, &content) || content != "post "+string(rune('a'+id%26)) {
+						t.Errorf("post %d: got %q", id, content)
+					}
+				}
+				var count int
+				term := "all"
+				vbolt.ReadTermCount(tx, tags, &term, &count)
+				if count != 50 {
+					t.Errorf("term count for %q: expected 50, got %d", term, count)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package vbolt
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+/*
+	Batch transactions coalesce the writes of many goroutines into fewer
+	commits (and fsyncs), using bolt's DB.Batch. The function passed to
+	WithBatchTx shares its transaction with other callers, and:
+
+	- may be called more than once: if any function in the batch fails, the
+	  batch is retried without it, and it's then run in its own transaction
+	- must not commit or roll back the transaction
+	- should only do small writes; the batch commits when it has
+	  db.MaxBatchSize calls or after db.MaxBatchDelay
+
+	WithBatchTx returns once the transaction containing fn's writes has
+	committed and its watchers have run (or with fn's error). Watchers, the
+	query cache, the IntegrityGuard (checked after each fn), and the journal
+	work as with TxCommit; the watchers of a batch run on the goroutine of one of its
+	callers, while the others wait.
+*/
+
+// the post-commit work of a batch tx, run once for all the callers whose
+// functions it committed
+type _BatchCommit struct {
+	once sync.Once
+	refs atomic.Int32 // callers whose function succeeded in the tx
+}
+
+var _batchCommits sync.Map // *Tx => *_BatchCommit
+
+func WithBatchTx(db *DB, fn func(tx *Tx) error) error {
+	var txs []*Tx                         // every tx fn ran in; only the last one can have committed
+	joined := make(map[*Tx]*_BatchCommit) // the txs fn succeeded in
+	err := func() error {
+		// held for the whole batch, since fn may run in more than one tx, but
+		// not while the watchers run, since they may write
+		gate := _WriteGate(db)
+		gate.RLock()
+		defer gate.RUnlock()
+
+		return db.Batch(func(tx *Tx) error {
+			txs = append(txs, tx)
+			if err := fn(tx); err != nil {
+				return err
+			}
+			if err := _CheckTouches(tx); err != nil {
+				return err
+			}
+			if err := _WriteJournal(tx); err != nil {
+				return err
+			}
+			value, _ := _batchCommits.LoadOrStore(tx, new(_BatchCommit))
+			joined[tx] = value.(*_BatchCommit)
+			joined[tx].refs.Add(1)
+			return nil
+		})
+	}()
+
+	for i, tx := range txs {
+		commit := joined[tx]
+		if err == nil && i == len(txs)-1 {
+			// the first caller to get here runs it, and the others wait for
+			// it, so the watchers have run when any of them returns
+			commit.once.Do(func() {
+				_BumpCommitted(tx)
+				_RunChanges(_TakeTxChanges(tx))
+				_ForgetTx(tx)
+			})
+		} else {
+			// rolled back, along with the changes queued in it
+			_ForgetTx(tx)
+		}
+		if commit != nil && commit.refs.Add(-1) == 0 {
+			_batchCommits.Delete(tx)
+		}
+	}
+	return err
+}
+
+// BatchWrite writes an item in a batch transaction; see WithBatchTx
+func BatchWrite[K comparable, T any](db *DB, bucketInfo *BucketInfo[K, T], id K, item *T) error {
+	return WithBatchTx(db, func(tx *Tx) error {
+		return WriteE(tx, bucketInfo, id, item)
+	})
+}
+
+// BatchDelete deletes an item in a batch transaction; see WithBatchTx
+func BatchDelete[K comparable, T any](db *DB, bucketInfo *BucketInfo[K, T], id K) error {
+	return WithBatchTx(db, func(tx *Tx) error {
+		return DeleteE(tx, bucketInfo, id)
+	})
+}
+
+// BatchSetTargetTerms updates a target's index terms in a batch transaction;
+// see WithBatchTx
+func BatchSetTargetTerms[K, T, P comparable](db *DB, indexInfo *IndexInfo[K, T, P], target K, terms map[T]P) error {
+	return WithBatchTx(db, func(tx *Tx) error {
+		return SetTargetTermsE(tx, indexInfo, target, terms)
+	})
+}
@@ -0,0 +1,114 @@
+package vbolt_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestWithBatchTx(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+
+	db := vbolttest.NewTestDB(t, &dbInfo)
+
+	var mu sync.Mutex
+	fired := make(map[int]int)
+	stop := vbolt.Watch(posts, func(id int, old *string, new *string) {
+		// slow enough for the other callers of the batch to return first, if
+		// they didn't wait
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		fired[id]++
+	})
+	defer stop()
+	firedFor := func(id int) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return fired[id]
+	}
+
+	const rounds = 20
+	const callers = 8
+	const failing = 3 // the caller whose function fails
+	errFail := errors.New("fail")
+
+	// every round's calls go in one batch, so the failing one rolls back the
+	// writes the others made before it
+	db.MaxBatchSize = callers
+	db.MaxBatchDelay = time.Second
+
+	var reruns atomic.Int32
+	for round := 0; round < rounds; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(id int, fail bool) {
+				defer wg.Done()
+				runs := 0
+				err := vbolt.WithBatchTx(db, func(tx *vbolt.Tx) error {
+					runs++
+					content := "post"
+					if err := vbolt.WriteE(tx, posts, id, &content); err != nil {
+						return err
+					}
+					if fail {
+						return errFail
+					}
+					return nil
+				})
+				if runs > 1 {
+					reruns.Add(1)
+				}
+				if fail {
+					if !errors.Is(err, errFail) {
+						t.Errorf("post %d: expected the function's error, got: %v", id, err)
+					}
+					// the in-memory store's Batch is just Update
+					if runs != 2 && vbolt.BackendName != "memstore" {
+						t.Errorf("post %d: expected the failing function to be retried alone once, ran %d times", id, runs)
+					}
+					return
+				}
+				if err != nil {
+					t.Errorf("post %d: %v", id, err)
+				}
+				// the watchers have run by the time any caller returns
+				if n := firedFor(id); n != 1 {
+					t.Errorf("post %d: watcher fired %d times on return, expected 1", id, n)
+				}
+			}(1+round*callers+i, i == failing)
+		}
+		wg.Wait()
+	}
+
+	if vbolt.BackendName != "memstore" && reruns.Load() <= rounds {
+		t.Errorf("expected the functions of failed batches to be rerun")
+	}
+
+	vbolttest.View(db, func(tx *vbolt.Tx) {
+		for round := 0; round < rounds; round++ {
+			for i := 0; i < callers; i++ {
+				id := 1 + round*callers + i
+				exists := vbolt.HasKey(tx, posts, id)
+				expected := 1
+				if i == failing {
+					expected = 0
+				}
+				if n := firedFor(id); n != expected {
+					t.Errorf("post %d: watcher fired %d times, expected %d", id, n, expected)
+				}
+				if exists != (expected == 1) {
+					t.Errorf("post %d: exists=%v after the batch", id, exists)
+				}
+			}
+		}
+	})
+}
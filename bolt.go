@@ -1,23 +1,104 @@
 package vbolt
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"sync"
 	"time"
 
-	"github.com/boltdb/bolt"
 	"go.hasen.dev/generic"
 )
 
-type DB = bolt.DB
-type Tx = bolt.Tx
-type BBucket = bolt.Bucket
-type Cursor = bolt.Cursor
+// The DB, Tx, BBucket, and Cursor types are defined by the backend:
+// bolt by default, or an in-memory store on js/wasm (see backend_*.go)
 
 func Open(filename string) *DB {
-	var options bolt.Options
-	options.Timeout = time.Second
-	options.InitialMmapSize = 1024 * 1024 * 1024
-	return generic.Must(bolt.Open(filename, 0644, &options))
+	return generic.Must(OpenWithOptions(filename, DefaultOpenOptions))
+}
+
+type OpenOptions struct {
+	// how long to wait for the file lock; 0 waits forever
+	Timeout time.Duration
+
+	// the initial size of the memory map; 0 lets bolt size it by the file.
+	// A large value avoids remapping (which waits for all read transactions)
+	// as the file grows, but reserves that much address space
+	InitialMmapSize int
+
+	// permissions of the file when it's created; 0 means 0644
+	FileMode os.FileMode
+
+	// skips fsync on commit; faster, but a crash can lose or corrupt data
+	NoSync bool
+
+	ReadOnly bool
+
+	// "array" or "hashmap"; only used by the bbolt backend (vboltbbolt tag)
+	FreelistType string
+}
+
+// the options used by Open
+var DefaultOpenOptions = OpenOptions{
+	Timeout:         time.Second,
+	InitialMmapSize: 1024 * 1024 * 1024,
+	FileMode:        0644,
+}
+
+// the options each db was opened with, so it can be reopened the same way
+// (see PromoteBranch)
+var _openOptions sync.Map // *DB => OpenOptions
+
+// OpenWithOptions is like Open, but with the given options instead of
+// DefaultOpenOptions, and returns the error instead of panicking
+func OpenWithOptions(filename string, opts OpenOptions) (*DB, error) {
+	var options Options
+	options.Timeout = opts.Timeout
+	options.InitialMmapSize = opts.InitialMmapSize
+	options.ReadOnly = opts.ReadOnly
+	if opts.FreelistType != "" {
+		_SetFreelistType(&options, opts.FreelistType)
+	}
+	mode := opts.FileMode
+	if mode == 0 {
+		mode = 0644
+	}
+	db, err := _OpenBackend(filename, mode, &options)
+	if err != nil {
+		return nil, err
+	}
+	db.NoSync = opts.NoSync
+	_openOptions.Store(db, opts)
+	return db, nil
+}
+
+// the options db was opened with; DefaultOpenOptions if it wasn't opened with
+// OpenWithOptions. NoSync is db's current setting
+func _OpenOptionsOf(db *DB) OpenOptions {
+	opts := DefaultOpenOptions
+	if value, ok := _openOptions.Load(db); ok {
+		opts = value.(OpenOptions)
+	}
+	opts.NoSync = db.NoSync
+	return opts
+}
+
+// Close closes db, and drops what vbolt keeps about it (its write gate,
+// open options, query cache generations and journal setting), which would
+// otherwise stay around for as long as the process runs. Prefer it to
+// db.Close() for databases that are opened and closed repeatedly.
+func Close(db *DB) error {
+	err := db.Close()
+	_ForgetDB(db)
+	return err
+}
+
+func _ForgetDB(db *DB) {
+	_writeGates.Delete(db)
+	_openOptions.Delete(db)
+	_generations.Delete(db)
+	_journaled.Delete(db)
 }
 
 func ReadTx(db *DB) *Tx {
@@ -27,18 +108,80 @@ func ReadTx(db *DB) *Tx {
 	return generic.Must(db.Begin(false))
 }
 
+// Every write tx holds its db's gate shared (RLock) from begin until it's
+// committed or closed. Holding it exclusively (Lock) waits for the current
+// write txs to finish and keeps new ones from starting, while bulk loading,
+// changing settings, or shutting down; see BulkLoad and InstallShutdownSnapshot
+var _writeGates sync.Map // *DB => *sync.RWMutex
+var _txGates sync.Map    // *Tx => the *sync.RWMutex it holds shared
+
+func _WriteGate(db *DB) *sync.RWMutex {
+	gate, ok := _writeGates.Load(db)
+	if !ok {
+		gate, _ = _writeGates.LoadOrStore(db, new(sync.RWMutex))
+	}
+	return gate.(*sync.RWMutex)
+}
+
 func WriteTx(db *DB) *Tx {
 	if db == nil {
 		return nil
 	}
-	return generic.Must(db.Begin(true))
+	return generic.Must(_BeginWriteTx(db))
+}
+
+// WriteTxE is like WriteTx but returns the error instead of panicking
+func WriteTxE(db *DB) (*Tx, error) {
+	return _BeginWriteTx(db)
+}
+
+func _BeginWriteTx(db *DB) (*Tx, error) {
+	gate := _WriteGate(db)
+	gate.RLock()
+	tx, err := _BeginWriteTxHeld(db)
+	if err != nil {
+		gate.RUnlock()
+		return nil, err
+	}
+	_txGates.Store(tx, gate)
+	return tx, nil
+}
+
+// for callers that hold the write gate exclusively
+func _BeginWriteTxHeld(db *DB) (*Tx, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+	_TrackTxOps(tx)
+	return tx, nil
+}
+
+// Writes queue per-tx state (watch callbacks, dirty structures for the query
+// cache, index touches for IntegrityGuard) that's only dropped by TxCommitE and
+// TxClose. A transaction begun some other way (db.Update, db.Begin) that
+// writes through vbolt must be finished with one of them, or the state leaks.
+func _ForgetTx(tx *Tx) {
+	_txTouches.Delete(tx)
+	_txDirty.Delete(tx)
+	_txChanges.Delete(tx)
+	_txJournal.Delete(tx)
+}
+
+func _ReleaseWriteGate(tx *Tx) {
+	if gate, ok := _txGates.LoadAndDelete(tx); ok {
+		gate.(*sync.RWMutex).RUnlock()
+	}
 }
 
 func TxClose(tx *Tx) {
 	if tx == nil {
 		return
 	}
+	_ForgetTx(tx)
+	_LogSlowTx(tx)
 	tx.Rollback()
+	_ReleaseWriteGate(tx)
 }
 
 func TxRawBucket(tx *Tx, name string) *BBucket {
@@ -47,20 +190,58 @@ func TxRawBucket(tx *Tx, name string) *BBucket {
 	if bkt == nil && tx.Writable() {
 		bkt = generic.Must(tx.CreateBucket(bname))
 	}
+	_JournalBucketName(tx, bkt, name)
 	return bkt
 }
 
+var ErrReadOnlyTx = errors.New("vbolt: write through a read-only transaction")
+var ErrBucketMissing = errors.New("vbolt: bucket does not exist")
+
+// like TxRawBucket, but panics with a descriptive error naming the bucket
+// instead of returning nil when the bucket can't be written to
+func _TxWriteBucket(tx *Tx, name string) *BBucket {
+	return generic.Must(_TxWriteBucketE(tx, name))
+}
+
+func _TxWriteBucketE(tx *Tx, name string) (*BBucket, error) {
+	if !tx.Writable() {
+		return nil, fmt.Errorf("%w: %q", ErrReadOnlyTx, name)
+	}
+	bname := generic.UnsafeStringBytes(name)
+	bkt := tx.Bucket(bname)
+	if bkt == nil {
+		var err error
+		if bkt, err = tx.CreateBucket(bname); err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrBucketMissing, name, err)
+		}
+	}
+	_JournalBucketName(tx, bkt, name)
+	return bkt, nil
+}
+
 func WithReadTx(db *DB, fn func(tx *Tx)) {
 	tx := ReadTx(db)
 	defer TxClose(tx)
 	fn(tx)
 }
 
+// TxCommit commits the transaction. It panics if IntegrityGuard rejects the
+// commit; other errors are ignored (use TxCommitE to get them)
 func TxCommit(tx *Tx) {
-	if tx == nil {
-		return
+	if err := TxCommitE(tx); errors.Is(err, ErrIntegrity) {
+		panic(err)
+	}
+}
+
+// CrashHook, if set, is called at points where simulating a crash is
+// interesting (before and after commits, which includes the batches of
+// RestoreBuckets). It's meant for tests; see vbolttest.SimulateCrashes
+var CrashHook func(point string)
+
+func _CrashPoint(point string) {
+	if CrashHook != nil {
+		CrashHook(point)
 	}
-	tx.Commit()
 }
 
 // WithWriteTx calls supplied function with a writeable transaction
@@ -81,7 +262,7 @@ type Info struct {
 }
 
 func EnsureBuckets(tx *Tx, dbInfo *Info) {
-	generic.MustTrue(tx.Writable(), bolt.ErrTxNotWritable)
+	generic.MustTrue(tx.Writable(), ErrTxNotWritable)
 	for _, name := range dbInfo.BucketList {
 		TxRawBucket(tx, name)
 	}
@@ -94,11 +275,14 @@ func EnsureBuckets(tx *Tx, dbInfo *Info) {
 }
 
 // Some helpers that most apps will need
+
+// WarmTheCache reads every bucket so its pages are in the OS cache. See also
+// WarmTheCacheExt
 func WarmTheCache(tx *Tx, dbInfo *Info) {
 	// TODO: re-enable the profiler
 	// p.Start(string(bucketName))
 	// defer p.Stop()
-	readAll := func(name []byte, b *bolt.Bucket) error {
+	readAll := func(name []byte, b *BBucket) error {
 		log.Println("preloading", string(name))
 		// we don't have nested bucket so we don't need to worry about them
 		b.ForEach(func(k, v []byte) error {
@@ -0,0 +1,35 @@
+package vbolt
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCloseForgetsDB(t *testing.T) {
+	NewQueryCache(0, 0) // so writes bump the generations
+	db, remove := OpenTemp()
+	EnableJournal(db)
+	WithWriteTx(db, func(tx *Tx) {
+		RawMustPut(TxRawBucket(tx, "items"), []byte("key"), []byte("value"))
+		TxCommit(tx)
+	})
+	BulkLoad(db, func(tx *Tx) (bool, error) { return false, nil })
+
+	maps := map[string]*sync.Map{
+		"write gates":  &_writeGates,
+		"open options": &_openOptions,
+		"generations":  &_generations,
+		"journaled":    &_journaled,
+	}
+	for name, m := range maps {
+		if _, ok := m.Load(db); !ok {
+			t.Fatalf("%s: the db is not there to begin with", name)
+		}
+	}
+	remove()
+	for name, m := range maps {
+		if _, ok := m.Load(db); ok {
+			t.Errorf("%s: the db is still there after closing it", name)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+//go:build !js && !vboltmem
+
+package vbolt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+/*
+	A branch is a copy of the database file, taken from a consistent view and
+	opened as its own *DB, so a migration can be tried against real data
+	without touching the original:
+
+		branch := generic.Must(Branch(db, "app.db.branch"))
+		ApplyDBProcess(branch, "reindex-v2", ...)
+		DiffDB(db, branch, visit)
+		DiscardBranch(branch) // or: db, err = PromoteBranch(db, branch)
+
+	Promoting replaces the original file with the branch. It fails with
+	ErrBranchStale if the original had commits after the branch was taken,
+	since those would be lost.
+
+	WhatIf does the branch / migrate / diff / discard sequence in one call.
+*/
+
+var ErrNotBranch = errors.New("vbolt: not a branch")
+var ErrBranchStale = errors.New("vbolt: original changed since branching")
+
+type _BranchOrigin struct {
+	Source *DB
+	TxID   int // last committed tx of the source when branching
+}
+
+var _branches sync.Map // branch *DB => _BranchOrigin
+
+// Branch copies db to path and opens the copy
+func Branch(db *DB, path string) (*DB, error) {
+	var origin _BranchOrigin
+	origin.Source = db
+	var err error
+	WithReadTx(db, func(tx *Tx) {
+		origin.TxID = tx.ID()
+		err = _WriteCopy(tx, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var options Options
+	options.Timeout = time.Second
+	branch, err := _OpenBackend(path, 0644, &options)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	_branches.Store(branch, origin)
+	return branch, nil
+}
+
+// DiscardBranch closes the branch and deletes its file
+func DiscardBranch(branch *DB) error {
+	if _, ok := _branches.LoadAndDelete(branch); !ok {
+		return ErrNotBranch
+	}
+	path := branch.Path()
+	err := Close(branch)
+	if rmErr := os.Remove(path); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// PromoteBranch replaces the original database file with the branch, closing
+// both, and returns the database reopened with the options db was opened
+// with. If the original is stale, or can't be closed, nothing is changed and
+// db is returned along with the error. If the branch can't be closed or
+// moved, the original is reopened and returned along with the error.
+//
+// Writes are blocked on db while promoting.
+func PromoteBranch(db *DB, branch *DB) (*DB, error) {
+	value, ok := _branches.Load(branch)
+	if !ok || value.(_BranchOrigin).Source != db {
+		return db, ErrNotBranch
+	}
+	origin := value.(_BranchOrigin)
+
+	gate := _WriteGate(db)
+	gate.Lock()
+	defer gate.Unlock()
+
+	var current int
+	WithReadTx(db, func(tx *Tx) {
+		current = tx.ID()
+	})
+	if current != origin.TxID {
+		return db, fmt.Errorf("%w: %s", ErrBranchStale, db.Path())
+	}
+
+	path := db.Path()
+	branchPath := branch.Path()
+	opts := _OpenOptionsOf(db)
+	if err := db.Close(); err != nil {
+		return db, err
+	}
+	_ForgetDB(db)
+	if err := Close(branch); err != nil {
+		reopened, openErr := OpenWithOptions(path, opts)
+		return reopened, errors.Join(err, openErr)
+	}
+	_branches.Delete(branch)
+	if err := os.Rename(branchPath, path); err != nil {
+		reopened, openErr := OpenWithOptions(path, opts)
+		return reopened, errors.Join(err, openErr)
+	}
+	return OpenWithOptions(path, opts)
+}
+
+// WhatIf runs migrate on a branch of db at path, reports the differences it
+// made to visit, and discards the branch
+func WhatIf(db *DB, path string, migrate func(branch *DB), visit func(d RawDiff) bool) error {
+	branch, err := Branch(db, path)
+	if err != nil {
+		return err
+	}
+	defer DiscardBranch(branch)
+	migrate(branch)
+	DiffDB(db, branch, visit)
+	return nil
+}
@@ -0,0 +1,112 @@
+//go:build !js && !vboltmem
+
+package vbolt_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestPromoteBranch(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+
+	write := func(db *vbolt.DB, id int, content string) {
+		vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+			vbolt.Write(tx, posts, id, &content)
+		})
+	}
+	read := func(db *vbolt.DB, id int) (content string) {
+		vbolttest.View(db, func(tx *vbolt.Tx) {
+			vbolt.Read(tx, posts, id, &content)
+		})
+		return
+	}
+
+	t.Run("stale", func(t *testing.T) {
+		db := vbolttest.NewTestDB(t, &dbInfo)
+		write(db, 1, "original")
+		branchPath := filepath.Join(t.TempDir(), "branch.db")
+		branch, err := vbolt.Branch(db, branchPath)
+		if err != nil {
+			t.Fatalf("branch failed: %v", err)
+		}
+		write(branch, 1, "branched")
+		write(db, 2, "committed after branching")
+
+		promoted, err := vbolt.PromoteBranch(db, branch)
+		if !errors.Is(err, vbolt.ErrBranchStale) {
+			t.Fatalf("expected ErrBranchStale, got: %v", err)
+		}
+		if promoted != db {
+			t.Fatalf("expected the original back, got: %p", promoted)
+		}
+
+		// nothing changed: both are still open and the branch can be discarded
+		if content := read(db, 2); content != "committed after branching" {
+			t.Errorf("original lost its commit: %q", content)
+		}
+		write(db, 3, "still writable")
+		if content := read(branch, 1); content != "branched" {
+			t.Errorf("branch: %q", content)
+		}
+		if err := vbolt.DiscardBranch(branch); err != nil {
+			t.Errorf("discard failed: %v", err)
+		}
+		if _, err := os.Stat(branchPath); !os.IsNotExist(err) {
+			t.Errorf("the branch file is still there: %v", err)
+		}
+	})
+
+	t.Run("promote", func(t *testing.T) {
+		db := vbolttest.NewTestDB(t, &dbInfo)
+		write(db, 1, "original")
+		path := db.Path()
+		branchPath := filepath.Join(t.TempDir(), "branch.db")
+		branch, err := vbolt.Branch(db, branchPath)
+		if err != nil {
+			t.Fatalf("branch failed: %v", err)
+		}
+		write(branch, 1, "branched")
+		write(branch, 2, "new in the branch")
+
+		other := vbolttest.NewTestDB(t, &dbInfo)
+		if same, err := vbolt.PromoteBranch(other, branch); !errors.Is(err, vbolt.ErrNotBranch) || same != other {
+			t.Fatalf("promoting onto another db: %v", err)
+		}
+
+		// reopened with the settings of the original
+		db.NoSync = true
+		promoted, err := vbolt.PromoteBranch(db, branch)
+		if err != nil {
+			t.Fatalf("promote failed: %v", err)
+		}
+		t.Cleanup(func() { promoted.Close() })
+
+		if promoted.Path() != path {
+			t.Errorf("promoted database at %s, expected %s", promoted.Path(), path)
+		}
+		if !promoted.NoSync {
+			t.Errorf("promoted database lost NoSync")
+		}
+		if content := read(promoted, 1); content != "branched" {
+			t.Errorf("post 1: %q", content)
+		}
+		if content := read(promoted, 2); content != "new in the branch" {
+			t.Errorf("post 2: %q", content)
+		}
+		write(promoted, 3, "after promoting")
+		if _, err := os.Stat(branchPath); !os.IsNotExist(err) {
+			t.Errorf("the branch file is still there: %v", err)
+		}
+		if err := vbolt.DiscardBranch(branch); !errors.Is(err, vbolt.ErrNotBranch) {
+			t.Errorf("the promoted branch is still registered: %v", err)
+		}
+	})
+}
@@ -9,9 +9,59 @@ type BucketInfo[K, T any] struct {
 	Name        string
 	KeyPackFn   vpack.PackFn[K]
 	ValuePackFn vpack.PackFn[T]
+
+	// Strict makes reads and iteration panic (with an error wrapping
+	// ErrDecode) on records that don't decode cleanly, instead of yielding a
+	// partially filled value. If OnDecodeError is set, it's called instead of
+	// panicking, and the record is treated as missing.
+	Strict        bool
+	OnDecodeError func(key []byte, err error)
+
+	// checked by Write before writing; see AddConstraints
+	Constraints []Constraint[K, T]
+
+	// the value for ids that have no record; see ReadOrDefault and GetOrCreate
+	Default func(id K) T
+
+	// set by TrackTimestamps
+	Meta *BucketInfo[K, RecordMeta]
+
+	// redacts a record for BackupScrubbed and ExportScrubbed
+	Scrub func(id K, item *T)
+}
+
+// decodes a record according to the bucket's strictness. returns false if the
+// record should be treated as missing
+func _DecodeRecord[K, T any](info *BucketInfo[K, T], key []byte, value []byte, itemKey *K, item *T) bool {
+	_CountRead(info.Name, len(value))
+	if !info.Strict {
+		// best effort: the record is visited whether it decodes or not
+		if itemKey != nil {
+			vpack.FromBytesInto(key, itemKey, info.KeyPackFn)
+		}
+		vpack.FromBytesInto(value, item, info.ValuePackFn)
+		return true
+	}
+	var err error
+	if itemKey != nil {
+		err = _DecodeInto(key, itemKey, info.KeyPackFn)
+	}
+	if err == nil {
+		err = _DecodeInto(value, item, info.ValuePackFn)
+	}
+	if err == nil {
+		return true
+	}
+	err = &RecordError{Op: "decode", Structure: info.Name, Key: _PrintableKey(info.KeyPackFn, key), Err: err}
+	if info.OnDecodeError == nil {
+		panic(err)
+	}
+	info.OnDecodeError(key, err)
+	return false
 }
 
 func Bucket[K, T any](dbInfo *Info, name string, keyFn vpack.PackFn[K], serFn vpack.PackFn[T]) *BucketInfo[K, T] {
+	_WarnIfRegistered(dbInfo, name)
 	generic.Append(&dbInfo.BucketList, name)
 	generic.EnsureMapNotNil(&dbInfo.Infos)
 	result := &BucketInfo[K, T]{
@@ -33,20 +83,92 @@ func Read[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K, item
 	return _Read(bkt, bucketInfo, id, item)
 }
 
-func _Read[K comparable, T any](bkt *BBucket, bucketInfo *BucketInfo[K, T], id K, item *T) bool {
+// ReadRaw returns the encoded value stored at id, without decoding it. The
+// value is copied into buf if it fits (otherwise into a new slice), so it
+// stays valid after the tx is closed.
+func ReadRaw[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K, buf []byte) ([]byte, bool) {
+	bkt := TxRawBucket(tx, bucketInfo.Name)
 	if bkt == nil {
-		return false
+		return nil, false
+	}
+	data := bkt.Get(vpack.ToBytes(&id, bucketInfo.KeyPackFn))
+	if data == nil {
+		return nil, false
+	}
+	_CountRead(bucketInfo.Name, len(data))
+	return append(buf[:0], data...), true
+}
+
+// Get is like Read but returns the item. If the item is not found, the zero
+// value is returned (never a partially decoded one)
+func Get[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K) (item T, ok bool) {
+	if ok = Read(tx, bucketInfo, id, &item); !ok {
+		var zero T
+		item = zero
+	}
+	return
+}
+
+func _DefaultValue[K comparable, T any](bucketInfo *BucketInfo[K, T], id K) (item T) {
+	if bucketInfo.Default != nil {
+		item = bucketInfo.Default(id)
+	}
+	return
+}
+
+// ReadOrDefault returns the item stored at id, or the bucket's default value
+// for id if there's none (the zero value if the bucket has no Default)
+func ReadOrDefault[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K) T {
+	item, ok := Get(tx, bucketInfo, id)
+	if !ok {
+		item = _DefaultValue(bucketInfo, id)
+	}
+	return item
+}
+
+// GetOrCreate returns the item stored at id. If there's none, the bucket's
+// default value for id is written and returned, with created set.
+//
+// Nothing can be stored at the zero id, so it fails with ErrZeroKey.
+func GetOrCreate[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K) (item T, created bool, err error) {
+	var zero K
+	if id == zero {
+		return item, false, _RecordErr("write", bucketInfo.Name, id, ErrZeroKey)
+	}
+	if item, ok := Get(tx, bucketInfo, id); ok {
+		return item, false, nil
+	}
+	item = _DefaultValue(bucketInfo, id)
+	if err = WriteE(tx, bucketInfo, id, &item); err != nil {
+		return
 	}
+	return item, true, nil
+}
+
+func _Read[K comparable, T any](bkt *BBucket, bucketInfo *BucketInfo[K, T], id K, item *T) bool {
 	var zero K
 	if id == zero {
 		return false
 	}
+	return _ReadKey(bkt, bucketInfo, id, item)
+}
+
+// _Read without the zero id check, for callers that have done it already
+func _ReadKey[K, T any](bkt *BBucket, bucketInfo *BucketInfo[K, T], id K, item *T) bool {
+	if bkt == nil {
+		return false
+	}
 	key := vpack.ToBytes(&id, bucketInfo.KeyPackFn)
 	data := bkt.Get(key)
 	if data == nil {
 		return false
 	}
-	return vpack.FromBytesInto(data, item, bucketInfo.ValuePackFn)
+	if !bucketInfo.Strict {
+		// unlike iteration, a read reports whether the value decoded
+		_CountRead(bucketInfo.Name, len(data))
+		return vpack.FromBytesInto(data, item, bucketInfo.ValuePackFn)
+	}
+	return _DecodeRecord(bucketInfo, key, data, nil, item)
 }
 
 // ReadSlice reads objects given by ids, appending them to the given slice.
@@ -67,9 +189,9 @@ func ReadSlice[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], ids []
 	return count
 }
 
-// ReadSliceToMap reads objects given by id into the given map.
+// ReadSliceToMap reads objects given by id into the given map, allocating it if nil.
 // returns the number of objects that were successfully read
-func ReadSliceToMap[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], ids []K, itemsMap map[K]T) int {
+func ReadSliceToMap[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], ids []K, itemsMap *map[K]T) int {
 	bkt := TxRawBucket(tx, bucketInfo.Name)
 	if bkt == nil {
 		return 0
@@ -78,7 +200,8 @@ func ReadSliceToMap[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], i
 	for _, id := range ids {
 		var item T
 		if _Read(bkt, bucketInfo, id, &item) {
-			itemsMap[id] = item
+			generic.EnsureMapNotNil(itemsMap)
+			(*itemsMap)[id] = item
 			count++
 		}
 	}
@@ -96,10 +219,12 @@ func ReadAppend[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K,
 	}
 }
 
-func ReadToMap[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K, itemsMap map[K]T) bool {
+// ReadToMap reads the object into the given map, allocating it if nil
+func ReadToMap[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K, itemsMap *map[K]T) bool {
 	var item T
 	if Read(tx, bucketInfo, id, &item) {
-		itemsMap[id] = item
+		generic.EnsureMapNotNil(itemsMap)
+		(*itemsMap)[id] = item
 		return true
 	} else {
 		return false
@@ -108,48 +233,161 @@ func ReadToMap[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K,
 
 // Writes an item to a key. Note: does not write anything if id is the zero value
 func Write[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K, item *T) {
+	generic.MustOK(WriteE(tx, bucketInfo, id, item))
+}
+
+// WriteE is like Write but returns errors (e.g. read-only tx, disk full,
+// constraint violations) instead of panicking
+func WriteE[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K, item *T) error {
 	var zero K
 	if id == zero {
-		return
+		return nil
+	}
+	return _Write(tx, bucketInfo, id, item)
+}
+
+// WriteE without the zero id check
+func _Write[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K, item *T) error {
+	bkt, err := _TxWriteBucketE(tx, bucketInfo.Name)
+	if err != nil {
+		return _RecordErr("write", bucketInfo.Name, id, err)
 	}
-	bkt := TxRawBucket(tx, bucketInfo.Name)
 	key := vpack.ToBytes(&id, bucketInfo.KeyPackFn)
 	data := vpack.ToBytes(item, bucketInfo.ValuePackFn)
-	RawMustPut(bkt, key, data)
+	if err = _CheckConstraints(tx, bucketInfo, id, item, data); err != nil {
+		return err
+	}
+	watch := _Watching(bucketInfo)
+	var old *T
+	if watch != nil {
+		old = watch._Old(bkt, bucketInfo, key)
+	}
+	if err = _Put(tx, bkt, bucketInfo.Name, key, data); err != nil {
+		return _RecordErr("write", bucketInfo.Name, id, err)
+	}
+	if err = _TouchMeta(tx, bucketInfo, id); err != nil {
+		return _RecordErr("write", bucketInfo.Name, id, err)
+	}
+	_CountWrite(bucketInfo.Name, len(key)+len(data))
+	_MarkDirty(tx, bucketInfo.Name)
+	if watch != nil {
+		watch._Queue(tx, id, old, item)
+	}
+	return nil
 }
 
 func Delete[K, T any](tx *Tx, info *BucketInfo[K, T], id K) {
-	bkt := TxRawBucket(tx, info.Name)
+	generic.MustOK(DeleteE(tx, info, id))
+}
+
+// DeleteE is like Delete but returns errors instead of panicking
+func DeleteE[K, T any](tx *Tx, info *BucketInfo[K, T], id K) error {
+	bkt, err := _TxWriteBucketE(tx, info.Name)
+	if err != nil {
+		return _RecordErr("delete", info.Name, id, err)
+	}
 	key := vpack.ToBytes(&id, info.KeyPackFn)
-	bkt.Delete(key)
+	watch := _Watching(info)
+	var old *T
+	if watch != nil {
+		old = watch._Old(bkt, info, key)
+	}
+	if err = _Del(tx, bkt, info.Name, key); err != nil {
+		return _RecordErr("delete", info.Name, id, err)
+	}
+	if err = _DeleteMeta(tx, info, id); err != nil {
+		return _RecordErr("delete", info.Name, id, err)
+	}
+	_CountDelete(info.Name)
+	_MarkDirty(tx, info.Name)
+	if watch != nil && old != nil {
+		watch._Queue(tx, id, old, nil)
+	}
+	return nil
 }
 
 func NextIntId[K, T any](tx *Tx, info *BucketInfo[K, T]) int {
-	bkt := TxRawBucket(tx, info.Name)
-	return int(RawNextSequence(bkt))
+	return generic.Must(NextIntIdE(tx, info))
+}
+
+// NextIntIdE is like NextIntId but returns errors instead of panicking
+func NextIntIdE[K, T any](tx *Tx, info *BucketInfo[K, T]) (int, error) {
+	bkt, err := _TxWriteBucketE(tx, info.Name)
+	if err != nil {
+		return 0, err
+	}
+	seq, err := RawNextSequenceE(bkt)
+	return int(seq), err
+}
+
+func _IterateAllCore[K, T any](bkt *BBucket, bucketInfo *BucketInfo[K, T], window Window, visitFn func(key K, item T) bool) Continuation {
+	return _IterateAllPrefix(bkt, bucketInfo, nil, window, visitFn)
 }
 
-func _IterateAllCore[K, T any](bkt *BBucket, bucketInfo *BucketInfo[K, T], direction IterationDirection, visitFn func(key K, item T) bool) {
+func _IterateAllPrefix[K, T any](bkt *BBucket, bucketInfo *BucketInfo[K, T], prefix []byte, window Window, visitFn func(key K, item T) bool) Continuation {
+	if bkt == nil {
+		return nil
+	}
 	var iterParams _RawIterationParams
-	iterParams.Direction = direction
+	iterParams.Name = bucketInfo.Name
+	iterParams.Prefix = prefix
+	iterParams.Window = window
 
-	_RawIterateCore(bkt, iterParams, func(key []byte, value []byte) bool {
+	return _RawIterateCore(bkt, iterParams, func(key []byte, value []byte) bool {
 		var itemKey K
 		var item T
-		vpack.FromBytesInto(key, &itemKey, bucketInfo.KeyPackFn)
-		vpack.FromBytesInto(value, &item, bucketInfo.ValuePackFn)
+		if !_DecodeRecord(bucketInfo, key, value, &itemKey, &item) {
+			return true
+		}
 		return visitFn(itemKey, item)
 	})
 }
 
-func IterateAll[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], visitFn func(key K, item T) bool) {
+func IterateAll[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], visitFn func(key K, item T) bool, opts ...IterOption) Continuation {
 	bkt := TxRawBucket(tx, bucketInfo.Name)
-	_IterateAllCore(bkt, bucketInfo, IterateRegular, visitFn)
+	o := _IterOptions(Window{Direction: IterateRegular}, opts)
+	return _IterateAllPrefix(bkt, bucketInfo, o.Prefix, o.Window, visitFn)
 }
 
-func IterateAllReverse[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], visitFn func(key K, item T) bool) {
+func IterateAllReverse[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], visitFn func(key K, item T) bool) Continuation {
 	bkt := TxRawBucket(tx, bucketInfo.Name)
-	_IterateAllCore(bkt, bucketInfo, IterateReverse, visitFn)
+	return _IterateAllCore(bkt, bucketInfo, Window{Direction: IterateReverse}, visitFn)
+}
+
+// IterateAllWindow is like IterateAll but only visits the records in the
+// window; use the returned Continuation to get the next page
+func IterateAllWindow[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], window Window, visitFn func(key K, item T) bool) Continuation {
+	bkt := TxRawBucket(tx, bucketInfo.Name)
+	return _IterateAllCore(bkt, bucketInfo, window, visitFn)
+}
+
+// IterateAllTolerant is like IterateAll, but records whose key or value does
+// not decode cleanly are passed to onError instead of visitFn. onError returns
+// true to skip the record and continue, or false to stop the iteration.
+//
+// Useful for scanning buckets after a schema change that not all records
+// have been migrated to.
+func IterateAllTolerant[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], direction IterationDirection, onError func(key []byte, err error) bool, visitFn func(key K, item T) bool) {
+	bkt := TxRawBucket(tx, bucketInfo.Name)
+	if bkt == nil {
+		return
+	}
+	var iterParams _RawIterationParams
+	iterParams.Name = bucketInfo.Name
+	iterParams.Direction = direction
+
+	_RawIterateCore(bkt, iterParams, func(key []byte, value []byte) bool {
+		var itemKey K
+		var item T
+		err := _DecodeInto(key, &itemKey, bucketInfo.KeyPackFn)
+		if err == nil {
+			err = _DecodeInto(value, &item, bucketInfo.ValuePackFn)
+		}
+		if err != nil {
+			return onError(key, err)
+		}
+		return visitFn(itemKey, item)
+	})
 }
 
 func IterateInBatches[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], batchSize int, visitFn func(items []T) bool) {
@@ -165,10 +403,14 @@ func IterateInBatches[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], batchSize
 	}
 }
 
+// ScanList appends up to count items to items, starting at startKey, and
+// returns the key to start the next page from. ScanListPage does the same
+// with a Continuation, like the other iteration functions.
 func ScanList[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], startKey K, count int, items *[]T) (nextKey K, done bool) {
 	bkt := TxRawBucket(tx, bucketInfo.Name)
 
 	var iterParams _RawIterationParams
+	iterParams.Name = bucketInfo.Name
 	iterParams.Prefix = []byte{}
 	iterParams.Cursor = vpack.ToBytes(&startKey, bucketInfo.KeyPackFn)
 	iterParams.Direction = IterateRegular
@@ -176,8 +418,9 @@ func ScanList[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], startKey K, count
 
 	nextKeyBytes := _RawIterateCore(bkt, iterParams, func(key []byte, value []byte) bool {
 		var item T
-		vpack.FromBytesInto(value, &item, bucketInfo.ValuePackFn)
-		generic.Append(items, item)
+		if _DecodeRecord(bucketInfo, key, value, nil, &item) {
+			generic.Append(items, item)
+		}
 		return true
 	})
 	done = nextKeyBytes == nil
@@ -187,18 +430,31 @@ func ScanList[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], startKey K, count
 	return
 }
 
+// ScanListPage appends the items visited by IterateAll with the same options
+// to items, and returns the continuation for the next page:
+//
+//	next := vbolt.ScanListPage(tx, Posts, &items, vbolt.WithLimit(50), vbolt.From(cursor))
+func ScanListPage[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], items *[]T, opts ...IterOption) Continuation {
+	return IterateAll(tx, bucketInfo, func(key K, item T) bool {
+		generic.Append(items, item)
+		return true
+	}, opts...)
+}
+
 // IterateBucketFrom lets you specify the starting key using the userspace key type
-func IterateBucketFrom[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], startKey K, visitFn func(key K, value T) bool) []byte {
+func IterateBucketFrom[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], startKey K, visitFn func(key K, value T) bool) Continuation {
 	bkt := TxRawBucket(tx, bucketInfo.Name)
 
 	var iterParams _RawIterationParams
+	iterParams.Name = bucketInfo.Name
 	iterParams.Prefix = vpack.ToBytes(&startKey, bucketInfo.KeyPackFn)
 
 	return _RawIterateCore(bkt, iterParams, func(key []byte, value []byte) bool {
 		var itemKey K
 		var item T
-		vpack.FromBytesInto(key, &itemKey, bucketInfo.KeyPackFn)
-		vpack.FromBytesInto(value, &item, bucketInfo.ValuePackFn)
+		if !_DecodeRecord(bucketInfo, key, value, &itemKey, &item) {
+			return true
+		}
 		return visitFn(itemKey, item)
 	})
 }
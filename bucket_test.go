@@ -0,0 +1,223 @@
+package vbolt_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestReadToMap(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+
+	db := vbolttest.NewTestDB(t, &dbInfo)
+	vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+		for _, id := range []int{1, 2, 3} {
+			content := "post " + string(rune('0'+id))
+			vbolt.Write(tx, posts, id, &content)
+		}
+	})
+
+	cases := []struct {
+		name     string
+		initial  map[int]string // nil: the map has to be allocated
+		ids      []int
+		expected map[int]string
+		nilAfter bool // nothing found, so the map stays nil
+	}{
+		{"nil map", nil, []int{1, 3}, map[int]string{1: "post 1", 3: "post 3"}, false},
+		{"existing map", map[int]string{9: "kept"}, []int{2}, map[int]string{9: "kept", 2: "post 2"}, false},
+		{"missing ids", nil, []int{4, 2, 5}, map[int]string{2: "post 2"}, false},
+		{"none found", nil, []int{7, 8}, nil, true},
+		{"no ids", nil, nil, nil, true},
+	}
+
+	check := func(t *testing.T, label string, items map[int]string, count int, expectedCount int, expected map[int]string, nilAfter bool) {
+		if nilAfter && items != nil {
+			t.Errorf("%s: allocated a map with nothing to put in it", label)
+		}
+		if count != expectedCount {
+			t.Errorf("%s: read %d items, expected %d", label, count, expectedCount)
+		}
+		if len(items) != len(expected) {
+			t.Errorf("%s: map has %d items, expected %d", label, len(items), len(expected))
+		}
+		for id, content := range expected {
+			if items[id] != content {
+				t.Errorf("%s: item %d: expected %q, found %q", label, id, content, items[id])
+			}
+		}
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// ReadSliceToMap and ReadToMap must agree
+			var fromSlice, fromOne map[int]string
+			if c.initial != nil {
+				fromSlice = map[int]string{}
+				fromOne = map[int]string{}
+				for id, content := range c.initial {
+					fromSlice[id] = content
+					fromOne[id] = content
+				}
+			}
+			expectedCount := len(c.expected) - len(c.initial)
+
+			vbolttest.View(db, func(tx *vbolt.Tx) {
+				count := vbolt.ReadSliceToMap(tx, posts, c.ids, &fromSlice)
+				check(t, "ReadSliceToMap", fromSlice, count, expectedCount, c.expected, c.nilAfter)
+
+				count = 0
+				for _, id := range c.ids {
+					if vbolt.ReadToMap(tx, posts, id, &fromOne) {
+						count++
+					}
+				}
+				check(t, "ReadToMap", fromOne, count, expectedCount, c.expected, c.nilAfter)
+			})
+		})
+	}
+}
+
+func TestBucketMethods(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+
+	tx := vbolttest.NewTx(t, &dbInfo)
+	content := "post 1"
+	posts.Write(tx, 1, &content)
+	posts.Write(tx, 0, &content) // the zero id is skipped, as with Write
+
+	if found, ok := vbolt.Get(tx, posts, 1); !ok || found != content {
+		t.Errorf("Get after the Write method: %q, %v", found, ok)
+	}
+	if found, ok := posts.Get(tx, 1); !ok || found != content {
+		t.Errorf("Get method: %q, %v", found, ok)
+	}
+	var found string
+	if posts.Read(tx, 0, &found) || vbolt.HasKey(tx, posts, 0) {
+		t.Errorf("the zero id was written")
+	}
+	raw, ok := posts.ReadRaw(tx, 1, nil)
+	if expected := vpack.ToBytes(&content, vpack.String); !ok || string(raw) != string(expected) {
+		t.Errorf("ReadRaw method: %x, expected %x", raw, expected)
+	}
+}
+
+func TestDecodeBadRecord(t *testing.T) {
+	var dbInfo vbolt.Info
+	loose := vbolt.Bucket(&dbInfo, "loose", vpack.FInt, vpack.String)
+	strict := vbolt.Bucket(&dbInfo, "strict", vpack.FInt, vpack.String)
+	var failed []string
+	strict.Strict = true
+	strict.OnDecodeError = func(key []byte, err error) {
+		failed = append(failed, err.Error())
+	}
+
+	db := vbolttest.NewTestDB(t, &dbInfo)
+	vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+		for _, info := range []*vbolt.BucketInfo[int, string]{loose, strict} {
+			for _, id := range []int{1, 3} {
+				content := "good"
+				vbolt.Write(tx, info, id, &content)
+			}
+			// a length with too few bytes after it
+			id := 2
+			vbolt.RawMustPut(vbolt.TxRawBucket(tx, info.Name), vpack.ToBytes(&id, vpack.FInt), []byte{5, 'b', 'a'})
+		}
+	})
+
+	vbolttest.View(db, func(tx *vbolt.Tx) {
+		for _, c := range []struct {
+			info     *vbolt.BucketInfo[int, string]
+			expected int
+		}{
+			{loose, 3}, // visited, partially filled, as before strict mode
+			{strict, 2},
+		} {
+			var ids []int
+			vbolt.IterateAll(tx, c.info, func(id int, content string) bool {
+				ids = append(ids, id)
+				return true
+			})
+			if len(ids) != c.expected {
+				t.Errorf("%s: IterateAll visited %v", c.info.Name, ids)
+			}
+
+			var items []string
+			vbolt.ScanList(tx, c.info, 0, 10, &items)
+			if len(items) != c.expected {
+				t.Errorf("%s: ScanList returned %q", c.info.Name, items)
+			}
+
+			count, err := vbolt.Select(c.info).Filter(func(id int, content *string) bool { return true }).Count(tx)
+			if err != nil || count != c.expected {
+				t.Errorf("%s: counted %d (%v)", c.info.Name, count, err)
+			}
+		}
+	})
+	if len(failed) != 3 {
+		t.Errorf("expected the strict bucket to report the bad record on each pass, got: %q", failed)
+	}
+}
+
+func TestGetOrCreate(t *testing.T) {
+	var dbInfo vbolt.Info
+	settings := vbolt.Bucket(&dbInfo, "settings", vpack.FInt, vpack.String)
+	settings.Default = func(id int) string { return "default" }
+
+	tx := vbolttest.NewTx(t, &dbInfo)
+	item, created, err := vbolt.GetOrCreate(tx, settings, 1)
+	if err != nil || !created || item != "default" {
+		t.Errorf("first call: %q, created=%v, err=%v", item, created, err)
+	}
+	item, created, err = vbolt.GetOrCreate(tx, settings, 1)
+	if err != nil || created || item != "default" {
+		t.Errorf("second call: %q, created=%v, err=%v", item, created, err)
+	}
+
+	item, created, err = vbolt.GetOrCreate(tx, settings, 0)
+	if !errors.Is(err, vbolt.ErrZeroKey) || created {
+		t.Errorf("zero id: %q, created=%v, err=%v", item, created, err)
+	}
+	if vbolt.HasKey(tx, settings, 0) {
+		t.Errorf("wrote the zero id")
+	}
+}
+
+func TestScanListPage(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+	years := vbolt.Index(&dbInfo, "post_year", vpack.FInt, vpack.FInt)
+	tx := vbolttest.NewTx(t, &dbInfo)
+	for id := 1; id <= 5; id++ {
+		content := "post"
+		vbolt.Write(tx, posts, id, &content)
+		vbolt.SetTargetSingleTerm(tx, years, id, 2000+id)
+	}
+
+	// buckets and index ranges page the same way
+	var items []string
+	var pages int
+	for next := vbolt.Continuation(nil); pages == 0 || !next.Done(); pages++ {
+		next = vbolt.ScanListPage(tx, posts, &items, vbolt.WithLimit(2), vbolt.From(next))
+	}
+	if len(items) != 5 || pages != 3 {
+		t.Errorf("read %d items in %d pages", len(items), pages)
+	}
+
+	var targets []int
+	pages = 0
+	for next := vbolt.Continuation(nil); pages == 0 || !next.Done(); pages++ {
+		next = vbolt.IterateTermRange(tx, years, 2002, 2005, func(target int, year int, priority uint16) bool {
+			targets = append(targets, target)
+			return true
+		}, vbolt.WithLimit(3), vbolt.From(next))
+	}
+	if len(targets) != 4 || targets[0] != 2 || targets[3] != 5 || pages != 2 {
+		t.Errorf("visited %v in %d pages", targets, pages)
+	}
+}
@@ -0,0 +1,53 @@
+package vbolt
+
+// BulkLoad is for large imports, where syncing each commit to disk would
+// dominate the time. It calls fn repeatedly, each time with a new write tx
+// that's committed when fn returns, until fn returns false or an error.
+//
+// While loading, the database doesn't sync commits (NoSync), and other write
+// transactions, including WithBatchTx, wait until the load is done (the load
+// starts once the ones already open have finished). fn decides how much goes
+// in each tx; there's no batching to tune.
+// Afterwards, the settings are restored and the file is synced, so once
+// BulkLoad returns everything is durable. If the process crashes during the
+// load, the file may be corrupted; only use it on data that can be reloaded.
+//
+// Watchers are called for the committed changes after the load is done and
+// other writes can proceed, so their callbacks may write.
+func BulkLoad(db *DB, fn func(tx *Tx) (more bool, err error)) (err error) {
+	var changes []func()
+	defer func() {
+		_RunChanges(changes)
+	}()
+
+	gate := _WriteGate(db)
+	gate.Lock()
+	defer gate.Unlock()
+
+	noSync := db.NoSync
+	db.NoSync = true
+	defer func() {
+		db.NoSync = noSync
+		if syncErr := db.Sync(); err == nil {
+			err = syncErr
+		}
+	}()
+
+	for more := true; more; {
+		tx, txErr := _BeginWriteTxHeld(db)
+		if txErr != nil {
+			return txErr
+		}
+		more, err = fn(tx)
+		if err == nil {
+			var txChanges []func()
+			txChanges, err = _CommitTx(tx)
+			changes = append(changes, txChanges...)
+		}
+		TxClose(tx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
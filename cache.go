@@ -0,0 +1,222 @@
+package vbolt
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+	Query results can be cached with a QueryCache. Each entry remembers the
+	database and the buckets and indexes the query reads from, and is dropped
+	when any of them is written to.
+
+	Invalidation is driven by generation counters, kept per database, that are
+	bumped on every write (Write, Delete, index and collection updates,
+	restores, fixtures, rotations), and again after commits done with
+	TxCommit/TxCommitE. RawPut doesn't know which bucket it writes to, so it
+	bumps a counter that invalidates every entry of the database. Writes from
+	transactions committed some other way (e.g. calling tx.Commit() directly)
+	still invalidate entries, but a query running concurrently with such a
+	commit may cache a result from before it; the TTL bounds how long that can
+	last.
+*/
+
+var _generations sync.Map // *DB => *sync.Map (structure name => *atomic.Uint64)
+
+// the generation bumped by raw writes, which every entry depends on
+const _rawWrites = ""
+
+// set when a QueryCache is created, so transactions only track dirty
+// structures when someone cares
+var _trackDirty atomic.Bool
+
+// the structures a tx has written to; the tx can't be asked for its db after
+// it's committed
+type _TxDirty struct {
+	DB    *DB
+	Names map[string]bool
+}
+
+var _txDirty sync.Map // *Tx => *_TxDirty
+
+func _Generation(db *DB, name string) *atomic.Uint64 {
+	gens, ok := _generations.Load(db)
+	if !ok {
+		gens, _ = _generations.LoadOrStore(db, new(sync.Map))
+	}
+	gen, ok := gens.(*sync.Map).Load(name)
+	if !ok {
+		gen, _ = gens.(*sync.Map).LoadOrStore(name, new(atomic.Uint64))
+	}
+	return gen.(*atomic.Uint64)
+}
+
+// called by everything that writes to a bucket, index, or collection
+func _MarkDirty(tx *Tx, name string) {
+	_NoteTxWrite(tx, name)
+	_BumpGeneration(tx, name)
+}
+
+func _BumpGeneration(tx *Tx, name string) {
+	if !_trackDirty.Load() {
+		return
+	}
+	db := tx.DB()
+	_Generation(db, name).Add(1)
+	dirty, ok := _txDirty.Load(tx)
+	if !ok {
+		dirty, _ = _txDirty.LoadOrStore(tx, &_TxDirty{DB: db, Names: make(map[string]bool)})
+	}
+	dirty.(*_TxDirty).Names[name] = true
+}
+
+// called after a successful commit
+func _BumpCommitted(tx *Tx) {
+	value, ok := _txDirty.LoadAndDelete(tx)
+	if !ok {
+		return
+	}
+	dirty := value.(*_TxDirty)
+	for name := range dirty.Names {
+		_Generation(dirty.DB, name).Add(1)
+	}
+}
+
+type _CacheEntry struct {
+	Result      QueryResult
+	Generations map[string]uint64
+	Expires     time.Time // zero if the cache has no TTL
+}
+
+type _CacheKey struct {
+	DB    *DB
+	Query string
+}
+
+// QueryCache caches query results for any number of databases. Entries expire
+// after TTL, or never if it's 0 (they're still dropped when invalidated).
+// MaxEntries bounds the size of the cache; 0 means no bound.
+type QueryCache struct {
+	TTL        time.Duration
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[_CacheKey]*_CacheEntry
+}
+
+func NewQueryCache(ttl time.Duration, maxEntries int) *QueryCache {
+	_trackDirty.Store(true)
+	return &QueryCache{
+		TTL:        ttl,
+		MaxEntries: maxEntries,
+		entries:    make(map[_CacheKey]*_CacheEntry),
+	}
+}
+
+// the names of the structures the query reads from
+func _QueryDependencies(query *Query) []string {
+	names := []string{_rawWrites}
+	if query.Bucket != "" {
+		names = append(names, query.Bucket)
+	}
+	if query.Index != "" {
+		names = append(names, query.Index)
+	}
+	var visit func(filter *QueryFilter)
+	visit = func(filter *QueryFilter) {
+		if filter.Index != "" {
+			names = append(names, filter.Index)
+		}
+		for i := range filter.And {
+			visit(&filter.And[i])
+		}
+		for i := range filter.Or {
+			visit(&filter.Or[i])
+		}
+	}
+	if query.Where != nil {
+		visit(query.Where)
+	}
+	return names
+}
+
+// Exec returns the cached result for the query if it's still valid, and
+// otherwise runs it with ExecQuery in a new read transaction and caches it.
+//
+// Cached results are shared; callers must not modify them.
+func (c *QueryCache) Exec(db *DB, dbInfo *Info, query *Query) (result QueryResult, err error) {
+	keyBytes, err := json.Marshal(query)
+	if err != nil {
+		return
+	}
+	key := _CacheKey{DB: db, Query: string(keyBytes)}
+	now := Now()
+
+	c.mu.Lock()
+	entry := c.entries[key]
+	c.mu.Unlock()
+	if entry != nil && entry._Valid(db, now) {
+		return entry.Result, nil
+	}
+
+	// capture the generations before the tx starts: anything committed after
+	// this point changes them, so we never keep a result older than its generations
+	generations := make(map[string]uint64)
+	for _, name := range _QueryDependencies(query) {
+		generations[name] = _Generation(db, name).Load()
+	}
+	WithReadTx(db, func(tx *Tx) {
+		result, err = ExecQuery(tx, dbInfo, query)
+	})
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.MaxEntries > 0 && len(c.entries) >= c.MaxEntries {
+		c._Evict(now)
+	}
+	entry = &_CacheEntry{Result: result, Generations: generations}
+	if c.TTL > 0 {
+		entry.Expires = now.Add(c.TTL)
+	}
+	c.entries[key] = entry
+	return
+}
+
+func (entry *_CacheEntry) _Valid(db *DB, now time.Time) bool {
+	if !entry.Expires.IsZero() && !now.Before(entry.Expires) {
+		return false
+	}
+	for name, gen := range entry.Generations {
+		if _Generation(db, name).Load() != gen {
+			return false
+		}
+	}
+	return true
+}
+
+// drops invalid entries; if none are, drops an arbitrary half to make room
+func (c *QueryCache) _Evict(now time.Time) {
+	for key, entry := range c.entries {
+		if !entry._Valid(key.DB, now) {
+			delete(c.entries, key)
+		}
+	}
+	for key := range c.entries {
+		if len(c.entries) < c.MaxEntries/2+1 {
+			break
+		}
+		delete(c.entries, key)
+	}
+}
+
+// Clear drops all the cached results
+func (c *QueryCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[_CacheKey]*_CacheEntry)
+}
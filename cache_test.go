@@ -0,0 +1,108 @@
+package vbolt_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestQueryCache(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+	tags := vbolt.Index(&dbInfo, "post_tags", vpack.String, vpack.FInt)
+
+	clock := vbolttest.UseFakeClock(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	dbA := vbolttest.NewTestDB(t, &dbInfo)
+	dbB := vbolttest.NewTestDB(t, &dbInfo)
+
+	write := func(db *vbolt.DB, id int) {
+		vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+			content := "post"
+			vbolt.Write(tx, posts, id, &content)
+			vbolt.SetTargetTermsPlain(tx, tags, id, []string{"go"})
+		})
+	}
+	// a write the cache can't see, to tell cached results from fresh ones
+	sneak := func(db *vbolt.DB, id int, content string) {
+		err := db.Update(func(tx *vbolt.Tx) error {
+			return tx.Bucket([]byte("posts")).Put(vpack.ToBytes(&id, vpack.FInt), vpack.ToBytes(&content, vpack.String))
+		})
+		if err != nil {
+			t.Fatalf("sneaking a write: %v", err)
+		}
+	}
+
+	query := vbolt.Query{
+		Bucket: "posts",
+		Index:  "post_tags",
+		Where:  &vbolt.QueryFilter{Term: json.RawMessage(`"go"`)},
+	}
+	cache := vbolt.NewQueryCache(time.Minute, 0)
+	exec := func(db *vbolt.DB) (contents []string) {
+		t.Helper()
+		result, err := cache.Exec(db, &dbInfo, &query)
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		for _, item := range result.Items {
+			contents = append(contents, *item.Value.(*string))
+		}
+		return
+	}
+	expect := func(name string, db *vbolt.DB, expected ...string) {
+		t.Helper()
+		contents := exec(db)
+		if len(contents) != len(expected) {
+			t.Errorf("%s: expected %q, got %q", name, expected, contents)
+			return
+		}
+		for i := range expected {
+			if contents[i] != expected[i] {
+				t.Errorf("%s: expected %q, got %q", name, expected, contents)
+				return
+			}
+		}
+	}
+
+	write(dbA, 1)
+	expect("db A", dbA, "post")
+	expect("db B", dbB)
+
+	sneak(dbA, 1, "sneaked")
+	expect("cached", dbA, "post")
+
+	write(dbB, 2)
+	expect("written to another db", dbA, "post")
+	expect("written to", dbB, "post")
+
+	write(dbA, 3)
+	expect("written to", dbA, "sneaked", "post")
+
+	clock.Advance(2 * time.Minute)
+	sneak(dbA, 3, "sneaked")
+	expect("expired", dbA, "sneaked", "sneaked")
+
+	// restores write raw records
+	var backup bytes.Buffer
+	if err := vbolt.BackupBuckets(dbA, &backup, "posts", "post_tags"); err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	if err := vbolt.RestoreBuckets(dbB, &backup); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	expect("restored", dbB, "sneaked", "post", "sneaked")
+
+	// without a TTL, entries only go when they're invalidated
+	cache = vbolt.NewQueryCache(0, 0)
+	expect("no TTL", dbA, "sneaked", "sneaked")
+	sneak(dbA, 1, "sneaked again")
+	clock.Advance(24 * time.Hour)
+	expect("no TTL, a day later", dbA, "sneaked", "sneaked")
+	write(dbA, 1)
+	expect("no TTL, written to", dbA, "post", "sneaked")
+}
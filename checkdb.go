@@ -0,0 +1,62 @@
+package vbolt
+
+import "fmt"
+
+// Report is the result of CheckDB. It's meant to be machine readable (e.g.
+// served as json from a health endpoint) as well as printed.
+type Report struct {
+	Backend    string
+	PageErrors []string // errors from the backend's own consistency check
+	Violations []Violation
+	Items      int // number of bucket items decoded
+}
+
+func (r *Report) OK() bool {
+	return len(r.PageErrors) == 0 && len(r.Violations) == 0
+}
+
+// CheckDB verifies the whole database: bolt's page level check, followed by
+// checking that every key and value in the registered buckets decodes with its
+// pack functions, followed by CheckInvariants.
+//
+// It runs inside a read transaction, so it can be used on a live database, but
+// it reads everything, so expect it to take a while on big files.
+func CheckDB(db *DB, dbInfo *Info) (report Report) {
+	report.Backend = BackendName
+	WithReadTx(db, func(tx *Tx) {
+		for err := range tx.Check() {
+			report.PageErrors = append(report.PageErrors, err.Error())
+		}
+		for _, name := range dbInfo.BucketList {
+			if g, ok := AsGenericBucket(dbInfo.Infos[name]); ok {
+				_CheckBucketDecoding(tx, &g, &report)
+			}
+		}
+		report.Violations = append(report.Violations, CheckInvariants(tx, dbInfo)...)
+	})
+	return
+}
+
+func _CheckBucketDecoding(tx *Tx, g *GenericBucketInfo, report *Report) {
+	bkt := TxRawBucket(tx, g.Name)
+	if bkt == nil {
+		return
+	}
+	RawIterate(bkt, nil, Window{}, func(key []byte, value []byte) bool {
+		report.Items++
+		if err := _CheckDecode(g.KeyPackFn, key); err != nil {
+			report.Violations = append(report.Violations, Violation{
+				Structure: g.Name,
+				Kind:      ViolationUndecodable,
+				Detail:    fmt.Sprintf("key %x: %v", key, err),
+			})
+		} else if err := _CheckDecode(g.ValuePackFn, value); err != nil {
+			report.Violations = append(report.Violations, Violation{
+				Structure: g.Name,
+				Kind:      ViolationUndecodable,
+				Detail:    fmt.Sprintf("value of key %x: %v", key, err),
+			})
+		}
+		return true
+	})
+}
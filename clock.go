@@ -0,0 +1,43 @@
+package vbolt
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for everything in this package that records or
+// compares timestamps (process runs, rotation, backups, ...). Tests can
+// replace it with SetClock to control time deterministically.
+//
+// Durations that are only logged (e.g. how long a process took) always use
+// real time.
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+var _clockMu sync.RWMutex
+var _clock Clock = SystemClock{}
+
+// SetClock replaces the package clock, and returns a function to restore the previous one
+func SetClock(clock Clock) (restore func()) {
+	_clockMu.Lock()
+	defer _clockMu.Unlock()
+	prev := _clock
+	_clock = clock
+	return func() {
+		SetClock(prev)
+	}
+}
+
+// Now returns the current time according to the package clock
+func Now() time.Time {
+	_clockMu.RLock()
+	defer _clockMu.RUnlock()
+	return _clock.Now()
+}
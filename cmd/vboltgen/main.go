@@ -0,0 +1,170 @@
+// vboltgen generates strongly typed accessors from a schema definition file.
+//
+// Usage (typically from a go:generate directive):
+//
+//	vboltgen -schema schema.json -o db_gen.go
+//
+// The schema describes buckets and indexes that are already declared as
+// package level variables (via vbolt.Bucket and vbolt.Index):
+//
+//	{
+//		"package": "models",
+//		"buckets": [{
+//			"var": "UsersBkt",
+//			"type": "User",
+//			"plural": "Users",
+//			"key": "int",
+//			"indexes": [{
+//				"var": "UsersByNameIdx",
+//				"name": "ByName",
+//				"term": "string",
+//				"termsFn": "userNameTerms"
+//			}]
+//		}]
+//	}
+//
+// For the above, the following functions are generated:
+//
+//	GetUser(tx, id) (User, bool)
+//	PutUser(tx, id, *User)         // also updates UsersByNameIdx via userNameTerms
+//	DeleteUser(tx, id)             // also removes the terms of id from UsersByNameIdx
+//	SearchUsersByName(tx, term, window) []User
+//
+// termsFn is a function you write that returns the terms (mapped to their
+// priorities) for an item, e.g. func(item *User) map[string]uint16. If it's
+// omitted, the index is not maintained by Put/Delete and only the search
+// function is generated.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"text/template"
+)
+
+type Index struct {
+	Var     string `json:"var"`
+	Name    string `json:"name"`
+	Term    string `json:"term"`
+	TermsFn string `json:"termsFn"`
+}
+
+type Bucket struct {
+	Var     string  `json:"var"`
+	Type    string  `json:"type"`
+	Plural  string  `json:"plural"`
+	Key     string  `json:"key"`
+	Indexes []Index `json:"indexes"`
+}
+
+type Schema struct {
+	Package string   `json:"package"`
+	Imports []string `json:"imports"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+var tmpl = template.Must(template.New("gen").Parse(`// Code generated by vboltgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"go.hasen.dev/vbolt"
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+{{range $b := .Buckets}}
+func Get{{$b.Type}}(tx *vbolt.Tx, id {{$b.Key}}) (item {{$b.Type}}, ok bool) {
+	ok = vbolt.Read(tx, {{$b.Var}}, id, &item)
+	return
+}
+
+func Put{{$b.Type}}(tx *vbolt.Tx, id {{$b.Key}}, item *{{$b.Type}}) {
+	vbolt.Write(tx, {{$b.Var}}, id, item)
+{{- range $b.Indexes}}{{if .TermsFn}}
+	vbolt.SetTargetTerms(tx, {{.Var}}, id, {{.TermsFn}}(item))
+{{- end}}{{end}}
+}
+
+func Delete{{$b.Type}}(tx *vbolt.Tx, id {{$b.Key}}) {
+	vbolt.Delete(tx, {{$b.Var}}, id)
+{{- range $b.Indexes}}{{if .TermsFn}}
+	vbolt.DeleteTargetTerms(tx, {{.Var}}, id)
+{{- end}}{{end}}
+}
+{{range $i := $b.Indexes}}
+func Search{{$b.Plural}}{{$i.Name}}(tx *vbolt.Tx, term {{$i.Term}}, window vbolt.Window) (items []{{$b.Type}}) {
+	var ids []{{$b.Key}}
+	vbolt.ReadTermTargets(tx, {{$i.Var}}, term, &ids, window)
+	vbolt.ReadSlice(tx, {{$b.Var}}, ids, &items)
+	return
+}
+{{end}}{{end}}`))
+
+func main() {
+	schemaPath := flag.String("schema", "", "path to the schema definition (json)")
+	outPath := flag.String("o", "", "output file (default: stdout)")
+	flag.Parse()
+
+	if *schemaPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*schemaPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var schema Schema
+	if err = json.Unmarshal(data, &schema); err != nil {
+		log.Fatalf("parsing %s: %v", *schemaPath, err)
+	}
+	if err = validate(&schema); err != nil {
+		log.Fatalf("%s: %v", *schemaPath, err)
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, &schema); err != nil {
+		log.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("formatting generated code: %v\n%s", err, buf.Bytes())
+	}
+
+	if *outPath == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err = os.WriteFile(*outPath, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// fills in defaults and reports missing fields
+func validate(schema *Schema) error {
+	if schema.Package == "" {
+		return fmt.Errorf("package is required")
+	}
+	for i := range schema.Buckets {
+		b := &schema.Buckets[i]
+		if b.Var == "" || b.Type == "" || b.Key == "" {
+			return fmt.Errorf("bucket #%d: var, type, and key are required", i)
+		}
+		if b.Plural == "" {
+			b.Plural = b.Type + "s"
+		}
+		for j := range b.Indexes {
+			idx := &b.Indexes[j]
+			if idx.Var == "" || idx.Name == "" || idx.Term == "" {
+				return fmt.Errorf("bucket %s, index #%d: var, name, and term are required", b.Type, j)
+			}
+		}
+	}
+	return nil
+}
@@ -12,7 +12,7 @@ import (
 // The original reason I created them was becuase the index did not support
 // traversing backwards and using a cursor for pagination.
 
-// TODO:  migration function that converts a collection to an index
+// To move off a collection, see MigrateCollectionToIndex
 
 /*
 	Collections are similar to indexes, but with some differences
@@ -25,8 +25,8 @@ import (
 	The key difference from indexes is:
 
 	- An Item wants to control its membership in collections one at a time.
-		- Although a "set all collections" action can be implemented on top of
-		  those building blocks
+		- Although a "set all collections" action is implemented on top of
+		  those building blocks: SetItemCollections
 		- Infact, an Index can probably be implemented on top of a collection
 		  by just implementing that
 		  - And perhaps we /should/ do that, as it could simplify the code!
@@ -49,6 +49,7 @@ type CollectionInfo[K, O, I any] struct {
 }
 
 func Collection[K, O, I any](dbInfo *Info, name string, keyFn vpack.PackFn[K], orderFn vpack.PackFn[O], itemFn vpack.PackFn[I]) *CollectionInfo[K, O, I] {
+	_WarnIfRegistered(dbInfo, name)
 	generic.Append(&dbInfo.CollectionList, name)
 	generic.EnsureMapNotNil(&dbInfo.Infos)
 	result := &CollectionInfo[K, O, I]{
@@ -113,51 +114,72 @@ func _ReadKeyOrderItem[K, O, I any](info *CollectionInfo[K, O, I], bKey []byte)
 	return
 }
 
-// TODO: take a "start at" param
-func _IterateCollectionCore[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, direction IterationDirection, visit func(key K, order O, item I) bool) {
-	prefix := _CKeyPrefix(info, key)
+func _IterateCollectionCore[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, direction IterationDirection, visit func(key K, order O, item I) bool) Continuation {
+	return _IterateCollectionOpts(tx, info, key, IterOptions{Window: Window{Direction: direction}}, visit)
+}
+
+func _IterateCollectionOpts[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, o IterOptions, visit func(key K, order O, item I) bool) Continuation {
+	prefix := append(_CKeyPrefix(info, key), o.Prefix...)
 
 	window := _RawIterationParams{
 		Prefix: prefix,
-		Window: Window{
-			Direction: direction,
-		},
+		Name:   info.Name,
+		Window: o.Window,
 	}
 
-	_RawIterateCore(TxRawBucket(tx, info.Name), window, func(bKey []byte, bValue []byte) bool {
+	return _RawIterateCore(TxRawBucket(tx, info.Name), window, func(bKey []byte, bValue []byte) bool {
 		key, order, item := _ReadKeyOrderItem(info, bKey)
 		return visit(key, order, item)
 	})
 }
 
-func IterateCollection[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, visit func(key K, order O, item I) bool) {
-	_IterateCollectionCore(tx, info, key, IterateRegular, visit)
+func IterateCollection[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, visit func(key K, order O, item I) bool, opts ...IterOption) Continuation {
+	return _IterateCollectionOpts(tx, info, key, _IterOptions(Window{}, opts), visit)
+}
+
+func IterateCollectionReverse[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, visit func(key K, order O, item I) bool) Continuation {
+	return _IterateCollectionCore(tx, info, key, IterateReverse, visit)
+}
+
+// IterateCollectionWindow is like IterateCollection but only visits the
+// entries in the window; use the returned Continuation to get the next page
+func IterateCollectionWindow[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, window Window, visit func(key K, order O, item I) bool) Continuation {
+	return _IterateCollectionOpts(tx, info, key, IterOptions{Window: window}, visit)
 }
 
-func IterateCollectionReverse[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, visit func(key K, order O, item I) bool) {
-	_IterateCollectionCore(tx, info, key, IterateReverse, visit)
+// ReadCollectionWindow appends the items in the window to items and returns
+// the continuation for the next page
+func ReadCollectionWindow[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, items *[]I, window Window) Continuation {
+	return IterateCollectionWindow(tx, info, key, window, func(_k K, _o O, item I) bool {
+		generic.Append(items, item)
+		return true
+	})
 }
 
-func ReadCollection[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, items *[]I, count int) {
+// ReadCollection appends up to count items under key to items, and returns
+// the continuation for the next page (pass it with From to
+// IterateCollection or ReadCollectionWindow)
+func ReadCollection[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, items *[]I, count int) Continuation {
 	if count < 0 {
-		return
+		return nil
 	}
 
 	var added int
-	IterateCollection(tx, info, key, func(_k K, _o O, item I) bool {
+	return IterateCollection(tx, info, key, func(_k K, _o O, item I) bool {
 		generic.Append(items, item)
 		added += 1
 		return added < count
 	})
 }
 
-func ReadCollectionReverse[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, items *[]I, count int) {
+// ReadCollectionReverse is like ReadCollection, from the last item back
+func ReadCollectionReverse[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, items *[]I, count int) Continuation {
 	if count < 0 {
-		return
+		return nil
 	}
 
 	var added int
-	IterateCollectionReverse(tx, info, key, func(_k K, _o O, item I) bool {
+	return IterateCollectionReverse(tx, info, key, func(_k K, _o O, item I) bool {
 		generic.Append(items, item)
 		added += 1
 		return added < count
@@ -165,19 +187,33 @@ func ReadCollectionReverse[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], k
 }
 
 func _IncCount[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, inc int) {
-	bkt := TxRawBucket(tx, info.Name)
+	bkt := _TxWriteBucket(tx, info.Name)
 	bKey := _CCountKey(info, key)
 	bValue := bkt.Get(bKey)
 	var count int
-	fn := vpack.Int
-	vpack.FromBytesInto(bKey, &count, fn)
+	vpack.FromBytesInto(bValue, &count, PackCountFn)
 	count += inc
-	bValue = vpack.ToBytes(&count, fn)
-	bkt.Put(bKey, bValue)
+	bValue = vpack.ToBytes(&count, PackCountFn)
+	_Put(tx, bkt, info.Name, bKey, bValue)
 }
 
-func CollectionAddEntry[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, order O, item I) {
+// ReadCollectionCount returns the number of items in the collection under key,
+// from the stored count, without iterating.
+//
+// Counts stored by older versions (which decoded the count from the wrong
+// bytes) are not reliable; fix them once with RecountCollections.
+func ReadCollectionCount[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K) int {
+	var count int
 	bkt := TxRawBucket(tx, info.Name)
+	if bkt != nil {
+		vpack.FromBytesInto(bkt.Get(_CCountKey(info, key)), &count, PackCountFn)
+	}
+	return count
+}
+
+func CollectionAddEntry[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, order O, item I) {
+	bkt := _TxWriteBucket(tx, info.Name)
+	_MarkDirty(tx, info.Name)
 
 	var exists bool
 	var eOrder O // existing order (if exists)
@@ -201,18 +237,19 @@ func CollectionAddEntry[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key
 
 	if exists {
 		// delete the existing
-		bkt.Delete(_CKeyFull(info, key, eOrder, item))
-		bkt.Put(_CKeyFull(info, key, order, item), nil)
-		bkt.Put(iKey, iValue)
+		_Del(tx, bkt, info.Name, _CKeyFull(info, key, eOrder, item))
+		_Put(tx, bkt, info.Name, _CKeyFull(info, key, order, item), nil)
+		_Put(tx, bkt, info.Name, iKey, iValue)
 	} else {
-		bkt.Put(_CKeyFull(info, key, order, item), nil)
-		bkt.Put(iKey, iValue)
+		_Put(tx, bkt, info.Name, _CKeyFull(info, key, order, item), nil)
+		_Put(tx, bkt, info.Name, iKey, iValue)
 		_IncCount(tx, info, key, 1)
 	}
 }
 
 func CollectionRemoveEntry[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, item I) {
-	bkt := TxRawBucket(tx, info.Name)
+	bkt := _TxWriteBucket(tx, info.Name)
+	_MarkDirty(tx, info.Name)
 
 	var order O // starts out as the zero order
 
@@ -230,7 +267,99 @@ func CollectionRemoveEntry[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], k
 	vpack.FromBytesInto(eValue, &order, info.OrderFn)
 
 	// delete the entry, the reverse entry, and decrease the count
-	bkt.Delete(_CKeyFull(info, key, order, item))
-	bkt.Delete(iKey)
+	_Del(tx, bkt, info.Name, _CKeyFull(info, key, order, item))
+	_Del(tx, bkt, info.Name, iKey)
 	_IncCount(tx, info, key, -1)
 }
+
+// Updates the entries of item so that it's only in the collections provided
+// here. keys maps the collection key to the item's order in it
+func SetItemCollections[K comparable, O, I any](tx *Tx, info *CollectionInfo[K, O, I], item I, keys map[K]O) {
+	bkt := _TxWriteBucket(tx, info.Name)
+
+	// collect the existing keys first; bolt cursors don't like writes during iteration
+	var existing []K
+	RawIterate(bkt, _CItemPrefix(info, item), Window{}, func(bKey []byte, _ []byte) bool {
+		var eItem I
+		var key K
+		buf := vpack.NewReader(bKey)
+		buf.Pos++ // skip the prefix byte
+		info.ItemFn(&eItem, buf)
+		info.KeyFn(&key, buf)
+		existing = append(existing, key)
+		return true
+	})
+
+	for _, key := range existing {
+		if _, keep := keys[key]; !keep {
+			CollectionRemoveEntry(tx, info, key, item)
+		}
+	}
+	for key, order := range keys {
+		CollectionAddEntry(tx, info, key, order, item)
+	}
+}
+
+// MigrateCollectionToIndex copies the entries of a collection into an index:
+// the collection key becomes the term, the order becomes the priority, and
+// the item becomes the target. The collection itself is left alone.
+//
+// It works in batches of separate write transactions, saving its progress
+// after each, so if it's interrupted, calling it again continues where it
+// stopped. Once it has finished, calling it again does nothing.
+func MigrateCollectionToIndex[K, O, I comparable](db *DB, info *CollectionInfo[K, O, I], indexInfo *IndexInfo[I, K, O], batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	name := "collection to index: " + info.Name + " => " + indexInfo.Name
+
+	var done bool
+	WithReadTx(db, func(tx *Tx) {
+		done = HasKey(tx, DBProcesses, name)
+	})
+	if done {
+		return nil
+	}
+
+	type entry struct {
+		Key   K
+		Order O
+		Item  I
+	}
+	var entries []entry
+	for !done {
+		tx, err := _BeginWriteTx(db)
+		if err != nil {
+			return err
+		}
+		cursor, _ := Get(tx, ProcessProgress, name)
+		entries = entries[:0]
+		next := RawIterate(TxRawBucket(tx, info.Name), []byte{CKeyPrefix}, Window{Limit: batchSize, Cursor: cursor}, func(bKey []byte, _ []byte) bool {
+			key, order, item := _ReadKeyOrderItem(info, bKey)
+			entries = append(entries, entry{key, order, item})
+			return true
+		})
+		for _, e := range entries {
+			if err == nil {
+				err = AddTargetTermE(tx, indexInfo, e.Item, e.Key, e.Order)
+			}
+		}
+		done = next.Done()
+		if err == nil && done {
+			ts := Now()
+			err = DeleteE(tx, ProcessProgress, name)
+			ChannelError(&err, WriteE(tx, DBProcesses, name, &ts))
+		} else if err == nil {
+			cursor = next
+			err = WriteE(tx, ProcessProgress, name, &cursor)
+		}
+		if err == nil {
+			err = TxCommitE(tx)
+		}
+		TxClose(tx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package vbolt_test
+
+import (
+	"testing"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+// the stored counts used to be decoded from the count key instead of its
+// value, so they were wrong as soon as a key had more than one item
+func TestCollectionCounts(t *testing.T) {
+	var dbInfo vbolt.Info
+	info := vbolt.Collection(&dbInfo, "coll1", vpack.StringZ, vpack.FInt, vpack.FInt)
+
+	db := vbolttest.NewTestDB(t, &dbInfo)
+
+	type step struct {
+		add   bool
+		key   string
+		order int
+		item  int
+	}
+
+	// each step runs in its own transaction
+	steps := []step{
+		{true, "a", 1, 10},
+		{true, "a", 2, 11},
+		{true, "a", 3, 12},
+		{true, "b", 1, 10},
+		{true, "a", 5, 10},  // same item, new order: no change in count
+		{true, "a", 5, 10},  // already there
+		{false, "b", 0, 99}, // not there
+		{false, "a", 0, 11},
+		{true, "b", 2, 20},
+		{true, "c", 1, 30},
+		{false, "c", 0, 30},
+	}
+
+	expectedCounts := map[string]int{
+		"a": 2,
+		"b": 2,
+		"c": 0,
+		"d": 0,
+	}
+
+	for _, s := range steps {
+		vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+			if s.add {
+				vbolt.CollectionAddEntry(tx, info, s.key, s.order, s.item)
+			} else {
+				vbolt.CollectionRemoveEntry(tx, info, s.key, s.item)
+			}
+		})
+	}
+
+	checkCounts := func(label string) {
+		vbolttest.View(db, func(tx *vbolt.Tx) {
+			for key, expected := range expectedCounts {
+				var items []int
+				vbolt.ReadCollection(tx, info, key, &items, 100)
+				if len(items) != expected {
+					t.Errorf("%s: collection %q has %d items, expected %d", label, key, len(items), expected)
+				}
+				if count := vbolt.ReadCollectionCount(tx, info, key); count != expected {
+					t.Errorf("%s: count of collection %q is %d, expected %d", label, key, count, expected)
+				}
+			}
+		})
+	}
+
+	checkCounts("incremental")
+	vbolt.RecountCollections(db, info, 2)
+	checkCounts("recounted")
+}
+
+func TestReadCollectionPages(t *testing.T) {
+	var dbInfo vbolt.Info
+	info := vbolt.Collection(&dbInfo, "coll1", vpack.StringZ, vpack.FInt, vpack.FInt)
+	tx := vbolttest.NewTx(t, &dbInfo)
+	for i := 1; i <= 5; i++ {
+		vbolt.CollectionAddEntry(tx, info, "a", i, i*10)
+	}
+
+	var items []int
+	next := vbolt.ReadCollection(tx, info, "a", &items, 2)
+	for !next.Done() {
+		next = vbolt.ReadCollectionWindow(tx, info, "a", &items, next.Resume(vbolt.Window{Limit: 2}))
+	}
+	if len(items) != 5 || items[0] != 10 || items[4] != 50 {
+		t.Errorf("paged through %v", items)
+	}
+
+	items = nil
+	next = vbolt.ReadCollectionReverse(tx, info, "a", &items, 3)
+	vbolt.IterateCollection(tx, info, "a", func(key string, order int, item int) bool {
+		items = append(items, item)
+		return true
+	}, vbolt.From(next), vbolt.Reverse())
+	if len(items) != 5 || items[0] != 50 || items[4] != 10 {
+		t.Errorf("paged back through %v", items)
+	}
+
+	if next := vbolt.ReadCollection(tx, info, "a", &items, 10); !next.Done() {
+		t.Errorf("expected the last page to be done, got %x", next)
+	}
+}
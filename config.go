@@ -0,0 +1,73 @@
+package vbolt
+
+import "time"
+
+/*
+	Runtime settings of an open database.
+
+	Durability: with NoSync, commits don't wait for fsync. A crash (of the
+	machine, not just the process) can lose recent commits or corrupt the
+	file, so only use it when the data can be reloaded, e.g. during a
+	maintenance window followed by a backup.
+
+	Batching: MaxBatchSize and MaxBatchDelay only affect db.Batch.
+
+	Mmap growth: bolt maps the file with InitialMmapSize (1GB with Open), and
+	when the data outgrows the map, it remaps at double the size up to 1GB,
+	then in 1GB steps. Remapping waits for all read txs to close, so a long
+	read tx during growth stalls the writer. To avoid remaps, open with an
+	InitialMmapSize above the expected file size; it costs address space,
+	not memory. The file itself grows by AllocSize at a time; a larger
+	AllocSize means fewer (slow) file extensions during heavy writes, and
+	NoGrowSync skips the fsync after each extension.
+*/
+
+type DBConfig struct {
+	NoSync        bool
+	NoGrowSync    bool
+	MaxBatchSize  int
+	MaxBatchDelay time.Duration
+	AllocSize     int
+}
+
+func ReadDBConfig(db *DB) DBConfig {
+	return DBConfig{
+		NoSync:        db.NoSync,
+		NoGrowSync:    db.NoGrowSync,
+		MaxBatchSize:  db.MaxBatchSize,
+		MaxBatchDelay: db.MaxBatchDelay,
+		AllocSize:     db.AllocSize,
+	}
+}
+
+// SetDBConfig changes the settings of an open database. It waits for the
+// current write tx to finish (and keeps new ones from starting) so that no
+// commit sees a half applied change. Returns the previous settings.
+//
+// When turning NoSync off, the file is synced, so everything committed
+// before is durable once SetDBConfig returns.
+func SetDBConfig(db *DB, config DBConfig) (previous DBConfig, err error) {
+	gate := _WriteGate(db)
+	gate.Lock()
+	defer gate.Unlock()
+
+	// holding a write tx means no commit is in progress
+	tx, err := _BeginWriteTxHeld(db)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	previous = ReadDBConfig(db)
+	db.NoSync = config.NoSync
+	db.NoGrowSync = config.NoGrowSync
+	db.MaxBatchSize = config.MaxBatchSize
+	db.MaxBatchDelay = config.MaxBatchDelay
+	if config.AllocSize > 0 {
+		db.AllocSize = config.AllocSize
+	}
+	if previous.NoSync && !config.NoSync {
+		err = db.Sync()
+	}
+	return
+}
@@ -0,0 +1,116 @@
+package vbolt
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+/*
+	Constraints are data quality rules declared next to the bucket:
+
+		var Users = vbolt.Bucket(&Info, "users", vpack.FInt, PackUser)
+
+		func init() {
+			vbolt.AddConstraints(Users,
+				vbolt.RequireFields[int, User]("Username", "Email"),
+				vbolt.MaxValueSize[int, User](64*1024),
+				vbolt.References(Users, "team", func(u *User) []int { return []int{u.TeamId} }, Teams),
+			)
+		}
+
+	They are checked by Write (which panics) and WriteE (which returns the
+	error) before anything is written. Violations are reported as a
+	*ConstraintError.
+*/
+
+var ErrConstraint = errors.New("vbolt: constraint violated")
+
+type ConstraintError struct {
+	Bucket     string
+	Key        any
+	Constraint string
+	Detail     string
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("vbolt: %s[%v]: constraint %s violated: %s", e.Bucket, e.Key, e.Constraint, e.Detail)
+}
+
+func (e *ConstraintError) Is(target error) bool {
+	return target == ErrConstraint
+}
+
+type Constraint[K, T any] struct {
+	Name string
+
+	// returns an empty string if the item is ok, or a description of what's
+	// wrong with it. data is the packed value about to be written
+	Check func(tx *Tx, key K, item *T, data []byte) string
+}
+
+func AddConstraints[K, T any](info *BucketInfo[K, T], constraints ...Constraint[K, T]) {
+	info.Constraints = append(info.Constraints, constraints...)
+}
+
+func _CheckConstraints[K, T any](tx *Tx, info *BucketInfo[K, T], key K, item *T, data []byte) error {
+	for _, c := range info.Constraints {
+		if detail := c.Check(tx, key, item, data); detail != "" {
+			return &ConstraintError{Bucket: info.Name, Key: key, Constraint: c.Name, Detail: detail}
+		}
+	}
+	return nil
+}
+
+// RequireFields rejects items where any of the named struct fields is the zero value
+func RequireFields[K, T any](fields ...string) Constraint[K, T] {
+	return Constraint[K, T]{
+		Name: "required",
+		Check: func(tx *Tx, key K, item *T, data []byte) string {
+			v := reflect.ValueOf(item).Elem()
+			for _, name := range fields {
+				field := v.FieldByName(name)
+				if !field.IsValid() {
+					return fmt.Sprintf("no field named %s", name)
+				}
+				if field.IsZero() {
+					return fmt.Sprintf("%s is required", name)
+				}
+			}
+			return ""
+		},
+	}
+}
+
+// MaxValueSize rejects items whose packed size exceeds maxBytes
+func MaxValueSize[K, T any](maxBytes int) Constraint[K, T] {
+	return Constraint[K, T]{
+		Name: "max size",
+		Check: func(tx *Tx, key K, item *T, data []byte) string {
+			if len(data) > maxBytes {
+				return fmt.Sprintf("packed size %d exceeds %d bytes", len(data), maxBytes)
+			}
+			return ""
+		},
+	}
+}
+
+// References rejects items that refer (through the ids returned by refFn) to
+// keys that don't exist in the target bucket. Zero ids are ignored, so
+// optional references can be left unset.
+//
+// The first parameter is only used for type inference.
+func References[K, T any, RK comparable, RT any](_ *BucketInfo[K, T], name string, refFn func(item *T) []RK, target *BucketInfo[RK, RT]) Constraint[K, T] {
+	return Constraint[K, T]{
+		Name: "references " + name,
+		Check: func(tx *Tx, key K, item *T, data []byte) string {
+			var zero RK
+			for _, id := range refFn(item) {
+				if id != zero && !HasKey(tx, target, id) {
+					return fmt.Sprintf("%v does not exist in %s", id, target.Name)
+				}
+			}
+			return ""
+		},
+	}
+}
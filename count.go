@@ -0,0 +1,115 @@
+package vbolt
+
+import (
+	"fmt"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	Counting the targets that match a set of terms, without reading records
+	or decoding targets:
+
+	- A single term is answered from its stored count.
+	- For several terms (AND), if any stored count is zero the answer is zero.
+	  Otherwise, the term with the fewest targets is iterated and each raw
+	  target is checked against the other terms with a key lookup.
+*/
+
+// TermCondition is a typed way to make a QueryCondition, for CountQuery
+func TermCondition[K, T, P comparable](indexInfo *IndexInfo[K, T, P], term T) QueryCondition {
+	g, _ := AsGenericIndex(indexInfo)
+	return QueryCondition{
+		Index:     g,
+		Term:      term,
+		TermBytes: vpack.ToBytes(&term, indexInfo.TermPackFn),
+	}
+}
+
+// CountQuery returns how many targets match all the conditions.
+// All the conditions must be on indexes with the same target type.
+func CountQuery(tx *Tx, conditions ...QueryCondition) (count int, err error) {
+	if len(conditions) == 0 {
+		return 0, fmt.Errorf("%w: no conditions to count", ErrInvalidQuery)
+	}
+	for _, c := range conditions[1:] {
+		if c.Index.TargetType != conditions[0].Index.TargetType {
+			return 0, fmt.Errorf("%w: indexes %s and %s have different targets", ErrInvalidQuery, conditions[0].Index.Name, c.Index.Name)
+		}
+	}
+
+	driverIdx, best := 0, -1
+	for i, c := range conditions {
+		n := _TermCountEstimate(tx, c.Index.Name, c.TermBytes)
+		if n == 0 {
+			return 0, nil
+		}
+		if best == -1 || n < best {
+			driverIdx, best = i, n
+		}
+	}
+	if len(conditions) == 1 {
+		return best, nil
+	}
+
+	driver := &conditions[driverIdx]
+	others := make([]*BBucket, len(conditions))
+	for i, c := range conditions {
+		others[i] = TxRawBucket(tx, c.Index.Name)
+	}
+	RawIterate(TxRawBucket(tx, driver.Index.Name), _Concat(IndexTermPrefix, driver.TermBytes), Window{}, func(key []byte, _ []byte) bool {
+		parts, ok := _SplitKey(key, driver.Index.TermPackFn, driver.Index.PriorityPackFn, driver.Index.TargetPackFn)
+		if !ok {
+			return true
+		}
+		for i, c := range conditions {
+			if i != driverIdx && !RawHasKey(others[i], _Concat(IndexTargetPrefix, parts[2], c.TermBytes)) {
+				return true
+			}
+		}
+		count++
+		return true
+	})
+	return
+}
+
+// Count returns the number of records matching the query, ignoring the
+// window and sorting. Without filters, it's answered by CountQuery (or the
+// bucket's key count if there are no conditions); with filters or
+// expressions, the matching records have to be read.
+func (q *QueryBuilder[K, T]) Count(tx *Tx) (count int, err error) {
+	if q.Error != nil {
+		return 0, q.Error
+	}
+	if len(q.Filters) == 0 && len(q.Exprs) == 0 {
+		if len(q.Conditions) == 0 {
+			return _BucketEstimate(tx, q.Bucket.Name), nil
+		}
+		return CountQuery(tx, q.Conditions...)
+	}
+
+	bkt := TxRawBucket(tx, q.Bucket.Name)
+	if bkt == nil {
+		return 0, nil
+	}
+	q._Candidates(tx, nil, func(_ []byte, key []byte, value []byte) bool {
+		if value == nil {
+			if value = bkt.Get(key); value == nil {
+				return true
+			}
+		}
+		var itemKey K
+		var item T
+		if !_DecodeRecord(q.Bucket, key, value, &itemKey, &item) {
+			return true
+		}
+		for _, filter := range q.Filters {
+			if !filter(itemKey, &item) {
+				return true
+			}
+		}
+		count++
+		return true
+	})
+	return
+}
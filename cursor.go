@@ -0,0 +1,161 @@
+package vbolt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+)
+
+/*
+	Stable pagination cursors.
+
+	Window.Offset shifts when items are inserted or removed between pages, and
+	Window.Cursor points at the next key to visit, which may itself be
+	removed. A PageCursor instead records the last key that was returned
+	(the full term+priority+target key for indexes), and the next page starts
+	strictly after it, so concurrent writes never cause items to be skipped
+	or repeated.
+
+	Cursors are opaque strings meant to be handed to clients. They carry a
+	fingerprint of the index they came from (name and types), so a cursor
+	from before a schema change (or from a different index) is rejected with
+	ErrStaleCursor instead of producing garbage.
+*/
+
+var ErrStaleCursor = errors.New("vbolt: cursor is invalid or from a different schema")
+
+type PageCursor struct {
+	Schema    uint32
+	Direction IterationDirection
+	LastKey   []byte
+}
+
+const _pageCursorVersion byte = 1
+
+// version byte, 4 bytes schema fingerprint, direction byte, last key
+func EncodeCursor(cursor *PageCursor) string {
+	data := make([]byte, 6, 6+len(cursor.LastKey))
+	data[0] = _pageCursorVersion
+	binary.BigEndian.PutUint32(data[1:5], cursor.Schema)
+	data[5] = byte(cursor.Direction)
+	data = append(data, cursor.LastKey...)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (cursor PageCursor, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: %v", ErrStaleCursor, err)
+	}
+	if len(data) < 6 || data[0] != _pageCursorVersion {
+		return cursor, ErrStaleCursor
+	}
+	cursor.Schema = binary.BigEndian.Uint32(data[1:5])
+	cursor.Direction = IterationDirection(data[5])
+	cursor.LastKey = data[6:]
+	return
+}
+
+// identifies the name and types of an index, to detect stale cursors
+func _IndexFingerprint[K, T, P comparable](indexInfo *IndexInfo[K, T, P]) uint32 {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s|%v|%v|%v", indexInfo.Name,
+		reflect.TypeOf((*K)(nil)).Elem(),
+		reflect.TypeOf((*T)(nil)).Elem(),
+		reflect.TypeOf((*P)(nil)).Elem())
+	return h.Sum32()
+}
+
+// CheckTermCursor returns ErrStaleCursor if the cursor was not produced by
+// ReadTermPage for this index and term (with the current schema)
+func CheckTermCursor[K, T, P comparable](indexInfo *IndexInfo[K, T, P], term T, cursor string) error {
+	_, err := _TermCursorStart(indexInfo, &term, cursor)
+	return err
+}
+
+func _TermCursorStart[K, T, P comparable](indexInfo *IndexInfo[K, T, P], term *T, cursor string) (c PageCursor, err error) {
+	if cursor == "" {
+		return
+	}
+	if c, err = DecodeCursor(cursor); err != nil {
+		return
+	}
+	if c.Schema != _IndexFingerprint(indexInfo) || !bytes.HasPrefix(c.LastKey, _TermKeyPrefix(indexInfo, term)) {
+		err = ErrStaleCursor
+	}
+	return
+}
+
+// visits the keys with the given prefix that come strictly after lastKey in
+// the given direction (or all of them if lastKey is nil)
+func _RawIterateAfter(bkt *BBucket, prefix []byte, lastKey []byte, direction IterationDirection, visitFn func(key []byte, value []byte) bool) {
+	if bkt == nil {
+		return
+	}
+	c := bkt.Cursor()
+	var key, value []byte
+	switch {
+	case lastKey == nil:
+		key, value = _CursorStartPosForPrefix(c, prefix, direction)
+	case direction == IterateReverse:
+		if key, _ = c.Seek(lastKey); key == nil {
+			key, value = c.Last()
+		} else {
+			key, value = c.Prev()
+		}
+	default:
+		if key, value = c.Seek(lastKey); bytes.Equal(key, lastKey) {
+			key, value = c.Next()
+		}
+	}
+	for key != nil && bytes.HasPrefix(key, prefix) {
+		if !visitFn(key, value) {
+			return
+		}
+		key, value = _CursorStep(c, direction)
+	}
+}
+
+// ReadTermPage appends up to limit targets of term to targets, starting
+// after cursor (pass "" for the first page). Returns the cursor for the next
+// page, or "" if there are no more targets.
+//
+// The direction used for the first page is kept in the cursor.
+func ReadTermPage[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term T, cursor string, limit int, direction IterationDirection, targets *[]K) (next string, err error) {
+	start, err := _TermCursorStart(indexInfo, &term, cursor)
+	if err != nil {
+		return
+	}
+	if cursor != "" {
+		direction = start.Direction
+	}
+
+	var lastKey []byte
+	count := 0
+	more := false
+	prefix := _TermKeyPrefix(indexInfo, &term)
+	_RawIterateAfter(TxRawBucket(tx, indexInfo.Name), prefix, start.LastKey, direction, func(key []byte, value []byte) bool {
+		if limit > 0 && count >= limit {
+			more = true
+			return false
+		}
+		_, target, _ := _ReadTermTargetPriority(indexInfo, key)
+		*targets = append(*targets, target)
+		lastKey = key
+		count++
+		return true
+	})
+
+	if more {
+		next = EncodeCursor(&PageCursor{
+			Schema:    _IndexFingerprint(indexInfo),
+			Direction: direction,
+			LastKey:   lastKey,
+		})
+	}
+	return
+}
@@ -0,0 +1,254 @@
+package vbolt
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// process wide counters; cheap enough to always keep
+
+var _opCounters sync.Map // bucket name => *_OpCounters
+var _lastBackupTime atomic.Int64
+
+type _OpCounters struct {
+	Reads        atomic.Int64
+	Writes       atomic.Int64
+	Deletes      atomic.Int64
+	BytesRead    atomic.Int64
+	BytesWritten atomic.Int64
+}
+
+func _Counters(bucketName string) *_OpCounters {
+	counters, ok := _opCounters.Load(bucketName)
+	if !ok {
+		counters, _ = _opCounters.LoadOrStore(bucketName, new(_OpCounters))
+	}
+	return counters.(*_OpCounters)
+}
+
+func _CountWrite(bucketName string, size int) {
+	c := _Counters(bucketName)
+	c.Writes.Add(1)
+	c.BytesWritten.Add(int64(size))
+}
+
+func _CountDelete(bucketName string) {
+	_Counters(bucketName).Deletes.Add(1)
+}
+
+func _CountRead(bucketName string, size int) {
+	c := _Counters(bucketName)
+	c.Reads.Add(1)
+	c.BytesRead.Add(int64(size))
+}
+
+// OpCounts are the operations done on a bucket through the typed api
+// (Read, Write, Delete, and the Iterate functions) since the process started.
+// Operations from transactions that were rolled back are still counted.
+type OpCounts struct {
+	Reads        int64
+	Writes       int64
+	Deletes      int64
+	BytesRead    int64 // encoded values
+	BytesWritten int64 // encoded keys and values
+}
+
+// OpStats returns the operation counts per bucket. The counters are process
+// wide, so with several databases open, buckets with the same name share them.
+func OpStats() map[string]OpCounts {
+	out := make(map[string]OpCounts)
+	_opCounters.Range(func(key, value any) bool {
+		c := value.(*_OpCounters)
+		out[key.(string)] = OpCounts{
+			Reads:        c.Reads.Load(),
+			Writes:       c.Writes.Load(),
+			Deletes:      c.Deletes.Load(),
+			BytesRead:    c.BytesRead.Load(),
+			BytesWritten: c.BytesWritten.Load(),
+		}
+		return true
+	})
+	return out
+}
+
+// WriteCounts returns the number of Write/Delete calls per bucket since the process started.
+// Note that writes from transactions that were rolled back are still counted.
+func WriteCounts() map[string]int64 {
+	out := make(map[string]int64)
+	for name, counts := range OpStats() {
+		out[name] = counts.Writes + counts.Deletes
+	}
+	return out
+}
+
+// LastBackupTime returns the time the last successful BackupBuckets call finished,
+// or the zero time if no backup was made since the process started.
+func LastBackupTime() time.Time {
+	ts := _lastBackupTime.Load()
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(ts, 0)
+}
+
+type DebugStats struct {
+	Path           string
+	FileSize       int64
+	OpenTxCount    int
+	TxCount        int
+	FreePageCount  int
+	PendingPages   int
+	WriteCounts    map[string]int64
+	OpStats        map[string]OpCounts
+	LastBackupTime time.Time
+	BucketCounts   map[string]int
+}
+
+func ReadDebugStats(db *DB, dbInfo *Info) (stats DebugStats) {
+	dbStats := db.Stats()
+	stats.Path = db.Path()
+	if fi, err := os.Stat(stats.Path); err == nil {
+		stats.FileSize = fi.Size()
+	}
+	stats.OpenTxCount = dbStats.OpenTxN
+	stats.TxCount = dbStats.TxN
+	stats.FreePageCount = dbStats.FreePageN
+	stats.PendingPages = dbStats.PendingPageN
+	stats.WriteCounts = WriteCounts()
+	stats.OpStats = OpStats()
+	stats.LastBackupTime = LastBackupTime()
+
+	stats.BucketCounts = make(map[string]int)
+	if dbInfo != nil {
+		WithReadTx(db, func(tx *Tx) {
+			for _, name := range dbInfo.BucketList {
+				if bkt := TxRawBucket(tx, name); bkt != nil {
+					stats.BucketCounts[name] = bkt.Stats().KeyN
+				}
+			}
+		})
+	}
+	return
+}
+
+// ExpvarPublish publishes live database stats under the "vbolt" expvar.
+// Must only be called once per process (expvar panics on duplicate names).
+//
+// Bucket item counts are not included because they require scanning every bucket;
+// use the stats debug route for those. The operation counters are process
+// wide (see OpStats), so if dbInfo is given, they're limited to the buckets and
+// indexes it registers.
+func ExpvarPublish(db *DB, dbInfo *Info) {
+	expvar.Publish("vbolt", expvar.Func(func() any {
+		stats := ReadDebugStats(db, nil)
+		if dbInfo != nil {
+			for name := range stats.OpStats {
+				if dbInfo.Infos[name] == nil {
+					delete(stats.OpStats, name)
+					delete(stats.WriteCounts, name)
+				}
+			}
+		}
+		return stats
+	}))
+}
+
+type DebugSchema struct {
+	Buckets     []DebugSchemaItem
+	Indexes     []DebugSchemaItem
+	Collections []string
+}
+
+type DebugSchemaItem struct {
+	Name  string
+	Types map[string]string
+}
+
+func ReadDebugSchema(dbInfo *Info) (schema DebugSchema) {
+	for _, name := range dbInfo.BucketList {
+		item := DebugSchemaItem{Name: name}
+		if g, ok := AsGenericBucket(dbInfo.Infos[name]); ok {
+			item.Types = map[string]string{
+				"key":   g.KeyType.String(),
+				"value": g.ValueType.String(),
+			}
+		}
+		schema.Buckets = append(schema.Buckets, item)
+	}
+	for _, name := range dbInfo.IndexList {
+		item := DebugSchemaItem{Name: name}
+		if g, ok := AsGenericIndex(dbInfo.Infos[name]); ok {
+			item.Types = map[string]string{
+				"target":   g.TargetType.String(),
+				"term":     g.TermType.String(),
+				"priority": g.PriorityType.String(),
+			}
+		}
+		schema.Indexes = append(schema.Indexes, item)
+	}
+	schema.Collections = append(schema.Collections, dbInfo.CollectionList...)
+	return
+}
+
+func _WriteJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// DebugRoutes registers the debug handlers on mux:
+//
+//	/debug/vbolt/stats                          database stats and per bucket counts
+//	/debug/vbolt/schema                         registered buckets and indexes with their types
+//	/debug/vbolt/inspect?bucket=name&limit=n    the first n items of a bucket
+//	/debug/vbolt/query                          see QueryHandler
+//	/debug/vbolt/health                         see HealthHandler
+//	/debug/vbolt/sizes                          disk usage per bucket, see SizeBreakdown
+func DebugRoutes(mux *http.ServeMux, db *DB, dbInfo *Info) {
+	mux.HandleFunc("/debug/vbolt/stats", func(w http.ResponseWriter, r *http.Request) {
+		_WriteJSON(w, ReadDebugStats(db, dbInfo))
+	})
+
+	mux.HandleFunc("/debug/vbolt/schema", func(w http.ResponseWriter, r *http.Request) {
+		_WriteJSON(w, ReadDebugSchema(dbInfo))
+	})
+
+	mux.HandleFunc("/debug/vbolt/inspect", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("bucket")
+		bucketInfo := dbInfo.Infos[name]
+		if _, ok := AsGenericBucket(bucketInfo); !ok {
+			http.Error(w, "unknown bucket: "+name, http.StatusNotFound)
+			return
+		}
+		var inspection Inspection
+		inspection.BucketInfoPtr = bucketInfo
+		inspection.Limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+		if inspection.Limit <= 0 {
+			inspection.Limit = 100
+		}
+		WithReadTx(db, func(tx *Tx) {
+			if TxRawBucket(tx, name) != nil {
+				GenericRead(tx, &inspection)
+			}
+		})
+		_WriteJSON(w, map[string]any{
+			"Items":           inspection.Items,
+			"NextKey":         inspection.NextKey,
+			"TotalItemsCount": inspection.TotalItemsCount,
+		})
+	})
+
+	mux.Handle("/debug/vbolt/query", QueryHandler(db, dbInfo))
+	mux.Handle("/debug/vbolt/health", HealthHandler(db, dbInfo))
+
+	mux.HandleFunc("/debug/vbolt/sizes", func(w http.ResponseWriter, r *http.Request) {
+		_WriteJSON(w, SizeBreakdown(db, dbInfo))
+	})
+}
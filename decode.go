@@ -0,0 +1,44 @@
+package vbolt
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.hasen.dev/vpack"
+)
+
+// ErrDecode is wrapped by errors about data that does not decode cleanly with
+// its pack function
+var ErrDecode = errors.New("vbolt: decode failed")
+
+// decodes data into item and returns an error if the pack function panics or
+// does not consume exactly all the bytes
+func _DecodeInto[T any](data []byte, item *T, fn vpack.PackFn[T]) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrDecode, r)
+		}
+	}()
+	reader := vpack.NewReader(data)
+	fn(item, reader)
+	if reader.Pos != len(data) {
+		return fmt.Errorf("%w: consumed %d of %d bytes", ErrDecode, reader.Pos, len(data))
+	}
+	return nil
+}
+
+// like _DecodeInto but for type erased pack functions
+func _CheckDecode(fn reflect.Value, data []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrDecode, r)
+		}
+	}()
+	reader := vpack.NewReader(data)
+	reflectUnpackFrom(fn, reader)
+	if reader.Pos != len(data) {
+		return fmt.Errorf("%w: consumed %d of %d bytes", ErrDecode, reader.Pos, len(data))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package vbolt
+
+import (
+	"bytes"
+	"sort"
+)
+
+// RawDiff is a key that differs between two databases. Before is nil when
+// the key was added, After is nil when it was removed
+type RawDiff struct {
+	Bucket string
+	Key    []byte
+	Before []byte
+	After  []byte
+}
+
+// DiffTx walks all buckets in both transactions in key order, calling visit
+// for every key whose value differs. A bucket missing on one side counts as
+// empty. The slices are only valid during visit. Stops when visit returns false.
+func DiffTx(before *Tx, after *Tx, visit func(d RawDiff) bool) {
+	names := make(map[string]bool)
+	for _, tx := range []*Tx{before, after} {
+		tx.ForEach(func(name []byte, _ *BBucket) error {
+			names[string(name)] = true
+			return nil
+		})
+	}
+	var sorted []string
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		if !_DiffBucket(name, before.Bucket([]byte(name)), after.Bucket([]byte(name)), visit) {
+			return
+		}
+	}
+}
+
+func _DiffBucket(name string, before *BBucket, after *BBucket, visit func(d RawDiff) bool) bool {
+	var bk, bv, ak, av []byte
+	var bc, ac *Cursor
+	if before != nil {
+		bc = before.Cursor()
+		bk, bv = bc.First()
+	}
+	if after != nil {
+		ac = after.Cursor()
+		ak, av = ac.First()
+	}
+	for bk != nil || ak != nil {
+		var cmp int
+		switch {
+		case bk == nil:
+			cmp = 1
+		case ak == nil:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(bk, ak)
+		}
+		var d RawDiff
+		d.Bucket = name
+		switch {
+		case cmp < 0:
+			d.Key, d.Before = bk, _NonNil(bv)
+			bk, bv = bc.Next()
+		case cmp > 0:
+			d.Key, d.After = ak, _NonNil(av)
+			ak, av = ac.Next()
+		default:
+			if !bytes.Equal(bv, av) {
+				d.Key, d.Before, d.After = bk, _NonNil(bv), _NonNil(av)
+			}
+			bk, bv = bc.Next()
+			ak, av = ac.Next()
+		}
+		if d.Key != nil && !visit(d) {
+			return false
+		}
+	}
+	return true
+}
+
+// empty values (index and collection entries) should not read as removed
+func _NonNil(b []byte) []byte {
+	if b == nil {
+		return []byte{}
+	}
+	return b
+}
+
+// DiffDB is DiffTx on read transactions of both databases
+func DiffDB(before *DB, after *DB, visit func(d RawDiff) bool) {
+	WithReadTx(before, func(btx *Tx) {
+		WithReadTx(after, func(atx *Tx) {
+			DiffTx(btx, atx, visit)
+		})
+	})
+}
@@ -0,0 +1,221 @@
+package vbolt
+
+import (
+	"fmt"
+	"strings"
+
+	"go.hasen.dev/vpack"
+)
+
+// PlanStep is one step of executing a query, in order
+type PlanStep struct {
+	Operation string // e.g. "scan bucket", "iterate term", "check term", "sort"
+	Structure string // bucket or index name, if any
+	Prefix    []byte // key prefix (range) read from the structure, if any
+	Estimate  int    // expected number of entries, from stored counts; -1 if unknown
+	InMemory  bool   // whether the step holds the whole result set in memory
+	Detail    string
+}
+
+// PlanDescription describes how a query will be executed, to help figure
+// out why a query is slow. See QueryBuilder.Explain and ExplainQuery
+type PlanDescription struct {
+	Steps []PlanStep
+}
+
+func (p PlanDescription) String() string {
+	var b strings.Builder
+	for i, step := range p.Steps {
+		fmt.Fprintf(&b, "%d. %s", i+1, step.Operation)
+		if step.Structure != "" {
+			fmt.Fprintf(&b, " %s", step.Structure)
+		}
+		if step.Prefix != nil {
+			fmt.Fprintf(&b, " prefix=%x", step.Prefix)
+		}
+		if step.Estimate >= 0 {
+			fmt.Fprintf(&b, " ~%d", step.Estimate)
+		}
+		if step.InMemory {
+			b.WriteString(" [in memory]")
+		}
+		if step.Detail != "" {
+			fmt.Fprintf(&b, " (%s)", step.Detail)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// reads the stored count of the term; -1 if tx is nil
+func _TermCountEstimate(tx *Tx, indexName string, termBytes []byte) int {
+	if tx == nil {
+		return -1
+	}
+	var count int
+	if bkt := TxRawBucket(tx, indexName); bkt != nil {
+		vpack.FromBytesInto(bkt.Get(_Concat(IndexCountPrefix, termBytes)), &count, PackCountFn)
+	}
+	return count
+}
+
+func _BucketEstimate(tx *Tx, name string) int {
+	if tx == nil {
+		return -1
+	}
+	if bkt := TxRawBucket(tx, name); bkt != nil {
+		return bkt.Stats().KeyN
+	}
+	return 0
+}
+
+// adds the steps that produce the candidates: a bucket scan, or the terms
+func (p *QueryPlan) _ExplainCandidates(tx *Tx, add func(step PlanStep)) {
+	direction := "forward"
+	if p.Paging.Direction == IterateReverse {
+		direction = "reverse"
+	}
+
+	if len(p.Conditions) == 0 && len(p.Exprs) == 0 {
+		add(PlanStep{Operation: "scan bucket", Structure: p.BucketName, Estimate: _BucketEstimate(tx, p.BucketName), Detail: direction})
+		return
+	}
+
+	if len(p.Exprs) > 0 {
+		root := QueryExpr{And: append([]QueryExpr(nil), p.Exprs...)}
+		for i := range p.Conditions {
+			root.And = append(root.And, TermExpr(p.Conditions[i]))
+		}
+		_ExplainExpr(tx, &root, direction, true, add)
+		return
+	}
+
+	driverIdx := 0
+	if tx != nil {
+		driverIdx = _QueryDriver(tx, p.Conditions, p.Paging.Cursor)
+	}
+	driver := &p.Conditions[driverIdx]
+	add(PlanStep{
+		Operation: "iterate term",
+		Structure: driver.Index.Name,
+		Prefix:    _Concat(IndexTermPrefix, driver.TermBytes),
+		Estimate:  _TermCountEstimate(tx, driver.Index.Name, driver.TermBytes),
+		Detail:    fmt.Sprintf("term %v, %s", driver.Term, direction),
+	})
+	for i, c := range p.Conditions {
+		if i == driverIdx {
+			continue
+		}
+		add(PlanStep{
+			Operation: "check term",
+			Structure: c.Index.Name,
+			Estimate:  _TermCountEstimate(tx, c.Index.Name, c.TermBytes),
+			Detail:    fmt.Sprintf("term %v, one lookup per candidate", c.Term),
+		})
+	}
+}
+
+// iterate is false for the parts that are only checked against candidates
+func _ExplainExpr(tx *Tx, expr *QueryExpr, direction string, iterate bool, add func(step PlanStep)) {
+	switch {
+	case expr.Term != nil:
+		c := expr.Term
+		if iterate {
+			add(PlanStep{
+				Operation: "iterate term",
+				Structure: c.Index.Name,
+				Prefix:    _Concat(IndexTermPrefix, c.TermBytes),
+				Estimate:  _TermCountEstimate(tx, c.Index.Name, c.TermBytes),
+				Detail:    fmt.Sprintf("term %v, %s", c.Term, direction),
+			})
+		} else {
+			add(PlanStep{
+				Operation: "check term",
+				Structure: c.Index.Name,
+				Estimate:  _TermCountEstimate(tx, c.Index.Name, c.TermBytes),
+				Detail:    fmt.Sprintf("term %v, one lookup per candidate", c.Term),
+			})
+		}
+
+	case len(expr.And) > 0:
+		driverIdx := -1
+		if iterate {
+			driverIdx = 0
+			if tx != nil {
+				driverIdx = _ExprDriver(tx, expr.And)
+			}
+			_ExplainExpr(tx, &expr.And[driverIdx], direction, true, add)
+		}
+		for i := range expr.And {
+			if i != driverIdx {
+				_ExplainExpr(tx, &expr.And[i], direction, false, add)
+			}
+		}
+
+	default:
+		estimate := -1
+		if tx != nil {
+			estimate = _ExprEstimate(tx, expr)
+		}
+		operation := "check union"
+		if iterate {
+			operation = "union"
+		}
+		add(PlanStep{Operation: operation, Estimate: estimate, Detail: fmt.Sprintf("%d parts, streamed", len(expr.Or))})
+		for i := range expr.Or {
+			_ExplainExpr(tx, &expr.Or[i], direction, iterate, add)
+		}
+	}
+}
+
+// Explain describes how the plan would be executed (by ExecPlan, or Fetch
+// for a QueryBuilder). tx is used to read the counts that drive planning; it
+// can be nil, in which case the first condition drives the iteration and no
+// estimates are given.
+func (p *QueryPlan) Explain(tx *Tx) (plan PlanDescription) {
+	add := func(step PlanStep) {
+		plan.Steps = append(plan.Steps, step)
+	}
+	p._ExplainCandidates(tx, add)
+	add(PlanStep{Operation: "window", Estimate: -1, Detail: fmt.Sprintf("offset %d, limit %d, stops early", p.Paging.Offset, p.Paging.Limit)})
+	if p.BucketName != "" && (len(p.Conditions) > 0 || len(p.Exprs) > 0) {
+		add(PlanStep{Operation: "load records", Structure: p.BucketName, Estimate: -1})
+	}
+	return
+}
+
+// Explain describes how Fetch would execute the query. tx is used to read
+// the counts that drive planning; it can be nil, in which case the first
+// condition drives the iteration and no estimates are given.
+func (q *QueryBuilder[K, T]) Explain(tx *Tx) (plan PlanDescription) {
+	add := func(step PlanStep) {
+		plan.Steps = append(plan.Steps, step)
+	}
+	q._ExplainCandidates(tx, add)
+	if len(q.Conditions) > 0 || len(q.Exprs) > 0 {
+		add(PlanStep{Operation: "load records", Structure: q.Bucket.Name, Estimate: -1})
+	}
+
+	if len(q.Filters) > 0 {
+		add(PlanStep{Operation: "filter", Estimate: -1, Detail: fmt.Sprintf("%d filter functions", len(q.Filters))})
+	}
+	if q.Less == nil {
+		add(PlanStep{Operation: "window", Estimate: -1, Detail: fmt.Sprintf("offset %d, limit %d, stops early", q.Paging.Offset, q.Paging.Limit)})
+	} else {
+		detail := "all matches"
+		if q.Paging.Limit > 0 {
+			detail = fmt.Sprintf("top %d", q.Paging.Offset+q.Paging.Limit)
+		}
+		add(PlanStep{Operation: "sort", Estimate: -1, InMemory: true, Detail: detail})
+	}
+	return
+}
+
+// ExplainQuery describes how ExecQuery would execute the query
+func ExplainQuery(tx *Tx, dbInfo *Info, query *Query) (plan PlanDescription, err error) {
+	compiled, err := query.Compile(dbInfo)
+	if err != nil {
+		return
+	}
+	return compiled.Explain(tx), nil
+}
@@ -0,0 +1,108 @@
+package vbolt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+)
+
+// FixtureRecord is the json shape of each entry in a fixture file
+type FixtureRecord struct {
+	Key   json.RawMessage `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// LoadFixtures seeds the buckets registered in dbInfo from files in fsys.
+//
+// For a bucket named "users", the file is either "users.jsonl" (one record per
+// line) or "users.json" (an array of records), where each record looks like
+// {"key": ..., "value": ...}. Buckets without a file are left alone.
+//
+// Everything is written in a single transaction. The afterLoad functions are
+// called in that same transaction before committing; use them to rebuild the
+// indexes bound to the loaded buckets.
+func LoadFixtures(db *DB, dbInfo *Info, fsys fs.FS, afterLoad ...func(tx *Tx)) error {
+	tx := WriteTx(db)
+	defer TxClose(tx)
+
+	for _, name := range dbInfo.BucketList {
+		g, ok := AsGenericBucket(dbInfo.Infos[name])
+		if !ok {
+			continue
+		}
+		records, err := _ReadFixtureFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		bkt := TxRawBucket(tx, name)
+		for i, record := range records {
+			key, value, err := _PackFixtureRecord(&g, &record)
+			if err != nil {
+				return fmt.Errorf("fixture %s, record %d: %w", name, i+1, err)
+			}
+			RawMustPut(bkt, key, value)
+		}
+	}
+
+	for _, fn := range afterLoad {
+		fn(tx)
+	}
+	return TxCommitE(tx)
+}
+
+func _ReadFixtureFile(fsys fs.FS, name string) (records []FixtureRecord, err error) {
+	data, err := fs.ReadFile(fsys, name+".jsonl")
+	if err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(nil, len(data)+1)
+		for line := 1; scanner.Scan(); line++ {
+			text := bytes.TrimSpace(scanner.Bytes())
+			if len(text) == 0 {
+				continue
+			}
+			var record FixtureRecord
+			if err = json.Unmarshal(text, &record); err != nil {
+				return nil, fmt.Errorf("%s.jsonl line %d: %w", name, line, err)
+			}
+			records = append(records, record)
+		}
+		return records, scanner.Err()
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	data, err = fs.ReadFile(fsys, name+".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("%s.json: %w", name, err)
+	}
+	return records, nil
+}
+
+// decodes the json key and value into the bucket types and serializes them
+func _PackFixtureRecord(g *GenericBucketInfo, record *FixtureRecord) (key []byte, value []byte, err error) {
+	keyPtr := reflect.New(g.KeyType)
+	if err = json.Unmarshal(record.Key, keyPtr.Interface()); err != nil {
+		return nil, nil, fmt.Errorf("key: %w", err)
+	}
+	if keyPtr.Elem().IsZero() {
+		return nil, nil, ErrZeroKey
+	}
+	valuePtr := reflect.New(g.ValueType)
+	if err = json.Unmarshal(record.Value, valuePtr.Interface()); err != nil {
+		return nil, nil, fmt.Errorf("value: %w", err)
+	}
+	key = GenericPackKey(g, keyPtr.Interface())
+	value = reflectPack(g.ValuePackFn, valuePtr.Interface())
+	return
+}
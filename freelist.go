@@ -0,0 +1,81 @@
+package vbolt
+
+import (
+	"os"
+	"time"
+)
+
+/*
+	Free pages in a bolt file are reused by later writes, but the file never
+	shrinks, and pages freed while a read tx is open can't be reused until it
+	closes. So a long read tx during heavy writes can bloat the file a lot,
+	and nothing shows it until the disk fills up.
+
+	WatchFragmentation polls the freelist and calls a function when the
+	free pages become too large a part of the file. The fix is usually to
+	find the long read tx, and then compact the file (e.g. bolt compact).
+*/
+
+type FragmentationStats struct {
+	FileSize     int64
+	DataSize     int64 // size of the file in use by the current tx (high water mark)
+	FreePages    int
+	PendingPages int // freed, but still reachable by open read txs
+	FreeBytes    int64
+	FreeRatio    float64 // FreeBytes / DataSize
+	OpenTxN      int
+}
+
+func ReadFragmentation(db *DB) (stats FragmentationStats) {
+	if fi, err := os.Stat(db.Path()); err == nil {
+		stats.FileSize = fi.Size()
+	}
+	dbStats := db.Stats()
+	stats.FreePages = dbStats.FreePageN
+	stats.PendingPages = dbStats.PendingPageN
+	stats.OpenTxN = dbStats.OpenTxN
+	stats.FreeBytes = int64(stats.FreePages+stats.PendingPages) * int64(_PageSize(db))
+	WithReadTx(db, func(tx *Tx) {
+		stats.DataSize = tx.Size()
+	})
+	if stats.DataSize > 0 {
+		stats.FreeRatio = float64(stats.FreeBytes) / float64(stats.DataSize)
+	}
+	return
+}
+
+// WatchFragmentation checks the freelist every interval, and calls alert
+// (and logs a warning) when FreeRatio goes above threshold. It's called again
+// only after the ratio drops below the threshold and then goes above it again.
+//
+// Returns a function to stop watching.
+func WatchFragmentation(db *DB, interval time.Duration, threshold float64, alert func(stats FragmentationStats)) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		alerted := false
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			stats := ReadFragmentation(db)
+			if stats.FreeRatio <= threshold {
+				alerted = false
+				continue
+			}
+			if alerted {
+				continue
+			}
+			alerted = true
+			Logf("vbolt: %.0f%% of %s is free pages (%d free, %d pending, %d open txs); consider compacting",
+				stats.FreeRatio*100, db.Path(), stats.FreePages, stats.PendingPages, stats.OpenTxN)
+			if alert != nil {
+				alert(stats)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,167 @@
+package vbolt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	The integrity guard checks the index entries touched by a write
+	transaction right before it's committed, and refuses to commit if they are
+	inconsistent. Unlike CheckInvariants, it only looks at what the
+	transaction modified, so it's cheap enough to leave on in tests and
+	staging (and even production, for small transactions).
+
+	Checked for every touched index term: the stored count matches the number
+	of targets. For every touched (target, term) pair: the reverse entry has a
+	matching forward entry.
+*/
+
+// IntegrityGuard enables the commit-level integrity checks in TxCommit and TxCommitE
+var IntegrityGuard bool
+
+var ErrIntegrity = errors.New("vbolt: integrity check failed")
+
+type IntegrityError struct {
+	Violations []Violation
+}
+
+func (e *IntegrityError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "vbolt: integrity check failed; commit rejected:")
+	for _, v := range e.Violations {
+		b.WriteString("\n  ")
+		b.WriteString(v.String())
+	}
+	return b.String()
+}
+
+func (e *IntegrityError) Is(target error) bool {
+	return target == ErrIntegrity
+}
+
+type _TouchedPair struct {
+	Target string
+	Term   string
+}
+
+type _TxTouches struct {
+	Terms map[string]map[string]bool       // index name => packed terms
+	Pairs map[string]map[_TouchedPair]bool // index name => packed pairs
+}
+
+var _txTouches sync.Map // *Tx => *_TxTouches
+
+func _TouchesFor(tx *Tx) *_TxTouches {
+	t, _ := _txTouches.LoadOrStore(tx, &_TxTouches{
+		Terms: make(map[string]map[string]bool),
+		Pairs: make(map[string]map[_TouchedPair]bool),
+	})
+	return t.(*_TxTouches)
+}
+
+// records that the (target, term) pair was modified by tx, if the guard is on
+func _TouchIndexPair[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target *K, term *T) {
+	if !IntegrityGuard {
+		return
+	}
+	touches := _TouchesFor(tx)
+	termBytes := string(vpack.ToBytes(term, indexInfo.TermPackFn))
+	targetBytes := string(vpack.ToBytes(target, indexInfo.TargetPackFn))
+	if touches.Terms[indexInfo.Name] == nil {
+		touches.Terms[indexInfo.Name] = make(map[string]bool)
+		touches.Pairs[indexInfo.Name] = make(map[_TouchedPair]bool)
+	}
+	touches.Terms[indexInfo.Name][termBytes] = true
+	touches.Pairs[indexInfo.Name][_TouchedPair{targetBytes, termBytes}] = true
+}
+
+func _CheckTouches(tx *Tx) error {
+	t, ok := _txTouches.LoadAndDelete(tx)
+	if !ok {
+		return nil
+	}
+	touches := t.(*_TxTouches)
+
+	var violations []Violation
+	report := func(name string, kind ViolationKind, detail string) {
+		violations = append(violations, Violation{Structure: name, Kind: kind, Detail: detail})
+	}
+
+	for name, terms := range touches.Terms {
+		bkt := TxRawBucket(tx, name)
+		for term := range terms {
+			actual := 0
+			RawIterate(bkt, _Concat(IndexTermPrefix, []byte(term)), Window{}, func(key []byte, value []byte) bool {
+				actual++
+				return true
+			})
+			var stored int
+			vpack.FromBytesInto(bkt.Get(_Concat(IndexCountPrefix, []byte(term))), &stored, PackCountFn)
+			if stored != actual {
+				report(name, ViolationTermCount, fmt.Sprintf("term %x: stored %d, actual %d", term, stored, actual))
+			}
+		}
+	}
+
+	for name, pairs := range touches.Pairs {
+		bkt := TxRawBucket(tx, name)
+		for pair := range pairs {
+			priority := bkt.Get(_Concat(IndexTargetPrefix, []byte(pair.Target), []byte(pair.Term)))
+			if priority == nil {
+				continue
+			}
+			forward := _Concat(IndexTermPrefix, []byte(pair.Term), priority, []byte(pair.Target))
+			if !RawHasKey(bkt, forward) {
+				report(name, ViolationMissingForward, fmt.Sprintf("target %x term %x", pair.Target, pair.Term))
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &IntegrityError{Violations: violations}
+	}
+	return nil
+}
+
+// TxCommitE commits the transaction, returning any error instead of ignoring
+// it. With IntegrityGuard on, the transaction is rolled back instead if the
+// entries it touched are inconsistent, and an *IntegrityError is returned.
+func TxCommitE(tx *Tx) error {
+	changes, err := _CommitTx(tx)
+	_RunChanges(changes)
+	return err
+}
+
+// like TxCommitE, but returns the watch callbacks of the committed changes
+// instead of running them, for callers that hold the write gate and must
+// release it first
+func _CommitTx(tx *Tx) (changes []func(), err error) {
+	if tx == nil {
+		return nil, nil
+	}
+	err = _CheckTouches(tx)
+	if err == nil {
+		err = _WriteJournal(tx)
+	}
+	if err != nil {
+		tx.Rollback()
+		_ReleaseWriteGate(tx)
+		_ForgetTx(tx)
+		return nil, err
+	}
+	_CrashPoint("commit:before")
+	err = tx.Commit()
+	_ReleaseWriteGate(tx)
+	_CrashPoint("commit:after")
+	if err == nil {
+		_BumpCommitted(tx)
+		changes = _TakeTxChanges(tx)
+	}
+	_ForgetTx(tx)
+	return changes, err
+}
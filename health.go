@@ -0,0 +1,134 @@
+package vbolt
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.hasen.dev/vpack"
+)
+
+// system bucket; the health check writes the time of the last check here
+// (only with HealthCheckWrite)
+var HealthChecks = Bucket(&dbInfo, "health", vpack.StringZ, vpack.UnixTime)
+
+// thresholds used by HealthCheck
+var HealthMaxFreeRatio = 0.5              // free pages / file size
+var HealthMaxBackupAge = time.Duration(0) // 0 disables the backup check
+
+// HealthCheck only reads by default. Set this to also commit a small write tx
+// on every check; that catches a read-only or full disk, but every check costs
+// an fsync and takes the write lock, which adds up with frequent probes.
+var HealthCheckWrite = false
+
+type Health struct {
+	OK       bool
+	Problems []string
+
+	FileSize     int64
+	FreeBytes    int64
+	FreeRatio    float64
+	ReadLatency  time.Duration // of the read tx
+	WriteLatency time.Duration // of the round trip write tx; 0 without HealthCheckWrite
+	LastBackup   time.Time
+}
+
+// HealthCheck verifies that the database is open (by reading in a read tx),
+// that the registered buckets exist, that the free pages are not an outsized
+// part of the file, and (if HealthMaxBackupAge is set) that a backup was made
+// recently. With HealthCheckWrite, it also verifies that the database is
+// writable, by committing a small write tx and reading it back.
+//
+// Meant for readiness/liveness probes; see also the health debug route.
+func HealthCheck(db *DB, info *Info) (health Health) {
+	problem := func(format string, args ...any) {
+		health.Problems = append(health.Problems, fmt.Sprintf(format, args...))
+	}
+
+	if db == nil {
+		problem("database is nil")
+		return
+	}
+
+	// round trip
+	var written time.Time
+	if HealthCheckWrite {
+		start := time.Now()
+		written = Now().Truncate(time.Second)
+		if err := _HealthWrite(db, written); err != nil {
+			problem("write failed: %v", err)
+			written = time.Time{}
+		}
+		health.WriteLatency = time.Since(start)
+	}
+
+	start := time.Now()
+	tx, err := db.Begin(false)
+	if err != nil {
+		problem("read failed: %v", err)
+	} else {
+		if !written.IsZero() {
+			var read time.Time
+			if !Read(tx, HealthChecks, "last", &read) || !read.Equal(written) {
+				problem("written value did not read back")
+			}
+		}
+		if info != nil {
+			for _, name := range _SortedNames(info.BucketList, info.IndexList, info.CollectionList) {
+				if tx.Bucket([]byte(name)) == nil {
+					problem("missing bucket: %s", name)
+				}
+			}
+		}
+		TxClose(tx)
+	}
+	health.ReadLatency = time.Since(start)
+
+	// the in-memory backend has no file
+	if _, err := os.Stat(db.Path()); err == nil {
+		frag := ReadFragmentation(db)
+		health.FileSize = frag.FileSize
+		health.FreeBytes = frag.FreeBytes
+		health.FreeRatio = frag.FreeRatio
+		if HealthMaxFreeRatio > 0 && health.FreeRatio > HealthMaxFreeRatio {
+			problem("free pages are %.0f%% of the file; consider compacting", health.FreeRatio*100)
+		}
+	}
+
+	health.LastBackup = LastBackupTime()
+	if HealthMaxBackupAge > 0 {
+		if health.LastBackup.IsZero() {
+			problem("no backup since the process started")
+		} else if age := Now().Sub(health.LastBackup); age > HealthMaxBackupAge {
+			problem("last backup is %s old", age.Truncate(time.Second))
+		}
+	}
+
+	health.OK = len(health.Problems) == 0
+	return
+}
+
+func _HealthWrite(db *DB, ts time.Time) error {
+	tx, err := _BeginWriteTx(db)
+	if err != nil {
+		return err
+	}
+	defer TxClose(tx)
+	if err = WriteE(tx, HealthChecks, "last", &ts); err != nil {
+		return err
+	}
+	return TxCommitE(tx)
+}
+
+// HealthHandler serves HealthCheck as json, with status 503 if not OK
+func HealthHandler(db *DB, dbInfo *Info) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := HealthCheck(db, dbInfo)
+		if !health.OK {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_WriteJSON(w, health)
+	})
+}
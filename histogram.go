@@ -0,0 +1,130 @@
+package vbolt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"go.hasen.dev/vpack"
+)
+
+// DefaultHistogramBuckets groups counts into 1, 2-10, 11-100, 101-1000,
+// 1001-10000, and more
+var DefaultHistogramBuckets = []int{1, 10, 100, 1000, 10000}
+
+// Histogram describes how targets are spread over the terms of an index.
+//
+// Bounds are the inclusive upper bounds of the buckets; the counts slices have
+// one more entry, for counts above the last bound.
+type Histogram struct {
+	Bounds []int
+
+	Terms          int
+	TermsByTargets []int // number of terms with that many targets
+	MaxTargets     int   // targets of the largest term
+
+	Targets        int
+	TargetsByTerms []int // number of targets with that many terms
+	MaxTerms       int   // terms of the target with the most terms
+}
+
+func (h *Histogram) _Bucket(count int) int {
+	for i, bound := range h.Bounds {
+		if count <= bound {
+			return i
+		}
+	}
+	return len(h.Bounds)
+}
+
+// Label returns the range of bucket i, e.g. "2-10"
+func (h *Histogram) Label(i int) string {
+	low := 1
+	if i > 0 {
+		low = h.Bounds[i-1] + 1
+	}
+	if i == len(h.Bounds) {
+		return fmt.Sprintf("%d+", low)
+	}
+	if low == h.Bounds[i] {
+		return fmt.Sprint(low)
+	}
+	return fmt.Sprintf("%d-%d", low, h.Bounds[i])
+}
+
+func (h *Histogram) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d terms (max %d targets):\n", h.Terms, h.MaxTargets)
+	for i, n := range h.TermsByTargets {
+		fmt.Fprintf(&b, "  %8s targets: %d\n", h.Label(i), n)
+	}
+	fmt.Fprintf(&b, "%d targets (max %d terms):\n", h.Targets, h.MaxTerms)
+	for i, n := range h.TargetsByTerms {
+		fmt.Fprintf(&b, "  %8s terms: %d\n", h.Label(i), n)
+	}
+	return b.String()
+}
+
+// TermHistogram counts the terms of the index by how many targets they have,
+// and the targets by how many terms they have. A few terms with a huge number
+// of targets make IterateTerm on them slow.
+//
+// buckets are the inclusive upper bounds, in increasing order; nil uses
+// DefaultHistogramBuckets. Term sizes come from the stored term counts (see
+// RecountIndexTerms if they might be off); target sizes are counted by
+// scanning all the target entries.
+func TermHistogram[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], buckets []int) Histogram {
+	var h Histogram
+	h.Bounds = buckets
+	if h.Bounds == nil {
+		h.Bounds = DefaultHistogramBuckets
+	}
+	h.TermsByTargets = make([]int, len(h.Bounds)+1)
+	h.TargetsByTerms = make([]int, len(h.Bounds)+1)
+
+	bkt := TxRawBucket(tx, indexInfo.Name)
+	if bkt == nil {
+		return h
+	}
+
+	IterateTermCounts(tx, indexInfo, func(term T, count int) bool {
+		h.Terms++
+		h.TermsByTargets[h._Bucket(count)]++
+		if count > h.MaxTargets {
+			h.MaxTargets = count
+		}
+		return true
+	})
+
+	// target entries are sorted by target, so the terms of each target are
+	// consecutive
+	var prevTarget []byte
+	var count int
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		h.Targets++
+		h.TargetsByTerms[h._Bucket(count)]++
+		if count > h.MaxTerms {
+			h.MaxTerms = count
+		}
+	}
+	RawIterate(bkt, []byte{IndexTargetPrefix}, Window{}, func(key []byte, value []byte) bool {
+		var target K
+		buf := vpack.NewReader(key)
+		buf.Pos++ // skip the IndexTargetPrefix byte
+		indexInfo.TargetPackFn(&target, buf)
+		targetKey := key[:buf.Pos]
+		if !bytes.Equal(targetKey, prevTarget) {
+			flush()
+			prevTarget = targetKey
+			count = 0
+		}
+		count++
+		return true
+	})
+	flush()
+
+	return h
+}
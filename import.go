@@ -0,0 +1,221 @@
+package vbolt
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type ImportFormat uint8
+
+const (
+	// first record is the header
+	ImportCSV = ImportFormat(iota)
+
+	// either a top level json array of objects, or a stream of objects (e.g. json lines)
+	ImportJSON
+)
+
+// ImportRow is the raw record given to the mapping function
+type ImportRow struct {
+	Number int // 1-based, not counting the csv header
+
+	// csv only
+	Header []string
+	Fields []string
+
+	// json only
+	JSON json.RawMessage
+}
+
+// ImportField returns the csv field under the given header name, or "" if there's no such column
+func ImportField(row *ImportRow, name string) string {
+	for i, h := range row.Header {
+		if h == name && i < len(row.Fields) {
+			return row.Fields[i]
+		}
+	}
+	return ""
+}
+
+type ImportError struct {
+	Row int
+	Err error
+}
+
+func (e ImportError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+type ImportResult struct {
+	Imported int
+	Errors   []ImportError
+}
+
+type ImportOptions[K, T any] struct {
+	// called in the same transaction after each item is written; use it to
+	// update the indexes bound to the bucket
+	AfterWrite func(tx *Tx, key K, item *T)
+
+	// called after every committed batch with the running total
+	Progress func(imported int)
+}
+
+var ErrZeroKey = errors.New("zero value key")
+
+// ImportRecords reads records from r, converts them with mapRow, and writes
+// them to the bucket, committing every batchSize items.
+//
+// Rows that fail to parse, map, or write (e.g. violating a constraint) are
+// collected in the result and skipped. The returned error is only for
+// failures that stop the import (io errors, failed commits).
+func ImportRecords[K comparable, T any](db *DB, bucketInfo *BucketInfo[K, T], r io.Reader, format ImportFormat, mapRow func(row *ImportRow) (K, T, error), batchSize int, opts ImportOptions[K, T]) (result ImportResult, err error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	tx, err := WriteTxE(db)
+	if err != nil {
+		return
+	}
+	defer func() { // the tx gets replaced after every batch
+		TxClose(tx)
+	}()
+	var pending int
+
+	commit := func() error {
+		if pending == 0 {
+			return nil
+		}
+		if err := TxCommitE(tx); err != nil {
+			return err
+		}
+		result.Imported += pending
+		pending = 0
+		var err error
+		if tx, err = WriteTxE(db); err != nil {
+			return err
+		}
+		if opts.Progress != nil {
+			opts.Progress(result.Imported)
+		}
+		return nil
+	}
+
+	visitRow := func(row *ImportRow) error {
+		key, item, err := mapRow(row)
+		var zero K
+		if err == nil && key == zero {
+			err = ErrZeroKey
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, ImportError{Row: row.Number, Err: err})
+			return nil
+		}
+		if err := WriteE(tx, bucketInfo, key, &item); err != nil {
+			// a ConstraintError, or a RecordError for a key or value bolt
+			// rejects; nothing was written
+			result.Errors = append(result.Errors, ImportError{Row: row.Number, Err: err})
+			return nil
+		}
+		if opts.AfterWrite != nil {
+			opts.AfterWrite(tx, key, &item)
+		}
+		pending++
+		if pending >= batchSize {
+			return commit()
+		}
+		return nil
+	}
+
+	switch format {
+	case ImportCSV:
+		err = _ImportCSVRows(r, &result, visitRow)
+	case ImportJSON:
+		err = _ImportJSONRows(r, visitRow)
+	default:
+		err = fmt.Errorf("unknown import format: %d", format)
+	}
+	if err != nil {
+		return
+	}
+	err = commit()
+	if err == nil && len(result.Errors) > 0 {
+		Logf("import into %s: %d rows imported, %d rows skipped", bucketInfo.Name, result.Imported, len(result.Errors))
+	}
+	return
+}
+
+func _ImportCSVRows(r io.Reader, result *ImportResult, visitFn func(row *ImportRow) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for number := 1; ; number++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			result.Errors = append(result.Errors, ImportError{Row: number, Err: err})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		row := ImportRow{Number: number, Header: header, Fields: fields}
+		if err = visitFn(&row); err != nil {
+			return err
+		}
+	}
+}
+
+func _ImportJSONRows(r io.Reader, visitFn func(row *ImportRow) error) error {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+
+	// peek at the first non-space byte to see if it's an array
+	isArray := false
+	for {
+		b, err := br.Peek(1)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if b[0] == ' ' || b[0] == '\t' || b[0] == '\r' || b[0] == '\n' {
+			br.ReadByte()
+			continue
+		}
+		isArray = b[0] == '['
+		break
+	}
+	if isArray {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+
+	for number := 1; dec.More(); number++ {
+		var row ImportRow
+		row.Number = number
+		if err := dec.Decode(&row.JSON); err != nil {
+			// json syntax errors leave the decoder in an unusable state
+			return ImportError{Row: number, Err: err}
+		}
+		if err := visitFn(&row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
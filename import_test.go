@@ -0,0 +1,52 @@
+package vbolt_test
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestImportRecords(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+	vbolt.AddConstraints(posts, vbolt.MaxValueSize[int, string](16))
+	db := vbolttest.NewTestDB(t, &dbInfo)
+
+	csv := "id,content\n" +
+		"1,first\n" +
+		"x,not a number\n" +
+		"2,far too long to be a post\n" +
+		"3,third\n"
+	mapRow := func(row *vbolt.ImportRow) (id int, content string, err error) {
+		id, err = strconv.Atoi(vbolt.ImportField(row, "id"))
+		content = vbolt.ImportField(row, "content")
+		return
+	}
+	// a batch of 2, so the bad rows land in the same batch as good ones
+	result, err := vbolt.ImportRecords(db, posts, strings.NewReader(csv), vbolt.ImportCSV, mapRow, 2, vbolt.ImportOptions[int, string]{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result.Imported != 2 {
+		t.Errorf("imported %d rows, expected 2", result.Imported)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Row != 2 || result.Errors[1].Row != 3 {
+		t.Fatalf("expected rows 2 and 3 to fail, got: %v", result.Errors)
+	}
+	if !errors.Is(result.Errors[1].Err, vbolt.ErrConstraint) {
+		t.Errorf("row 3: expected a constraint error, got: %v", result.Errors[1].Err)
+	}
+
+	vbolttest.View(db, func(tx *vbolt.Tx) {
+		for id, expected := range map[int]bool{1: true, 2: false, 3: true} {
+			if vbolt.HasKey(tx, posts, id) != expected {
+				t.Errorf("post %d: expected exists=%v", id, expected)
+			}
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package vbolt
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+/*
+	Differential backups
+
+	bolt does not record which keys a transaction changed, so unless the
+	journal is enabled (see RestoreToPoint), a differential backup is computed
+	against a base backup: both the base stream and the bucket are sorted by
+	key, so they're merged in one pass (without loading either into memory),
+	emitting the items that were added or changed since the base, and a
+	DELETE_HEADER record for every key that's gone.
+
+	To restore, RestoreBuckets the base, then RestoreBuckets the delta on top.
+	Each delta is relative to its base, not to the previous delta, so only the
+	latest one needs to be kept.
+
+	The base must be a full backup: a delta lists what changed relative to
+	its own base, so using it as a base would drop everything it doesn't
+	mention. Deltas are marked as such in their header, and refused as bases.
+
+	Without a journal, every delta costs a full read of the base and a full
+	scan of the buckets, however little changed.
+*/
+
+var ErrBackupNotFull = errors.New("vbolt: the base is a differential backup")
+
+// BackupBucketsSince writes to out the differences between the given buckets
+// and the full backup read from base (as produced by BackupBuckets,
+// compressed or not). Buckets that are not in base are written in full.
+func BackupBucketsSince(db *DB, base io.Reader, out io.Writer, bucketNames ...string) error {
+	tx := ReadTx(db)
+	defer TxClose(tx)
+
+	var backup _BackupBuilder
+	backup.Output = bufio.NewWriter(out)
+	header := _NewBackupHeader(db, tx, nil, bucketNames)
+	header.Delta = true
+	headerDone := false
+	writeHeader := func() {
+		if !headerDone {
+			_BackupWriteFormatHeader(&backup, header)
+			headerDone = true
+		}
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range bucketNames {
+		wanted[name] = true
+	}
+	done := make(map[string]bool)
+
+	var crsr *Cursor // nil when the current base bucket is skipped or missing from db
+	var key, value []byte
+	var inBucket bool
+
+	// writes what's left of the current bucket in db; it's all newer than base
+	flush := func() {
+		for ; crsr != nil && key != nil && backup.Error == nil; key, value = crsr.Next() {
+			_BackupWriteItem(&backup, key, value)
+		}
+		crsr = nil
+	}
+
+	err := ReadBackup(base, func(rec BackupRecord) error {
+		if rec.Kind == FORMAT_HEADER {
+			if rec.Header.Delta {
+				return ErrBackupNotFull
+			}
+			header.Base = rec.Header.Created
+			return nil
+		}
+		writeHeader() // older bases have no header record
+		switch rec.Kind {
+		case DELETE_HEADER:
+			return ErrBackupNotFull
+		case BUCKET_HEADER:
+			flush()
+			name := string(rec.Bucket)
+			inBucket = wanted[name] && !done[name]
+			if !inBucket {
+				return nil
+			}
+			done[name] = true
+			_BackupWriteBucketHeader(&backup, rec.Bucket)
+			if bkt := tx.Bucket(rec.Bucket); bkt != nil {
+				crsr = bkt.Cursor()
+				key, value = crsr.First()
+			}
+		case ITEM_HEADER:
+			if !inBucket {
+				return nil
+			}
+			for crsr != nil && key != nil && bytes.Compare(key, rec.Key) < 0 {
+				_BackupWriteItem(&backup, key, value)
+				key, value = crsr.Next()
+			}
+			if crsr != nil && bytes.Equal(key, rec.Key) {
+				if !bytes.Equal(value, rec.Value) {
+					_BackupWriteItem(&backup, key, value)
+				}
+				key, value = crsr.Next()
+			} else {
+				_BackupWriteDelete(&backup, rec.Key)
+			}
+		}
+		return backup.Error
+	})
+	flush()
+	writeHeader()
+	ChannelError(&backup.Error, err)
+
+	for _, name := range bucketNames {
+		if done[name] || backup.Error != nil {
+			continue
+		}
+		bkt := tx.Bucket([]byte(name))
+		if bkt == nil {
+			continue
+		}
+		_BackupWriteBucketHeader(&backup, []byte(name))
+		bkt.ForEach(func(key []byte, value []byte) error {
+			_BackupWriteItem(&backup, key, value)
+			return backup.Error
+		})
+	}
+
+	_BackupFinish(&backup)
+	return backup.Error
+}
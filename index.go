@@ -1,6 +1,10 @@
 package vbolt
 
 import (
+	"bytes"
+	"errors"
+	"time"
+
 	"go.hasen.dev/generic"
 	"go.hasen.dev/vpack"
 )
@@ -49,13 +53,17 @@ func Index[K, T comparable](dbInfo *Info, name string, termFn vpack.PackFn[T], t
 }
 
 func IndexExt[K, T, P comparable](dbInfo *Info, name string, termFn vpack.PackFn[T], priorityFn vpack.PackFn[P], targetFn vpack.PackFn[K]) *IndexInfo[K, T, P] {
+	_WarnIfRegistered(dbInfo, name)
 	generic.Append(&dbInfo.IndexList, name)
-	return &IndexInfo[K, T, P]{
+	generic.EnsureMapNotNil(&dbInfo.Infos)
+	result := &IndexInfo[K, T, P]{
 		Name:           name,
 		TargetPackFn:   targetFn,
 		TermPackFn:     termFn,
 		PriorityPackFn: priorityFn,
 	}
+	dbInfo.Infos[name] = result
+	return result
 }
 
 func _TermKeyPrefix[K, T, P comparable](indexInfo *IndexInfo[K, T, P], term *T) []byte {
@@ -106,14 +114,17 @@ func _TargetTermKey[K, T, P comparable](indexInfo *IndexInfo[K, T, P], target *K
 
 var PackCountFn = vpack.Int
 
-func _IncTermCount[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term *T, increment int) {
+func _IncTermCount[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term *T, increment int) error {
 	key := _TermCountKey(indexInfo, term)
-	bkt := TxRawBucket(tx, indexInfo.Name)
+	bkt, err := _TxWriteBucketE(tx, indexInfo.Name)
+	if err != nil {
+		return err
+	}
 	v := bkt.Get(key)
 	var count int
 	vpack.FromBytesInto(v, &count, PackCountFn)
 	count += increment
-	RawMustPut(bkt, key, vpack.ToBytes(&count, PackCountFn))
+	return RawPut(bkt, key, vpack.ToBytes(&count, PackCountFn))
 }
 
 func ReadTermCount[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term *T, count *int) bool {
@@ -123,18 +134,69 @@ func ReadTermCount[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], te
 	return vpack.FromBytesInto(v, count, PackCountFn)
 }
 
-func _AddTargetTermPair[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target *K, term *T, priority *P) {
-	val := vpack.ToBytes(priority, indexInfo.PriorityPackFn)
+// IterateTermCounts visits every term of the index with its count of
+// targets, in term order, reading only the stored counts. Terms with no
+// targets left are skipped.
+func IterateTermCounts[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], visitFn func(term T, count int) bool, opts ...IterOption) Continuation {
+	o := _IterOptions(Window{}, opts)
+	var iterParams = _RawIterationParams{
+		Prefix: append([]byte{IndexCountPrefix}, o.Prefix...),
+		Name:   indexInfo.Name,
+		Window: o.Window,
+	}
 	bkt := TxRawBucket(tx, indexInfo.Name)
-	bkt.Put(_TermTargetKey(indexInfo, target, term, priority), nil)
-	bkt.Put(_TargetTermKey(indexInfo, target, term), val)
+	if bkt == nil {
+		return nil
+	}
+	return _RawIterateCore(bkt, iterParams, func(key []byte, value []byte) bool {
+		var count int
+		vpack.FromBytesInto(value, &count, PackCountFn)
+		if count <= 0 {
+			return true
+		}
+		var term T
+		buf := vpack.NewReader(key)
+		buf.Pos++ // skip the IndexCountPrefix byte
+		indexInfo.TermPackFn(&term, buf)
+		return visitFn(term, count)
+	})
 }
 
-func _DelTargetTermPair[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target *K, term *T, priority *P) {
+// CountDistinctTerms returns the number of terms that have targets
+func CountDistinctTerms[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P]) (count int) {
+	IterateTermCounts(tx, indexInfo, func(term T, _ int) bool {
+		count++
+		return true
+	})
+	return
+}
+
+func _AddTargetTermPair[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target *K, term *T, priority *P) error {
+	val := vpack.ToBytes(priority, indexInfo.PriorityPackFn)
+	bkt, err := _TxWriteBucketE(tx, indexInfo.Name)
+	if err != nil {
+		return err
+	}
+	_TouchIndexPair(tx, indexInfo, target, term)
+	_MarkDirty(tx, indexInfo.Name)
+	if err = _Put(tx, bkt, indexInfo.Name, _TermTargetKey(indexInfo, target, term, priority), nil); err != nil {
+		return err
+	}
+	return _Put(tx, bkt, indexInfo.Name, _TargetTermKey(indexInfo, target, term), val)
+}
+
+func _DelTargetTermPair[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target *K, term *T, priority *P) error {
 	targetTermKey := _TargetTermKey(indexInfo, target, term)
-	bkt := TxRawBucket(tx, indexInfo.Name)
-	bkt.Delete(_TermTargetKey(indexInfo, target, term, priority))
-	bkt.Delete(targetTermKey)
+	bkt, err := _TxWriteBucketE(tx, indexInfo.Name)
+	if err != nil {
+		return err
+	}
+	_TouchIndexPair(tx, indexInfo, target, term)
+	_MarkDirty(tx, indexInfo.Name)
+	if err = _Del(tx, bkt, indexInfo.Name, _TermTargetKey(indexInfo, target, term, priority)); err != nil {
+		return err
+	}
+	return _Del(tx, bkt, indexInfo.Name, targetTermKey)
 }
 
 func _PlainTerms[T, P comparable](terms []T) map[T]P {
@@ -174,6 +236,28 @@ func SetTargetTermsUniform[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T
 // Updates target,term pairs so that only the terms provided here point to target.
 // terms map the term to the priority
 func SetTargetTerms[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, terms map[T]P) {
+	generic.MustOK(SetTargetTermsE(tx, indexInfo, target, terms))
+}
+
+// SetTargetTermsE is like SetTargetTerms but returns errors instead of panicking
+func SetTargetTermsE[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, terms map[T]P) error {
+	return _RecordErr("set terms", indexInfo.Name, target, _SetTargetTerms(tx, indexInfo, target, terms))
+}
+
+func _SetTargetTerms[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, terms map[T]P) error {
+	// fail early if the tx is read-only
+	if _, err := _TxWriteBucketE(tx, indexInfo.Name); err != nil {
+		return err
+	}
+	if SlowOps.SetTargetTerms > 0 {
+		start := time.Now()
+		defer func() {
+			if elapsed := time.Since(start); elapsed >= SlowOps.SetTargetTerms {
+				Logf("vbolt: slow SetTargetTerms on %s: %d terms in %s", indexInfo.Name, len(terms), elapsed)
+			}
+		}()
+	}
+
 	var existing = make(map[T]P)
 
 	// read out the list of existing index terms so we can get the list of actual bucket keys to add / remove
@@ -200,27 +284,300 @@ func SetTargetTerms[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], t
 	}
 
 	for term, priority := range del {
-		_DelTargetTermPair(tx, indexInfo, &target, &term, &priority)
-		_IncTermCount(tx, indexInfo, &term, -1)
+		if err := _DelTargetTermPair(tx, indexInfo, &target, &term, &priority); err != nil {
+			return err
+		}
+		if err := _IncTermCount(tx, indexInfo, &term, -1); err != nil {
+			return err
+		}
 	}
 
 	for term, priority := range add {
-		_AddTargetTermPair(tx, indexInfo, &target, &term, &priority)
-		_IncTermCount(tx, indexInfo, &term, 1)
+		if err := _AddTargetTermPair(tx, indexInfo, &target, &term, &priority); err != nil {
+			return err
+		}
+		if err := _IncTermCount(tx, indexInfo, &term, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTargetTerm adds a single term to target (or changes its priority),
+// keeping the term count right. Cheaper than SetTargetTerms when only one
+// term changes.
+func AddTargetTerm[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, term T, priority P) {
+	generic.MustOK(AddTargetTermE(tx, indexInfo, target, term, priority))
+}
+
+func AddTargetTermE[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, term T, priority P) error {
+	return _RecordErr("add term", indexInfo.Name, target, _AddTargetTerm(tx, indexInfo, target, term, priority))
+}
+
+func _AddTargetTerm[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, term T, priority P) error {
+	bkt, err := _TxWriteBucketE(tx, indexInfo.Name)
+	if err != nil {
+		return err
+	}
+	if value := bkt.Get(_TargetTermKey(indexInfo, &target, &term)); value != nil {
+		var existing P
+		vpack.FromBytesInto(value, &existing, indexInfo.PriorityPackFn)
+		if existing == priority {
+			return nil
+		}
+		if err = _DelTargetTermPair(tx, indexInfo, &target, &term, &existing); err != nil {
+			return err
+		}
+		return _AddTargetTermPair(tx, indexInfo, &target, &term, &priority)
+	}
+	if err = _AddTargetTermPair(tx, indexInfo, &target, &term, &priority); err != nil {
+		return err
+	}
+	return _IncTermCount(tx, indexInfo, &term, 1)
+}
+
+// RemoveTargetTerm removes a single term from target, if it has it, keeping
+// the term count right
+func RemoveTargetTerm[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, term T) {
+	generic.MustOK(RemoveTargetTermE(tx, indexInfo, target, term))
+}
+
+func RemoveTargetTermE[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, term T) error {
+	return _RecordErr("remove term", indexInfo.Name, target, _RemoveTargetTerm(tx, indexInfo, target, term))
+}
+
+func _RemoveTargetTerm[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, term T) error {
+	bkt, err := _TxWriteBucketE(tx, indexInfo.Name)
+	if err != nil {
+		return err
+	}
+	value := bkt.Get(_TargetTermKey(indexInfo, &target, &term))
+	if value == nil {
+		return nil
+	}
+	var priority P
+	vpack.FromBytesInto(value, &priority, indexInfo.PriorityPackFn)
+	if err = _DelTargetTermPair(tx, indexInfo, &target, &term, &priority); err != nil {
+		return err
+	}
+	return _IncTermCount(tx, indexInfo, &term, -1)
+}
+
+var ErrTermsUnsorted = errors.New("vbolt: terms not in increasing order")
+
+// SetTargetTermsStream is like SetTargetTerms, for targets with too many terms
+// to hold in memory: instead of a map, next yields the terms (until ok is
+// false) in increasing order of their packed bytes, without duplicates. The
+// existing terms are merged with them one at a time, so memory use doesn't
+// depend on the number of terms.
+//
+// If the order is wrong it returns ErrTermsUnsorted, with the changes up to
+// that point made; the tx should be rolled back.
+func SetTargetTermsStream[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, next func() (term T, priority P, ok bool)) error {
+	return _RecordErr("set terms", indexInfo.Name, target, _SetTargetTermsStream(tx, indexInfo, target, next))
+}
+
+func _SetTargetTermsStream[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, next func() (term T, priority P, ok bool)) error {
+	bkt, err := _TxWriteBucketE(tx, indexInfo.Name)
+	if err != nil {
+		return err
 	}
+	prefix := _TargetKeyPrefix(indexInfo, &target)
+
+	// the bucket changes as we go, which invalidates cursors (and the slices
+	// they return), so every step seeks with a fresh one
+	seek := func(key []byte, after bool) (k []byte, v []byte) {
+		c := bkt.Cursor()
+		k, v = c.Seek(key)
+		if after && bytes.Equal(k, key) {
+			k, v = c.Next()
+		}
+		if !bytes.HasPrefix(k, prefix) {
+			return nil, nil
+		}
+		return bytes.Clone(k), bytes.Clone(v)
+	}
+
+	exKey, exValue := seek(prefix, false)
+
+	var inTerm T
+	var inPriority P
+	var inKey, prevInKey []byte
+	advance := func() error {
+		var ok bool
+		inTerm, inPriority, ok = next()
+		if !ok {
+			inKey = nil
+			return nil
+		}
+		prevInKey, inKey = inKey, _TargetTermKey(indexInfo, &target, &inTerm)
+		if prevInKey != nil && bytes.Compare(inKey, prevInKey) <= 0 {
+			return ErrTermsUnsorted
+		}
+		return nil
+	}
+	if err = advance(); err != nil {
+		return err
+	}
+
+	for exKey != nil || inKey != nil {
+		cmp := 0
+		switch {
+		case exKey == nil:
+			cmp = 1
+		case inKey == nil:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(exKey, inKey)
+		}
+
+		var exTerm T
+		var exPriority P
+		if cmp <= 0 {
+			_, exTerm = _ReadTargetTerm(indexInfo, exKey)
+			vpack.FromBytesInto(exValue, &exPriority, indexInfo.PriorityPackFn)
+		}
+
+		switch {
+		case cmp < 0: // no longer wanted
+			if err = _DelTargetTermPair(tx, indexInfo, &target, &exTerm, &exPriority); err != nil {
+				return err
+			}
+			if err = _IncTermCount(tx, indexInfo, &exTerm, -1); err != nil {
+				return err
+			}
+		case cmp > 0: // new
+			if err = _AddTargetTermPair(tx, indexInfo, &target, &inTerm, &inPriority); err != nil {
+				return err
+			}
+			if err = _IncTermCount(tx, indexInfo, &inTerm, 1); err != nil {
+				return err
+			}
+		case exPriority != inPriority:
+			if err = _DelTargetTermPair(tx, indexInfo, &target, &exTerm, &exPriority); err != nil {
+				return err
+			}
+			if err = _AddTargetTermPair(tx, indexInfo, &target, &inTerm, &inPriority); err != nil {
+				return err
+			}
+		}
+
+		if cmp <= 0 {
+			exKey, exValue = seek(exKey, true)
+		}
+		if cmp >= 0 {
+			if err = advance(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IterateTerm visits the targets of term, ordered by priority (then target),
+// lowest first; with the Reverse option, highest first
+func IterateTerm[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term T, visitFn func(target K, priority P) bool, opts ...IterOption) Continuation {
+	o := _IterOptions(Window{}, opts)
+	prefix := append(_TermKeyPrefix(indexInfo, &term), o.Prefix...)
+	var iterParams = _RawIterationParams{
+		Prefix: prefix,
+		Name:   indexInfo.Name,
+		Window: o.Window,
+	}
+	if o.Lower != nil {
+		iterParams.Lower = append(bytes.Clone(prefix), o.Lower...)
+	}
+	if o.Upper != nil {
+		iterParams.Upper = append(bytes.Clone(prefix), o.Upper...)
+	}
+	return _RawIterateCore(TxRawBucket(tx, indexInfo.Name), iterParams, func(key []byte, v []byte) bool {
+		_, target, priority := _ReadTermTargetPriority(indexInfo, key)
+		return visitFn(target, priority)
+	})
+}
+
+// IterateTermReverse visits the targets of term from the highest priority
+// down (targets with the same priority are in reverse order too)
+func IterateTermReverse[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term T, visitFn func(target K, priority P) bool, opts ...IterOption) Continuation {
+	return IterateTerm(tx, indexInfo, term, visitFn, append([]IterOption{Reverse()}, opts...)...)
 }
 
-func IterateTerm[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term T, visitFn func(target K, priority P) bool) []byte {
-	return _IterateTermCore(tx, indexInfo, term, Window{}, visitFn)
+// IterateTermRange visits the entries of all the terms from minTerm to
+// maxTerm (inclusive), ordered by term, then priority. Terms are compared by
+// their packed bytes, so the range only makes sense with pack functions that
+// preserve order (e.g. fixed size ints, which are big endian).
+func IterateTermRange[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], minTerm T, maxTerm T, visitFn func(target K, term T, priority P) bool, opts ...IterOption) Continuation {
+	o := _IterOptions(Window{}, opts)
+	var iterParams = _RawIterationParams{
+		Prefix: []byte{IndexTermPrefix},
+		Name:   indexInfo.Name,
+		Lower:  _TermKeyPrefix(indexInfo, &minTerm),
+		Upper:  _NextPrefix(_TermKeyPrefix(indexInfo, &maxTerm)),
+		Window: o.Window,
+	}
+	return _RawIterateCore(TxRawBucket(tx, indexInfo.Name), iterParams, func(key []byte, v []byte) bool {
+		term, target, priority := _ReadTermTargetPriority(indexInfo, key)
+		return visitFn(target, term, priority)
+	})
 }
 
-func ReadTermTargets[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term T, targets *[]K, window Window) []byte {
+// IterateTermPrefix visits the entries of all the terms whose packed form
+// starts with termPrefix, ordered by term, then priority.
+//
+// The prefix is matched against the packed bytes, so it has to be packed the
+// same way as the terms, minus whatever the pack function adds after the
+// shared part. E.g. for terms packed with vpack.StringZ (zero terminated),
+// the tags starting with "go" are []byte("go"); length prefixed strings can't
+// be matched this way.
+func IterateTermPrefix[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], termPrefix []byte, visitFn func(target K, term T, priority P) bool, opts ...IterOption) Continuation {
+	return _IterateTermsPrefix(tx, indexInfo, _Concat(IndexTermPrefix, termPrefix), opts, visitFn)
+}
+
+// visits the entries of all the terms whose packed form starts with keyPrefix
+// (which includes the IndexTermPrefix byte)
+func _IterateTermsPrefix[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], keyPrefix []byte, opts []IterOption, visitFn func(target K, term T, priority P) bool) Continuation {
+	o := _IterOptions(Window{}, opts)
+	var iterParams = _RawIterationParams{
+		Prefix: append(keyPrefix, o.Prefix...),
+		Name:   indexInfo.Name,
+		Window: o.Window,
+	}
+	return _RawIterateCore(TxRawBucket(tx, indexInfo.Name), iterParams, func(key []byte, v []byte) bool {
+		term, target, priority := _ReadTermTargetPriority(indexInfo, key)
+		return visitFn(target, term, priority)
+	})
+}
+
+func ReadTermTargets[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term T, targets *[]K, window Window) Continuation {
 	return _IterateTermCore(tx, indexInfo, term, window, func(target K, priority P) bool {
 		generic.Append(targets, target)
 		return true
 	})
 }
 
+// ReadTermRecords reads the records (from bucketInfo) of the targets of term,
+// in one pass. Targets that have no record are skipped. The window applies to
+// the index entries, so a page may have fewer records than window.Limit if
+// some are missing.
+func ReadTermRecords[K, T, P comparable, V any](tx *Tx, indexInfo *IndexInfo[K, T, P], bucketInfo *BucketInfo[K, V], term T, window Window, items *[]V) Continuation {
+	return ReadTermRecordsExt(tx, indexInfo, bucketInfo, term, window, items, nil)
+}
+
+// ReadTermRecordsExt is like ReadTermRecords, but also appends the targets
+// that have no record to missing (if not nil)
+func ReadTermRecordsExt[K, T, P comparable, V any](tx *Tx, indexInfo *IndexInfo[K, T, P], bucketInfo *BucketInfo[K, V], term T, window Window, items *[]V, missing *[]K) Continuation {
+	bkt := TxRawBucket(tx, bucketInfo.Name)
+	return _IterateTermCore(tx, indexInfo, term, window, func(target K, priority P) bool {
+		var item V
+		if _Read(bkt, bucketInfo, target, &item) {
+			generic.Append(items, item)
+		} else if missing != nil {
+			generic.Append(missing, target)
+		}
+		return true
+	})
+}
+
 func ReadTermTargetSingle[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term T, target *K) bool {
 	var targets []K
 	var opts Window
@@ -244,12 +601,16 @@ type Window struct {
 
 // iterate over targets that are assigned to term
 func _IterateTermCore[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term T, window Window, visitFn func(target K, priority P) bool) []byte {
-	keyPrefix := _TermKeyPrefix(indexInfo, &term)
+	return _IterateTermRaw(tx, indexInfo, _TermKeyPrefix(indexInfo, &term), window, visitFn)
+}
 
+// iterate over the term->target entries with the given raw key prefix
+func _IterateTermRaw[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], keyPrefix []byte, window Window, visitFn func(target K, priority P) bool) []byte {
 	bkt := TxRawBucket(tx, indexInfo.Name)
 
 	var iterParams = _RawIterationParams{
 		Prefix: keyPrefix,
+		Name:   indexInfo.Name,
 		Window: window,
 	}
 
@@ -262,21 +623,25 @@ func _IterateTermCore[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P],
 }
 
 // iterate over terms that are assigned to target
-func IterateTarget[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, visitFn func(term T, priority P) bool) {
+func IterateTarget[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, visitFn func(term T, priority P) bool) Continuation {
+	return IterateTargetWindow(tx, indexInfo, target, Window{}, visitFn)
+}
+
+// IterateTargetWindow is IterateTarget with a window, for paginating over
+// targets that have many terms. The terms are ordered by their packed bytes.
+func IterateTargetWindow[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K, window Window, visitFn func(term T, priority P) bool) Continuation {
 	keyPrefix := _TargetKeyPrefix(indexInfo, &target)
 	bkt := TxRawBucket(tx, indexInfo.Name)
-	window := _RawIterationParams{
+	iterParams := _RawIterationParams{
 		Prefix: keyPrefix,
-		Window: Window{
-			Direction: IterateRegular,
-		},
+		Name:   indexInfo.Name,
+		Window: window,
 	}
-	_RawIterateCore(bkt, window, func(key []byte, v []byte) bool {
-		// we can safely assume the key starts with IndexTermPrefix because otherwise the RawIterateKeyPrefixValues func will not call us
-		target, term := _ReadTargetTerm(indexInfo, key)
+	return _RawIterateCore(bkt, iterParams, func(key []byte, v []byte) bool {
+		// we can safely assume the key starts with IndexTargetPrefix because otherwise _RawIterateCore will not call us
+		_, term := _ReadTargetTerm(indexInfo, key)
 		var priority P
 		vpack.FromBytesInto(v, &priority, indexInfo.PriorityPackFn)
-		_ = target
 		return visitFn(term, priority)
 	})
 }
@@ -290,18 +655,19 @@ func _ReadTermTargetPriority[K, T, P comparable](indexInfo *IndexInfo[K, T, P],
 	return
 }
 
-func IterateAllTerms[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], visitFn func(term T, target K, priority P) bool) {
+func IterateAllTerms[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], visitFn func(term T, target K, priority P) bool) Continuation {
 	var keyPrefix = []byte{IndexTermPrefix}
 	bkt := TxRawBucket(tx, indexInfo.Name)
 
 	window := _RawIterationParams{
 		Prefix: keyPrefix,
+		Name:   indexInfo.Name,
 		Window: Window{
 			Direction: IterateRegular,
 		},
 	}
 
-	_RawIterateCore(bkt, window, func(key []byte, v []byte) bool {
+	return _RawIterateCore(bkt, window, func(key []byte, v []byte) bool {
 		term, target, priority := _ReadTermTargetPriority(indexInfo, key)
 		return visitFn(term, target, priority)
 	})
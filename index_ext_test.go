@@ -0,0 +1,218 @@
+package vbolt_test
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"testing"
+
+	"go.hasen.dev/generic"
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestIndex(t *testing.T) {
+	var dbInfo vbolt.Info
+	info := vbolt.Index(&dbInfo, "idx1", vpack.StringZ, vpack.Int)
+
+	db := vbolttest.NewTestDB(t, &dbInfo)
+
+	type entry struct {
+		term     string
+		target   int
+		priority uint16
+	}
+
+	expectedEntries := []entry{
+		{"abc", 12, 2},
+		{"lol", 10, 4},
+		{"lol", 12, 5},
+		{"rofl", 10, 7},
+		{"klm", 12, 10},
+	}
+
+	expectedCounts := map[string]int{
+		"abc":  1,
+		"lol":  2,
+		"rofl": 1,
+		"klm":  1,
+	}
+
+	foundEntries := make(map[entry]bool)
+	foundCounts := make(map[string]int)
+
+	vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+		vbolt.SetTargetTerms(tx, info, 10, map[string]uint16{
+			"abc": 1,
+			"lol": 2,
+		})
+		vbolt.SetTargetTerms(tx, info, 12, map[string]uint16{
+			"abc": 2,
+			"klm": 10,
+			"lol": 5,
+		})
+	})
+
+	vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+		vbolt.SetTargetTerms(tx, info, 10, map[string]uint16{
+			"lol":  4,
+			"rofl": 7,
+		})
+	})
+
+	// verify results
+
+	vbolttest.View(db, func(tx *vbolt.Tx) {
+		vbolt.IterateAllTerms(tx, info, func(term string, target int, priority uint16) bool {
+			foundEntries[entry{term, target, priority}] = true
+			var count int
+			vbolt.ReadTermCount(tx, info, &term, &count)
+			foundCounts[term] = count
+			return true
+		})
+	})
+
+	for _, e := range expectedEntries {
+		if !foundEntries[e] {
+			t.Logf("Entry not found: %s %d %d", e.term, e.target, e.priority)
+			t.Fail()
+		}
+	}
+
+	for term, count := range expectedCounts {
+		if foundCounts[term] != count {
+			t.Logf("Entry Count Different. Expected: %d. Found: %d", count, foundCounts[term])
+			t.Fail()
+		}
+	}
+	for term, count := range foundCounts {
+		var _, ok = expectedCounts[term]
+		if !ok {
+			t.Logf("Unaccounted for term; no expected counts! Term: %s. Count: %d", term, count)
+			t.Fail()
+		}
+	}
+
+	for entry := range foundEntries {
+		if !generic.OneOf(entry, expectedEntries) {
+			t.Logf("Found a bogus entry: %s %d %d", entry.term, entry.target, entry.priority)
+			t.Fail()
+		}
+	}
+
+	if len(foundEntries) != len(expectedEntries) {
+		t.Logf("Found entries and expected entries don't match! %d != %d", len(foundEntries), len(expectedEntries))
+		t.Fail()
+	}
+}
+
+func TestIndexCrashes(t *testing.T) {
+	var dbInfo vbolt.Info
+	info := vbolt.Index(&dbInfo, "idx1", vpack.StringZ, vpack.Int)
+
+	terms := []string{"a", "b", "c", "d", "e"}
+	vbolttest.SimulateCrashes(t, &dbInfo, 20, 1, func(db *vbolt.DB, crash func(point string)) {
+		for target := 1; target <= 10; target++ {
+			vbolt.WithWriteTx(db, func(tx *vbolt.Tx) {
+				vbolt.SetTargetTermsPlain(tx, info, target, terms[target%3:target%3+3])
+				vbolt.TxCommit(tx)
+			})
+		}
+	})
+}
+
+func TestSetTargetTermsStream(t *testing.T) {
+	var dbInfo vbolt.Info
+	mapped := vbolt.Index(&dbInfo, "mapped", vpack.StringZ, vpack.Int)
+	streamed := vbolt.Index(&dbInfo, "streamed", vpack.StringZ, vpack.Int)
+
+	db := vbolttest.NewTestDB(t, &dbInfo)
+
+	// yields the terms in the order of their packed bytes
+	stream := func(terms map[string]uint16) func() (string, uint16, bool) {
+		var sorted []string
+		for term := range terms {
+			sorted = append(sorted, term)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			return bytes.Compare(vpack.ToBytes(&sorted[i], vpack.StringZ), vpack.ToBytes(&sorted[j], vpack.StringZ)) < 0
+		})
+		return func() (term string, priority uint16, ok bool) {
+			if len(sorted) == 0 {
+				return
+			}
+			term, sorted = sorted[0], sorted[1:]
+			return term, terms[term], true
+		}
+	}
+
+	type update struct {
+		target int
+		terms  map[string]uint16
+	}
+
+	// each update runs in its own transaction
+	updates := []update{
+		{10, map[string]uint16{"abc": 1, "lol": 2}},
+		{12, map[string]uint16{"abc": 2, "klm": 10, "lol": 5}},
+		{10, map[string]uint16{"lol": 4, "rofl": 7}},         // some removed, some added, one re-prioritized
+		{12, map[string]uint16{"abc": 2, "klm": 10, "z": 1}}, // mostly unchanged
+		{14, map[string]uint16{"a": 1, "ab": 2, "abcd": 3, "b": 4}},
+		{14, nil}, // all removed
+		{12, map[string]uint16{"abc": 3, "klm": 1, "z": 1}}, // priorities only
+	}
+
+	for _, u := range updates {
+		vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+			vbolt.SetTargetTerms(tx, mapped, u.target, u.terms)
+			if err := vbolt.SetTargetTermsStream(tx, streamed, u.target, stream(u.terms)); err != nil {
+				t.Fatalf("stream: %v", err)
+			}
+		})
+	}
+
+	type entry struct {
+		term     string
+		target   int
+		priority uint16
+		count    int
+	}
+	readEntries := func(tx *vbolt.Tx, info *vbolt.IndexInfo[int, string, uint16]) (entries []entry) {
+		vbolt.IterateAllTerms(tx, info, func(term string, target int, priority uint16) bool {
+			var count int
+			vbolt.ReadTermCount(tx, info, &term, &count)
+			entries = append(entries, entry{term, target, priority, count})
+			return true
+		})
+		return
+	}
+
+	vbolttest.View(db, func(tx *vbolt.Tx) {
+		expected := readEntries(tx, mapped)
+		found := readEntries(tx, streamed)
+		if len(expected) != len(found) {
+			t.Fatalf("SetTargetTerms made %d entries, the stream made %d", len(expected), len(found))
+		}
+		for i := range expected {
+			if expected[i] != found[i] {
+				t.Errorf("entry %d: expected %v, found %v", i, expected[i], found[i])
+			}
+		}
+	})
+
+	// out of order terms are refused
+	tx := vbolt.WriteTx(db)
+	defer vbolt.TxClose(tx)
+	terms := []string{"b", "a"}
+	err := vbolt.SetTargetTermsStream(tx, streamed, 20, func() (term string, priority uint16, ok bool) {
+		if len(terms) == 0 {
+			return
+		}
+		term, terms = terms[0], terms[1:]
+		return term, 1, true
+	})
+	if !errors.Is(err, vbolt.ErrTermsUnsorted) {
+		t.Errorf("expected ErrTermsUnsorted, got: %v", err)
+	}
+}
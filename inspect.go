@@ -97,3 +97,132 @@ func DEBUGInspect[K, V any](tx *Tx, bucket *BucketInfo[K, V]) {
 	fmt.Fprint(&b, "Total Count:", inspection.TotalItemsCount)
 	log.Println(b.String())
 }
+
+// GenericBucketInfo is a type-erased view of a *BucketInfo[K, V], for tools
+// (rpc servers, exporters, etc) that need to access data without knowing K and
+// V at compile time.
+type GenericBucketInfo struct {
+	Name        string
+	KeyType     reflect.Type
+	ValueType   reflect.Type
+	KeyPackFn   reflect.Value
+	ValuePackFn reflect.Value
+}
+
+// GenericIndexInfo is a type-erased view of an *IndexInfo[K, T, P]
+type GenericIndexInfo struct {
+	Name           string
+	TargetType     reflect.Type
+	TermType       reflect.Type
+	PriorityType   reflect.Type
+	TargetPackFn   reflect.Value
+	TermPackFn     reflect.Value
+	PriorityPackFn reflect.Value
+}
+
+func _PackFnElemType(fn reflect.Value) reflect.Type {
+	return fn.Type().In(0).Elem()
+}
+
+// AsGenericBucket returns ok=false if infoPtr is not a *BucketInfo
+func AsGenericBucket(infoPtr any) (g GenericBucketInfo, ok bool) {
+	v := reflect.ValueOf(infoPtr)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	v = v.Elem()
+	keyFn := v.FieldByName("KeyPackFn")
+	valueFn := v.FieldByName("ValuePackFn")
+	if !keyFn.IsValid() || !valueFn.IsValid() {
+		return
+	}
+	g.Name = v.FieldByName("Name").String()
+	g.KeyPackFn = keyFn
+	g.ValuePackFn = valueFn
+	g.KeyType = _PackFnElemType(keyFn)
+	g.ValueType = _PackFnElemType(valueFn)
+	return g, true
+}
+
+// AsGenericIndex returns ok=false if infoPtr is not an *IndexInfo
+func AsGenericIndex(infoPtr any) (g GenericIndexInfo, ok bool) {
+	v := reflect.ValueOf(infoPtr)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	v = v.Elem()
+	targetFn := v.FieldByName("TargetPackFn")
+	termFn := v.FieldByName("TermPackFn")
+	priorityFn := v.FieldByName("PriorityPackFn")
+	if !targetFn.IsValid() || !termFn.IsValid() || !priorityFn.IsValid() {
+		return
+	}
+	g.Name = v.FieldByName("Name").String()
+	g.TargetPackFn = targetFn
+	g.TermPackFn = termFn
+	g.PriorityPackFn = priorityFn
+	g.TargetType = _PackFnElemType(targetFn)
+	g.TermType = _PackFnElemType(termFn)
+	g.PriorityType = _PackFnElemType(priorityFn)
+	return g, true
+}
+
+// GenericPackKey serializes key, which must be a *K
+func GenericPackKey(info *GenericBucketInfo, key any) []byte {
+	return reflectPack(info.KeyPackFn, key)
+}
+
+// GenericUnpackKey returns a *K
+func GenericUnpackKey(info *GenericBucketInfo, data []byte) any {
+	return reflectUnpack(info.KeyPackFn, data)
+}
+
+// GenericUnpackValue returns a *V
+func GenericUnpackValue(info *GenericBucketInfo, data []byte) any {
+	return reflectUnpack(info.ValuePackFn, data)
+}
+
+// GenericReadKey reads the value for key (which must be a *K) and returns it as a *V
+func GenericReadKey(tx *Tx, info *GenericBucketInfo, key any) (value any, ok bool) {
+	bkt := TxRawBucket(tx, info.Name)
+	if bkt == nil {
+		return nil, false
+	}
+	data := bkt.Get(GenericPackKey(info, key))
+	if data == nil {
+		return nil, false
+	}
+	return GenericUnpackValue(info, data), true
+}
+
+func reflectUnpackFrom(serFn reflect.Value, buf *vpack.Buffer) any {
+	obj := reflect.New(_PackFnElemType(serFn))
+	serFn.Call([]reflect.Value{obj, reflect.ValueOf(buf)})
+	return obj.Interface()
+}
+
+// GenericIterateTerm is the type-erased version of IterateTerm with a window.
+// term must be a *T; the visitor receives a *K and a *P
+func GenericIterateTerm(tx *Tx, info *GenericIndexInfo, term any, window Window, visitFn func(target any, priority any) bool) []byte {
+	buf := vpack.NewWriter()
+	buf.WriteBytes(IndexTermPrefix)
+	info.TermPackFn.Call([]reflect.Value{reflect.ValueOf(term), reflect.ValueOf(buf)})
+
+	bkt := TxRawBucket(tx, info.Name)
+	if bkt == nil {
+		return nil
+	}
+
+	var iterParams = _RawIterationParams{
+		Prefix: buf.Data,
+		Window: window,
+	}
+	return _RawIterateCore(bkt, iterParams, func(key []byte, value []byte) bool {
+		reader := vpack.NewReader(key)
+		reader.Pos++ // skip the IndexTermPrefix byte
+		reflectUnpackFrom(info.TermPackFn, reader)
+		priority := reflectUnpackFrom(info.PriorityPackFn, reader)
+		target := reflectUnpackFrom(info.TargetPackFn, reader)
+		return visitFn(target, priority)
+	})
+}
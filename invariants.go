@@ -0,0 +1,272 @@
+package vbolt
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"go.hasen.dev/vpack"
+)
+
+type ViolationKind string
+
+const (
+	ViolationMissingReverse  ViolationKind = "missing reverse entry"
+	ViolationMissingForward  ViolationKind = "missing forward entry"
+	ViolationPriority        ViolationKind = "priority mismatch"
+	ViolationTermCount       ViolationKind = "term count mismatch"
+	ViolationSequenceTooLow  ViolationKind = "sequence below max key"
+	ViolationUnknownKeyShape ViolationKind = "unrecognized key"
+	ViolationUndecodable     ViolationKind = "undecodable"
+)
+
+type Violation struct {
+	Structure string // bucket, index, or collection name
+	Kind      ViolationKind
+	Detail    string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s", v.Structure, v.Kind, v.Detail)
+}
+
+// CheckInvariants validates the structural invariants of everything registered in dbInfo:
+//
+//   - index term->target entries and target->term entries mirror each other,
+//     with matching priorities
+//   - index term counts match the number of targets for each term
+//   - collection forward and reverse entries mirror each other
+//   - for buckets with integer keys that use NextIntId, the sequence is not
+//     below the largest key
+//
+// It's meant to be shared by tests (fuzzing, crash simulation) and production
+// health checks. It reads every entry, so it's not cheap on big databases.
+func CheckInvariants(tx *Tx, dbInfo *Info) (violations []Violation) {
+	for _, name := range dbInfo.BucketList {
+		if g, ok := AsGenericBucket(dbInfo.Infos[name]); ok {
+			_CheckBucketSequence(tx, &g, &violations)
+		}
+	}
+	for _, name := range dbInfo.IndexList {
+		if g, ok := AsGenericIndex(dbInfo.Infos[name]); ok {
+			_CheckIndex(tx, &g, &violations)
+		}
+	}
+	for _, name := range dbInfo.CollectionList {
+		_CheckCollection(tx, name, dbInfo.Infos[name], &violations)
+	}
+	return
+}
+
+// VerifyIndex checks a single index the way CheckInvariants does: that the
+// term->target and target->term entries mirror each other (reporting orphans
+// on either side, and mismatched priorities), and that the stored term
+// counts are right. Wrong counts can be fixed with RecountIndexTerms.
+func VerifyIndex[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P]) (violations []Violation) {
+	g, _ := AsGenericIndex(indexInfo)
+	_CheckIndex(tx, &g, &violations)
+	return
+}
+
+func _IsIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func _CheckBucketSequence(tx *Tx, g *GenericBucketInfo, violations *[]Violation) {
+	if !_IsIntKind(g.KeyType.Kind()) {
+		return
+	}
+	bkt := TxRawBucket(tx, g.Name)
+	if bkt == nil || bkt.Sequence() == 0 {
+		// sequence not in use
+		return
+	}
+	lastKey, _ := bkt.Cursor().Last()
+	if lastKey == nil {
+		return
+	}
+	key := reflect.ValueOf(GenericUnpackKey(g, lastKey)).Elem()
+	var maxKey uint64
+	if key.CanInt() {
+		if key.Int() < 0 {
+			return
+		}
+		maxKey = uint64(key.Int())
+	} else {
+		maxKey = key.Uint()
+	}
+	if bkt.Sequence() < maxKey {
+		*violations = append(*violations, Violation{
+			Structure: g.Name,
+			Kind:      ViolationSequenceTooLow,
+			Detail:    fmt.Sprintf("sequence %d < max key %d", bkt.Sequence(), maxKey),
+		})
+	}
+}
+
+// splits the key (after the prefix byte) into the raw segments consumed by each pack function
+func _SplitKey(key []byte, fns ...reflect.Value) (parts [][]byte, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	reader := vpack.NewReader(key)
+	reader.Pos = 1
+	for _, fn := range fns {
+		start := reader.Pos
+		reflectUnpackFrom(fn, reader)
+		if reader.Pos > len(key) || reader.Pos < start {
+			return nil, false
+		}
+		parts = append(parts, key[start:reader.Pos])
+	}
+	return parts, reader.Pos == len(key)
+}
+
+func _Concat(prefix byte, parts ...[]byte) []byte {
+	out := []byte{prefix}
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// reports keys in the reserved prefix range that don't belong to a known
+// structure, skipping over the known ranges without reading them
+func _CheckUnknownPrefixes(bkt *BBucket, known []byte, report func(kind ViolationKind, key []byte)) {
+	c := bkt.Cursor()
+	for key, _ := c.First(); key != nil && key[0] < ReservedPrefixLimit; {
+		if bytes.IndexByte(known, key[0]) >= 0 {
+			key, _ = c.Seek([]byte{key[0] + 1})
+			continue
+		}
+		report(ViolationUnknownKeyShape, key)
+		key, _ = c.Next()
+	}
+}
+
+func _CheckIndex(tx *Tx, g *GenericIndexInfo, violations *[]Violation) {
+	bkt := TxRawBucket(tx, g.Name)
+	if bkt == nil {
+		return
+	}
+	report := func(kind ViolationKind, key []byte) {
+		*violations = append(*violations, Violation{Structure: g.Name, Kind: kind, Detail: fmt.Sprintf("%x", key)})
+	}
+
+	_CheckUnknownPrefixes(bkt, []byte{IndexTermPrefix, IndexTargetPrefix, IndexCountPrefix}, report)
+
+	termCounts := make(map[string]int)
+
+	// term -> target entries
+	RawIterate(bkt, []byte{IndexTermPrefix}, Window{}, func(key []byte, value []byte) bool {
+		parts, ok := _SplitKey(key, g.TermPackFn, g.PriorityPackFn, g.TargetPackFn)
+		if !ok {
+			report(ViolationUnknownKeyShape, key)
+			return true
+		}
+		term, priority, target := parts[0], parts[1], parts[2]
+		termCounts[string(term)]++
+		reverse := bkt.Get(_Concat(IndexTargetPrefix, target, term))
+		if reverse == nil {
+			report(ViolationMissingReverse, key)
+		} else if !bytes.Equal(reverse, priority) {
+			report(ViolationPriority, key)
+		}
+		return true
+	})
+
+	// target -> term entries
+	RawIterate(bkt, []byte{IndexTargetPrefix}, Window{}, func(key []byte, value []byte) bool {
+		parts, ok := _SplitKey(key, g.TargetPackFn, g.TermPackFn)
+		if !ok {
+			report(ViolationUnknownKeyShape, key)
+			return true
+		}
+		target, term := parts[0], parts[1]
+		if !RawHasKey(bkt, _Concat(IndexTermPrefix, term, value, target)) {
+			report(ViolationMissingForward, key)
+		}
+		return true
+	})
+
+	// counts
+	RawIterate(bkt, []byte{IndexCountPrefix}, Window{}, func(key []byte, value []byte) bool {
+		term := string(key[1:])
+		var count int
+		vpack.FromBytesInto(value, &count, PackCountFn)
+		if count != termCounts[term] {
+			*violations = append(*violations, Violation{
+				Structure: g.Name,
+				Kind:      ViolationTermCount,
+				Detail:    fmt.Sprintf("%x: stored %d, actual %d", key, count, termCounts[term]),
+			})
+		}
+		delete(termCounts, term)
+		return true
+	})
+	for term, count := range termCounts {
+		*violations = append(*violations, Violation{
+			Structure: g.Name,
+			Kind:      ViolationTermCount,
+			Detail:    fmt.Sprintf("%x: no stored count, actual %d", _Concat(IndexCountPrefix, []byte(term)), count),
+		})
+	}
+}
+
+func _CheckCollection(tx *Tx, name string, infoPtr any, violations *[]Violation) {
+	if infoPtr == nil {
+		return
+	}
+	v := reflect.ValueOf(infoPtr).Elem()
+	keyFn := v.FieldByName("KeyFn")
+	orderFn := v.FieldByName("OrderFn")
+	itemFn := v.FieldByName("ItemFn")
+	if !keyFn.IsValid() || !orderFn.IsValid() || !itemFn.IsValid() {
+		return
+	}
+	bkt := TxRawBucket(tx, name)
+	if bkt == nil {
+		return
+	}
+	report := func(kind ViolationKind, key []byte) {
+		*violations = append(*violations, Violation{Structure: name, Kind: kind, Detail: fmt.Sprintf("%x", key)})
+	}
+
+	_CheckUnknownPrefixes(bkt, []byte{CKeyPrefix, CItemPrefix, CCountPrefix}, report)
+
+	RawIterate(bkt, []byte{CKeyPrefix}, Window{}, func(key []byte, value []byte) bool {
+		parts, ok := _SplitKey(key, keyFn, orderFn, itemFn)
+		if !ok {
+			report(ViolationUnknownKeyShape, key)
+			return true
+		}
+		cKey, order, item := parts[0], parts[1], parts[2]
+		reverse := bkt.Get(_Concat(CItemPrefix, item, cKey))
+		if reverse == nil {
+			report(ViolationMissingReverse, key)
+		} else if !bytes.Equal(reverse, order) {
+			report(ViolationPriority, key)
+		}
+		return true
+	})
+
+	RawIterate(bkt, []byte{CItemPrefix}, Window{}, func(key []byte, value []byte) bool {
+		parts, ok := _SplitKey(key, itemFn, keyFn)
+		if !ok {
+			report(ViolationUnknownKeyShape, key)
+			return true
+		}
+		item, cKey := parts[0], parts[1]
+		if !RawHasKey(bkt, _Concat(CKeyPrefix, cKey, value, item)) {
+			report(ViolationMissingForward, key)
+		}
+		return true
+	})
+}
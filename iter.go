@@ -0,0 +1,83 @@
+//go:build go1.23
+
+package vbolt
+
+import "iter"
+
+/*
+	Range-over-func variants of the Iterate functions:
+
+		for id, post := range vbolt.All(tx, Posts) {
+			...
+		}
+
+	The iteration runs on the caller's transaction, so the sequences must be
+	consumed before the transaction is closed.
+*/
+
+// All yields all the records of the bucket in key order
+func All[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T]) iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		IterateAll(tx, bucketInfo, yield)
+	}
+}
+
+// AllReverse yields all the records of the bucket in reverse key order
+func AllReverse[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T]) iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		IterateAllReverse(tx, bucketInfo, yield)
+	}
+}
+
+// Term yields the targets of term with their priorities
+func Term[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term T) iter.Seq2[K, P] {
+	return func(yield func(K, P) bool) {
+		IterateTerm(tx, indexInfo, term, yield)
+	}
+}
+
+// TermWindow is like Term, but only yields the targets in the window
+func TermWindow[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], term T, window Window) iter.Seq2[K, P] {
+	return func(yield func(K, P) bool) {
+		_IterateTermCore(tx, indexInfo, term, window, yield)
+	}
+}
+
+// Target yields the terms of target with their priorities
+func Target[K, T, P comparable](tx *Tx, indexInfo *IndexInfo[K, T, P], target K) iter.Seq2[T, P] {
+	return func(yield func(T, P) bool) {
+		IterateTarget(tx, indexInfo, target, yield)
+	}
+}
+
+// TermRecords yields the targets of term along with their records. Targets
+// without a record are skipped.
+func TermRecords[K, T, P comparable, V any](tx *Tx, indexInfo *IndexInfo[K, T, P], bucketInfo *BucketInfo[K, V], term T) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		bkt := TxRawBucket(tx, bucketInfo.Name)
+		_IterateTermCore(tx, indexInfo, term, Window{}, func(target K, priority P) bool {
+			var item V
+			if !_Read(bkt, bucketInfo, target, &item) {
+				return true
+			}
+			return yield(target, item)
+		})
+	}
+}
+
+// CollectionEntries yields the order and item of the entries under key
+func CollectionEntries[K, O, I any](tx *Tx, info *CollectionInfo[K, O, I], key K, direction IterationDirection) iter.Seq2[O, I] {
+	return func(yield func(O, I) bool) {
+		_IterateCollectionCore(tx, info, key, direction, func(_ K, order O, item I) bool {
+			return yield(order, item)
+		})
+	}
+}
+
+// Raw yields the raw keys and values of bkt that start with prefix, within
+// the window. The slices are only valid during the iteration.
+func Raw(bkt *BBucket, prefix []byte, window Window) iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		RawIterate(bkt, prefix, window, yield)
+	}
+}
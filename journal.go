@@ -0,0 +1,444 @@
+package vbolt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	Point-in-time recovery
+
+	With the journal enabled on a db, every commit adds an entry to the
+	JournalEntries bucket, in the same transaction, listing the raw changes it
+	made: the puts and deletes of records, index and collection entries and
+	metadata, bucket sequences, and dropped buckets. Backups record the
+	journal position they were taken at, so a base backup and the journal
+	entries after it rebuild the database as it was after any commit since:
+
+		vbolt.EnableJournal(db)
+		...
+		vbolt.BackupDB(db, &Info, baseFile)
+		...
+		// later, to just before the bad deploy
+		vbolt.ExportJournal(db, journalFile, 0)
+		vbolt.RestoreToPoint(target, baseFile, journalFile, vbolt.JournalPoint{Time: deployedAt})
+
+	Entries are numbered from 1 by the journal bucket's sequence, with no
+	gaps, since a rolled back tx rolls back the sequence too.
+
+	As with Watch, only the commits done with TxCommit/TxCommitE, WithBatchTx
+	and BulkLoad are journaled, and only the writes made through vbolt:
+	restores, fixtures and the Raw functions are, as long as the bucket was
+	opened with TxRawBucket, but writes through bolt's own api are not. The
+	journal grows with every commit; TrimJournal drops the entries a base
+	backup already covers.
+*/
+
+const (
+	JournalPut = iota + 1
+	JournalDelete
+	JournalDropBucket
+	JournalSequence
+)
+
+type JournalOp struct {
+	Kind   int
+	Bucket string
+	Key    []byte // JournalPut and JournalDelete
+	Value  []byte // JournalPut
+	Seq    uint64 // JournalSequence: the new sequence of the bucket
+}
+
+type JournalEntry struct {
+	Seq  uint64    // position in the journal
+	Time time.Time // when the tx committed, to the second
+	Ops  []JournalOp
+}
+
+func PackJournalOp(self *JournalOp, buf *vpack.Buffer) {
+	vpack.Version(1, buf)
+	vpack.Int(&self.Kind, buf)
+	vpack.String(&self.Bucket, buf)
+	vpack.Bytes(&self.Key, buf)
+	vpack.Bytes(&self.Value, buf)
+	vpack.FUInt64(&self.Seq, buf)
+}
+
+func PackJournalEntry(self *JournalEntry, buf *vpack.Buffer) {
+	vpack.Version(1, buf)
+	vpack.FUInt64(&self.Seq, buf)
+	vpack.UnixTime(&self.Time, buf)
+	vpack.Slice(&self.Ops, PackJournalOp, buf)
+}
+
+// system bucket: journal seq => entry. Written raw, so it's not journaled itself
+var JournalEntries = Bucket(&dbInfo, "journal", vpack.FUInt64, PackJournalEntry)
+
+var ErrJournalGap = errors.New("vbolt: journal entries are missing")
+var ErrBackupNoHeader = errors.New("vbolt: backup has no header")
+var ErrBackupTooNew = errors.New("vbolt: base backup is newer than the recovery point")
+
+var _journaled sync.Map // *DB => true
+
+// set by EnableJournal, so transactions only collect their changes when
+// someone cares
+var _journaling atomic.Bool
+
+var _txJournal sync.Map // *Tx => *_TxJournal, until it's committed or rolled back
+
+type _TxJournal struct {
+	Entry JournalEntry
+
+	// the names of the buckets opened through vbolt, so RawPut, which only
+	// gets the bucket, can journal its writes. bolt returns the same handle
+	// for a bucket every time within a write tx
+	Names map[*BBucket]string
+}
+
+// EnableJournal journals the commits on db from now on; see JournalEntries.
+// It's not persisted: call it every time the db is opened.
+func EnableJournal(db *DB) {
+	_journaling.Store(true)
+	_journaled.Store(db, true)
+}
+
+func DisableJournal(db *DB) {
+	_journaled.Delete(db)
+}
+
+// the pending journal entry of tx; nil if its db isn't journaled
+func _TxJournalOf(tx *Tx) *_TxJournal {
+	if !_journaling.Load() {
+		return nil
+	}
+	value, ok := _txJournal.Load(tx)
+	if !ok {
+		if !tx.Writable() {
+			return nil
+		}
+		if _, on := _journaled.Load(tx.DB()); !on {
+			return nil
+		}
+		value, _ = _txJournal.LoadOrStore(tx, &_TxJournal{Names: make(map[*BBucket]string)})
+	}
+	return value.(*_TxJournal)
+}
+
+// called when vbolt opens a bucket in tx
+func _JournalBucketName(tx *Tx, bkt *BBucket, name string) {
+	if journal := _TxJournalOf(tx); journal != nil && bkt != nil {
+		if _, ok := journal.Names[bkt]; !ok {
+			journal.Names[bkt] = strings.Clone(name) // may be an unsafe string
+		}
+	}
+}
+
+// the name bkt was opened with through vbolt, if its writes are journaled
+func _JournalName(bkt *BBucket) (string, bool) {
+	if journal := _TxJournalOf(bkt.Tx()); journal != nil {
+		name, ok := journal.Names[bkt]
+		return name, ok
+	}
+	return "", false
+}
+
+// records an op in the pending entry of tx. The key and value are kept as
+// is; bolt already requires them to be left alone until the tx is done
+func _JournalOp(tx *Tx, op JournalOp) {
+	if journal := _TxJournalOf(tx); journal != nil {
+		journal.Entry.Ops = append(journal.Entry.Ops, op)
+	}
+}
+
+// The raw writes of vbolt's own functions go through these, so they're journaled
+
+func _Put(tx *Tx, bkt *BBucket, name string, key []byte, value []byte) error {
+	if bkt == nil {
+		return ErrBucketMissing
+	}
+	if err := bkt.Put(key, value); err != nil {
+		return err
+	}
+	_JournalOp(tx, JournalOp{Kind: JournalPut, Bucket: name, Key: key, Value: value})
+	return nil
+}
+
+func _Del(tx *Tx, bkt *BBucket, name string, key []byte) error {
+	if bkt == nil {
+		return ErrBucketMissing
+	}
+	if err := bkt.Delete(key); err != nil {
+		return err
+	}
+	_JournalOp(tx, JournalOp{Kind: JournalDelete, Bucket: name, Key: key})
+	return nil
+}
+
+func _DropBucket(tx *Tx, name string) error {
+	if err := tx.DeleteBucket([]byte(name)); err != nil {
+		return err
+	}
+	_JournalOp(tx, JournalOp{Kind: JournalDropBucket, Bucket: name})
+	return nil
+}
+
+func _SetSequence(tx *Tx, bkt *BBucket, name string, seq uint64) error {
+	if err := bkt.SetSequence(seq); err != nil {
+		return err
+	}
+	_JournalOp(tx, JournalOp{Kind: JournalSequence, Bucket: name, Seq: seq})
+	return nil
+}
+
+// writes the pending entry of tx to the journal; called before committing.
+// A batch tx calls it after each function, rewriting the entry each time
+func _WriteJournal(tx *Tx) error {
+	value, ok := _txJournal.Load(tx)
+	if !ok || len(value.(*_TxJournal).Entry.Ops) == 0 {
+		return nil
+	}
+	entry := &value.(*_TxJournal).Entry
+	bkt, err := tx.CreateBucketIfNotExists([]byte(JournalEntries.Name))
+	if err != nil {
+		return err
+	}
+	if entry.Seq == 0 {
+		if entry.Seq, err = bkt.NextSequence(); err != nil {
+			return err
+		}
+	}
+	entry.Time = Now()
+	return bkt.Put(vpack.ToBytes(&entry.Seq, JournalEntries.KeyPackFn), vpack.ToBytes(entry, JournalEntries.ValuePackFn))
+}
+
+// the position of the journal as seen by tx; 0 if it has no entries
+func _JournalSeq(tx *Tx) uint64 {
+	if bkt := tx.Bucket([]byte(JournalEntries.Name)); bkt != nil {
+		return bkt.Sequence()
+	}
+	return 0
+}
+
+// ExportJournal writes the journal entries after seq to out, for
+// RestoreToPoint or ReadJournal. Returns the seq of the last entry written
+// (seq if there were none), to export from next time.
+func ExportJournal(db *DB, out io.Writer, seq uint64) (last uint64, err error) {
+	last = seq
+	w := bufio.NewWriter(out)
+	WithReadTx(db, func(tx *Tx) {
+		bkt := TxRawBucket(tx, JournalEntries.Name)
+		if bkt == nil {
+			return
+		}
+		from := seq + 1
+		RawIterate(bkt, nil, Window{Cursor: vpack.ToBytes(&from, JournalEntries.KeyPackFn)}, func(key []byte, value []byte) bool {
+			if _, err = w.Write(binary.AppendUvarint(nil, uint64(len(value)))); err == nil {
+				_, err = w.Write(value)
+			}
+			vpack.FromBytesInto(key, &last, JournalEntries.KeyPackFn)
+			return err == nil
+		})
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	return
+}
+
+// ReadJournal calls visit for every entry in a stream written by
+// ExportJournal, in order. Returns the first error from visit, or an error
+// wrapping ErrBackupCorrupt if the stream is truncated or doesn't decode.
+func ReadJournal(in io.Reader, visit func(entry *JournalEntry) error) error {
+	r := bufio.NewReader(in)
+	for {
+		size, err := binary.ReadUvarint(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: journal: %v", ErrBackupCorrupt, err)
+		}
+		// read through a limit reader, so a corrupt size doesn't allocate a
+		// huge buffer
+		data, err := io.ReadAll(io.LimitReader(r, int64(size)))
+		if err == nil && uint64(len(data)) != size {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return fmt.Errorf("%w: journal: %v", ErrBackupCorrupt, err)
+		}
+		var entry JournalEntry
+		if err = _DecodeInto(data, &entry, JournalEntries.ValuePackFn); err != nil {
+			return fmt.Errorf("%w: journal: %v", ErrBackupCorrupt, err)
+		}
+		if err = visit(&entry); err != nil {
+			return err
+		}
+	}
+}
+
+// TrimJournal deletes the journal entries up to and including seq, e.g.
+// the position of the oldest base backup that's kept (BackupHeader.JournalSeq)
+func TrimJournal(db *DB, seq uint64) error {
+	const batchSize = 1000
+	for done := false; !done; {
+		tx, err := WriteTxE(db)
+		if err != nil {
+			return err
+		}
+		bkt := TxRawBucket(tx, JournalEntries.Name)
+		var keys [][]byte
+		RawIterate(bkt, nil, Window{Limit: batchSize}, func(key []byte, value []byte) bool {
+			var entrySeq uint64
+			vpack.FromBytesInto(key, &entrySeq, JournalEntries.KeyPackFn)
+			if entrySeq > seq {
+				return false
+			}
+			keys = append(keys, key)
+			return true
+		})
+		for _, key := range keys {
+			if err = bkt.Delete(key); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = TxCommitE(tx)
+		}
+		TxClose(tx)
+		if err != nil {
+			return err
+		}
+		done = len(keys) < batchSize
+	}
+	return nil
+}
+
+// JournalPoint is where RestoreToPoint stops: after the entry Seq, or after
+// the last entry at or before Time, whichever comes first of the ones set
+type JournalPoint struct {
+	Seq  uint64
+	Time time.Time
+}
+
+func (point JournalPoint) _Includes(entry *JournalEntry) bool {
+	if point.Seq != 0 && entry.Seq > point.Seq {
+		return false
+	}
+	if !point.Time.IsZero() && entry.Time.After(point.Time) {
+		return false
+	}
+	return true
+}
+
+// RestoreToPoint restores base (a full backup with a header) into db, which
+// should be empty, then replays the journal entries read from changes (as
+// written by ExportJournal) that came after base, up to point. Returns the
+// seq of the last entry replayed, or the position of base if there were none.
+//
+// The base and the point are checked before anything is written, and base
+// is restored as with RestoreBuckets. The entries must follow base without
+// gaps (ErrJournalGap). They're replayed as they're read, committing every
+// few thousand changes, so a stream that turns out to be corrupt leaves the
+// ones before the damage.
+func RestoreToPoint(db *DB, base io.Reader, changes io.Reader, point JournalPoint) (restoredTo uint64, err error) {
+	// keep what reading the header consumed, to restore it along with the rest
+	var head bytes.Buffer
+	header, err := ReadBackupHeader(io.TeeReader(base, &head))
+	if err == nil && header == nil {
+		err = ErrBackupNoHeader
+	}
+	if err != nil {
+		return
+	}
+	if header.Delta {
+		return 0, ErrBackupNotFull
+	}
+	if (point.Seq != 0 && point.Seq < header.JournalSeq) || (!point.Time.IsZero() && header.Created.After(point.Time)) {
+		return 0, ErrBackupTooNew
+	}
+	if err = RestoreBuckets(db, io.MultiReader(&head, base)); err != nil {
+		return
+	}
+	restoredTo = header.JournalSeq
+
+	tx, err := WriteTxE(db)
+	if err != nil {
+		return
+	}
+	defer func() { // the tx gets replaced after every batch
+		TxClose(tx)
+	}()
+	var writesCount int
+	const txThreshold = 1024 * 4
+
+	errStop := errors.New("stop")
+	err = ReadJournal(changes, func(entry *JournalEntry) (err error) {
+		if entry.Seq <= restoredTo {
+			return nil // covered by the base
+		}
+		if entry.Seq != restoredTo+1 {
+			return fmt.Errorf("%w: expected %d, found %d", ErrJournalGap, restoredTo+1, entry.Seq)
+		}
+		if !point._Includes(entry) {
+			return errStop
+		}
+		for i := range entry.Ops {
+			if err = _ReplayJournalOp(tx, &entry.Ops[i]); err != nil {
+				return err
+			}
+		}
+		writesCount += len(entry.Ops)
+		if writesCount > txThreshold {
+			if err = TxCommitE(tx); err != nil {
+				return err
+			}
+			if tx, err = WriteTxE(db); err != nil {
+				return err
+			}
+			writesCount = 0
+		}
+		restoredTo = entry.Seq
+		return nil
+	})
+	if err == errStop {
+		err = nil
+	}
+	if err == nil {
+		err = TxCommitE(tx)
+	}
+	return
+}
+
+func _ReplayJournalOp(tx *Tx, op *JournalOp) error {
+	_MarkDirty(tx, op.Bucket)
+	if op.Kind == JournalDropBucket {
+		if tx.Bucket([]byte(op.Bucket)) == nil {
+			return nil
+		}
+		return _DropBucket(tx, op.Bucket)
+	}
+	bkt, err := _TxWriteBucketE(tx, op.Bucket)
+	if err != nil {
+		return err
+	}
+	switch op.Kind {
+	case JournalPut:
+		return _Put(tx, bkt, op.Bucket, op.Key, op.Value)
+	case JournalDelete:
+		return _Del(tx, bkt, op.Bucket, op.Key)
+	case JournalSequence:
+		return _SetSequence(tx, bkt, op.Bucket, op.Seq)
+	}
+	return fmt.Errorf("%w: journal op %d", ErrBackupFormat, op.Kind)
+}
@@ -0,0 +1,184 @@
+package vbolt_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestRestoreToPoint(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+	tags := vbolt.Index(&dbInfo, "post_tags", vpack.String, vpack.FInt)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := vbolttest.UseFakeClock(t, start)
+	db := vbolttest.NewTestDB(t, &dbInfo)
+	vbolt.EnableJournal(db)
+
+	write := func(tx *vbolt.Tx, id int, content string) {
+		vbolt.Write(tx, posts, id, &content)
+		vbolt.SetTargetTermsPlain(tx, tags, id, []string{content})
+	}
+
+	// entries 1 and 2
+	vbolttest.Commit(t, db, func(tx *vbolt.Tx) { write(tx, vbolt.NextIntId(tx, posts), "first") })
+	vbolttest.Commit(t, db, func(tx *vbolt.Tx) { write(tx, vbolt.NextIntId(tx, posts), "second") })
+	var base bytes.Buffer
+	if err := vbolt.BackupDB(db, &dbInfo, &base); err != nil {
+		t.Fatalf("base backup: %v", err)
+	}
+
+	// entry 3, an hour later
+	clock.Advance(time.Hour)
+	vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+		write(tx, 1, "edited")
+		vbolt.Delete(tx, posts, 2)
+		vbolt.SetTargetTermsPlain(tx, tags, 2, nil)
+		write(tx, vbolt.NextIntId(tx, posts), "third")
+		vbolt.RawMustPut(vbolt.TxRawBucket(tx, "notes"), []byte("note"), []byte("raw"))
+	})
+	// entry 4, the bad deploy
+	clock.Advance(time.Hour)
+	vbolttest.Commit(t, db, func(tx *vbolt.Tx) { write(tx, 1, "bad") })
+	// entry 5
+	clock.Advance(time.Hour)
+	if err := vbolt.WithBatchTx(db, func(tx *vbolt.Tx) error {
+		write(tx, 3, "batched")
+		return nil
+	}); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	// rolled back: not journaled
+	vbolt.WithWriteTx(db, func(tx *vbolt.Tx) { write(tx, 9, "rolled back") })
+
+	export := func(seq uint64) []byte {
+		t.Helper()
+		var changes bytes.Buffer
+		if _, err := vbolt.ExportJournal(db, &changes, seq); err != nil {
+			t.Fatalf("exporting the journal: %v", err)
+		}
+		return changes.Bytes()
+	}
+	changes := export(0)
+
+	type state struct {
+		posts  map[int]string
+		tagged map[string]int // the target of each term
+		nextId int
+		note   string
+	}
+	read := func(db *vbolt.DB) (s state) {
+		s.posts = make(map[int]string)
+		s.tagged = make(map[string]int)
+		vbolttest.View(db, func(tx *vbolt.Tx) {
+			vbolt.IterateAll(tx, posts, func(id int, content string) bool {
+				s.posts[id] = content
+				var targets []int
+				vbolt.ReadTermTargets(tx, tags, content, &targets, vbolt.Window{})
+				for _, target := range targets {
+					s.tagged[content] = target
+				}
+				return true
+			})
+			if bkt := vbolt.TxRawBucket(tx, posts.Name); bkt != nil {
+				s.nextId = int(bkt.Sequence()) + 1
+			}
+			if bkt := vbolt.TxRawBucket(tx, "notes"); bkt != nil {
+				s.note = string(bkt.Get([]byte("note")))
+			}
+		})
+		return
+	}
+	expectState := func(t *testing.T, db *vbolt.DB, expected state) {
+		t.Helper()
+		s := read(db)
+		if len(s.posts) != len(expected.posts) {
+			t.Errorf("expected posts %v, got %v", expected.posts, s.posts)
+		}
+		for id, content := range expected.posts {
+			if s.posts[id] != content {
+				t.Errorf("expected posts %v, got %v", expected.posts, s.posts)
+				break
+			}
+			if s.tagged[content] != id {
+				t.Errorf("post %d: expected it tagged %q, got %v", id, content, s.tagged)
+			}
+		}
+		if s.nextId != expected.nextId {
+			t.Errorf("expected the next id to be %d, got %d", expected.nextId, s.nextId)
+		}
+		if s.note != expected.note {
+			t.Errorf("expected the note %q, got %q", expected.note, s.note)
+		}
+	}
+	expectState(t, db, state{posts: map[int]string{1: "bad", 3: "batched"}, nextId: 4, note: "raw"})
+
+	cases := []struct {
+		name       string
+		point      vbolt.JournalPoint
+		restoredTo uint64
+		expected   state
+		err        error
+	}{
+		{"before the base", vbolt.JournalPoint{Seq: 1}, 0, state{}, vbolt.ErrBackupTooNew},
+		// backups don't carry bucket sequences, but the journal does
+		{"base only", vbolt.JournalPoint{Seq: 2}, 2, state{posts: map[int]string{1: "first", 2: "second"}, nextId: 1}, nil},
+		{"before the bad deploy", vbolt.JournalPoint{Time: start.Add(90 * time.Minute)}, 3, state{posts: map[int]string{1: "edited", 3: "third"}, nextId: 4, note: "raw"}, nil},
+		{"bad deploy", vbolt.JournalPoint{Seq: 4}, 4, state{posts: map[int]string{1: "bad", 3: "third"}, nextId: 4, note: "raw"}, nil},
+		{"everything", vbolt.JournalPoint{}, 5, state{posts: map[int]string{1: "bad", 3: "batched"}, nextId: 4, note: "raw"}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			target := vbolttest.NewTestDB(t, &dbInfo)
+			restoredTo, err := vbolt.RestoreToPoint(target, bytes.NewReader(base.Bytes()), bytes.NewReader(changes), c.point)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got: %v", c.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if restoredTo != c.restoredTo {
+				t.Errorf("expected to restore to %d, got %d", c.restoredTo, restoredTo)
+			}
+			expectState(t, target, c.expected)
+		})
+	}
+
+	t.Run("gap", func(t *testing.T) {
+		target := vbolttest.NewTestDB(t, &dbInfo)
+		_, err := vbolt.RestoreToPoint(target, bytes.NewReader(base.Bytes()), bytes.NewReader(export(3)), vbolt.JournalPoint{})
+		if !errors.Is(err, vbolt.ErrJournalGap) {
+			t.Errorf("expected ErrJournalGap, got: %v", err)
+		}
+	})
+
+	t.Run("trim", func(t *testing.T) {
+		if err := vbolt.TrimJournal(db, 2); err != nil {
+			t.Fatalf("trimming the journal: %v", err)
+		}
+		var seqs []uint64
+		err := vbolt.ReadJournal(bytes.NewReader(export(0)), func(entry *vbolt.JournalEntry) error {
+			seqs = append(seqs, entry.Seq)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("reading the journal: %v", err)
+		}
+		if len(seqs) != 3 || seqs[0] != 3 || seqs[2] != 5 {
+			t.Errorf("expected entries 3 to 5, got %v", seqs)
+		}
+
+		// the base still restores to the end
+		target := vbolttest.NewTestDB(t, &dbInfo)
+		restoredTo, err := vbolt.RestoreToPoint(target, bytes.NewReader(base.Bytes()), bytes.NewReader(export(0)), vbolt.JournalPoint{})
+		if err != nil || restoredTo != 5 {
+			t.Errorf("expected to restore to 5, got %d, %v", restoredTo, err)
+		}
+	})
+}
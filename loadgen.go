@@ -0,0 +1,213 @@
+package vbolt
+
+import (
+	"math/rand"
+	"reflect"
+	"time"
+
+	"go.hasen.dev/generic"
+	"go.hasen.dev/vpack"
+)
+
+type LoadIndexSpec struct {
+	Targets        int // number of distinct targets to assign terms to
+	TermsPerTarget int // default 3
+	DistinctTerms  int // size of the pool terms are picked from; default 100
+}
+
+type LoadSpec struct {
+	Seed      int64
+	BatchSize int // items per transaction; default 10000
+
+	Buckets map[string]int           // bucket name => number of records
+	Indexes map[string]LoadIndexSpec // index name => spec
+
+	StringLen int // max length of generated strings; default 24
+	SliceLen  int // max length of generated slices and maps; default 5
+}
+
+type _LoadGen struct {
+	Rand *rand.Rand
+	Spec *LoadSpec
+}
+
+// GenerateLoad fills the buckets and indexes named in spec with pseudo random
+// data, for benchmarking queries, backups, etc at a realistic scale.
+//
+// Values are generated by reflection from the registered types. Integer keys
+// and targets are assigned sequentially from 1, so that indexes generated
+// with the same number of targets as a bucket has records point to existing
+// records. The same seed always produces the same data.
+func GenerateLoad(db *DB, dbInfo *Info, spec LoadSpec) {
+	if spec.BatchSize <= 0 {
+		spec.BatchSize = 10000
+	}
+	if spec.StringLen <= 0 {
+		spec.StringLen = 24
+	}
+	if spec.SliceLen <= 0 {
+		spec.SliceLen = 5
+	}
+	gen := _LoadGen{Rand: rand.New(rand.NewSource(spec.Seed)), Spec: &spec}
+
+	for _, name := range dbInfo.BucketList {
+		count := spec.Buckets[name]
+		g, ok := AsGenericBucket(dbInfo.Infos[name])
+		if !ok || count <= 0 {
+			continue
+		}
+		_LoadBatches(db, count, spec.BatchSize, func(tx *Tx, i int) {
+			key := _LoadKey(&gen, g.KeyType, i)
+			value := reflect.New(g.ValueType)
+			_LoadFill(&gen, value.Elem(), 0)
+			RawMustPut(TxRawBucket(tx, name), reflectPack(g.KeyPackFn, key.Interface()), reflectPack(g.ValuePackFn, value.Interface()))
+		})
+		WithWriteTx(db, func(tx *Tx) {
+			if _IsIntKind(g.KeyType.Kind()) {
+				generic.MustOK(_SetSequence(tx, TxRawBucket(tx, name), name, uint64(count)))
+			}
+			TxCommit(tx)
+		})
+	}
+
+	for _, name := range dbInfo.IndexList {
+		idxSpec, ok := spec.Indexes[name]
+		g, isIndex := AsGenericIndex(dbInfo.Infos[name])
+		if !ok || !isIndex || idxSpec.Targets <= 0 {
+			continue
+		}
+		if idxSpec.TermsPerTarget <= 0 {
+			idxSpec.TermsPerTarget = 3
+		}
+		if idxSpec.DistinctTerms <= 0 {
+			idxSpec.DistinctTerms = 100
+		}
+
+		terms := make([][]byte, idxSpec.DistinctTerms)
+		for i := range terms {
+			terms[i] = reflectPack(g.TermPackFn, _LoadKey(&gen, g.TermType, i).Interface())
+		}
+		counts := make([]int, len(terms))
+
+		_LoadBatches(db, idxSpec.Targets, spec.BatchSize, func(tx *Tx, i int) {
+			bkt := TxRawBucket(tx, name)
+			target := reflectPack(g.TargetPackFn, _LoadKey(&gen, g.TargetType, i).Interface())
+			for _, t := range gen.Rand.Perm(len(terms))[:_Min(idxSpec.TermsPerTarget, len(terms))] {
+				priority := reflect.New(g.PriorityType)
+				_LoadFill(&gen, priority.Elem(), 0)
+				priorityBytes := reflectPack(g.PriorityPackFn, priority.Interface())
+				RawMustPut(bkt, _Concat(IndexTermPrefix, terms[t], priorityBytes, target), nil)
+				RawMustPut(bkt, _Concat(IndexTargetPrefix, target, terms[t]), priorityBytes)
+				counts[t]++
+			}
+		})
+
+		WithWriteTx(db, func(tx *Tx) {
+			bkt := TxRawBucket(tx, name)
+			for t, count := range counts {
+				RawMustPut(bkt, _Concat(IndexCountPrefix, terms[t]), vpack.ToBytes(&count, PackCountFn))
+			}
+			TxCommit(tx)
+		})
+	}
+}
+
+func _Min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func _LoadBatches(db *DB, count int, batchSize int, fn func(tx *Tx, i int)) {
+	for start := 0; start < count; start += batchSize {
+		WithWriteTx(db, func(tx *Tx) {
+			for i := start; i < count && i < start+batchSize; i++ {
+				fn(tx, i)
+			}
+			TxCommit(tx)
+		})
+	}
+}
+
+// the i-th key: sequential for integers (starting from 1), random otherwise
+func _LoadKey(gen *_LoadGen, t reflect.Type, i int) reflect.Value {
+	key := reflect.New(t)
+	switch {
+	case _IsIntKind(t.Kind()) && key.Elem().CanInt():
+		key.Elem().SetInt(int64(i + 1))
+	case _IsIntKind(t.Kind()):
+		key.Elem().SetUint(uint64(i + 1))
+	default:
+		_LoadFill(gen, key.Elem(), 0)
+	}
+	return key
+}
+
+const _letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 "
+
+// fills v with random data, recursively
+func _LoadFill(gen *_LoadGen, v reflect.Value, depth int) {
+	r := gen.Rand
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		v.Set(reflect.ValueOf(time.Unix(1_500_000_000+r.Int63n(300_000_000), 0)))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(r.Int63() >> (64 - v.Type().Bits()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(r.Int63() >> (64 - v.Type().Bits())))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(r.Float64() * 1000)
+	case reflect.String:
+		b := make([]byte, 1+r.Intn(gen.Spec.StringLen))
+		for i := range b {
+			b[i] = _letters[r.Intn(len(_letters))]
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		if depth > 3 {
+			return
+		}
+		n := r.Intn(gen.Spec.SliceLen + 1)
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			_LoadFill(gen, s.Index(i), depth+1)
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			_LoadFill(gen, v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		if depth > 3 {
+			return
+		}
+		m := reflect.MakeMap(v.Type())
+		n := r.Intn(gen.Spec.SliceLen + 1)
+		for i := 0; i < n; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			value := reflect.New(v.Type().Elem()).Elem()
+			_LoadFill(gen, key, depth+1)
+			_LoadFill(gen, value, depth+1)
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+	case reflect.Pointer:
+		if depth > 3 {
+			return
+		}
+		p := reflect.New(v.Type().Elem())
+		_LoadFill(gen, p.Elem(), depth+1)
+		v.Set(p)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				_LoadFill(gen, v.Field(i), depth+1)
+			}
+		}
+	}
+}
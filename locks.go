@@ -0,0 +1,74 @@
+package vbolt
+
+import (
+	"time"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	Advisory record locks, for workers that need exclusive processing of a
+	record:
+
+		var JobLocks = vbolt.LockBucket(&Info, "job_locks", vpack.FInt)
+
+		vbolt.WithWriteTx(db, func(tx *vbolt.Tx) {
+			ok = vbolt.TryLock(tx, JobLocks, jobId, workerName, time.Minute)
+			vbolt.TxCommit(tx)
+		})
+
+	Locks are just records, so they only take effect once the tx commits, and
+	nothing stops code that doesn't check them. A lock expires after its ttl
+	(with second precision) so a crashed worker doesn't hold it forever;
+	workers that take longer should lock again to extend it.
+*/
+
+type RecordLock struct {
+	Owner   string
+	Expires time.Time
+}
+
+func PackRecordLock(self *RecordLock, buf *vpack.Buffer) {
+	vpack.Version(1, buf)
+	vpack.String(&self.Owner, buf)
+	vpack.UnixTime(&self.Expires, buf)
+}
+
+// LockBucket registers a bucket to hold locks on keys of type K
+func LockBucket[K comparable](dbInfo *Info, name string, keyFn vpack.PackFn[K]) *BucketInfo[K, RecordLock] {
+	return Bucket(dbInfo, name, keyFn, PackRecordLock)
+}
+
+// TryLock takes the lock on key for owner, unless someone else holds it and
+// it hasn't expired. If owner already holds it, the expiry is extended.
+// Returns whether owner holds the lock.
+func TryLock[K comparable](tx *Tx, lockBucket *BucketInfo[K, RecordLock], key K, owner string, ttl time.Duration) bool {
+	now := Now()
+	lock, ok := Get(tx, lockBucket, key)
+	if ok && lock.Owner != owner && now.Before(lock.Expires) {
+		return false
+	}
+	lock = RecordLock{Owner: owner, Expires: now.Add(ttl)}
+	Write(tx, lockBucket, key, &lock)
+	return true
+}
+
+// Unlock releases the lock on key if owner holds it (even if expired).
+// Returns false if the lock is held by someone else or not at all.
+func Unlock[K comparable](tx *Tx, lockBucket *BucketInfo[K, RecordLock], key K, owner string) bool {
+	lock, ok := Get(tx, lockBucket, key)
+	if !ok || lock.Owner != owner {
+		return false
+	}
+	Delete(tx, lockBucket, key)
+	return true
+}
+
+// LockHolder returns the current holder of the lock on key, if any
+func LockHolder[K comparable](tx *Tx, lockBucket *BucketInfo[K, RecordLock], key K) (owner string, ok bool) {
+	lock, ok := Get(tx, lockBucket, key)
+	if !ok || !Now().Before(lock.Expires) {
+		return "", false
+	}
+	return lock.Owner, true
+}
@@ -0,0 +1,568 @@
+// Package memstore is an in-memory key-value store that mimics the subset of
+// the bolt API used by vbolt (DB, Tx, Bucket, Cursor).
+//
+// It's used as the vbolt backend on platforms where bolt does not build
+// (GOOS=js), and for tests that don't need a file on disk.
+//
+// Semantics follow bolt: one writer at a time, any number of readers, and
+// readers see a consistent snapshot taken when their transaction started.
+// Nothing is persisted; the path passed to Open is only remembered.
+package memstore
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrDatabaseNotOpen     = errors.New("database not open")
+	ErrTxNotWritable       = errors.New("tx not writable")
+	ErrTxClosed            = errors.New("tx closed")
+	ErrBucketNotFound      = errors.New("bucket not found")
+	ErrBucketExists        = errors.New("bucket already exists")
+	ErrBucketNameRequired  = errors.New("bucket name required")
+	ErrKeyRequired         = errors.New("key required")
+	ErrIncompatibleValue   = errors.New("incompatible value")
+	ErrDatabaseReadOnly    = errors.New("database is in read-only mode")
+	ErrSequenceOverflowing = errors.New("sequence overflow")
+	ErrTimeout             = errors.New("timeout") // never returned; there is no file lock
+)
+
+// Options is accepted for compatibility with bolt.Options; only ReadOnly is used
+type Options struct {
+	Timeout         time.Duration
+	NoGrowSync      bool
+	ReadOnly        bool
+	MmapFlags       int
+	InitialMmapSize int
+}
+
+type Stats struct {
+	FreePageN     int
+	PendingPageN  int
+	FreeAlloc     int
+	FreelistInuse int
+	TxN           int
+	OpenTxN       int
+}
+
+type BucketStats struct {
+	BranchPageN       int
+	BranchOverflowN   int
+	LeafPageN         int
+	LeafOverflowN     int
+	KeyN              int
+	Depth             int
+	BranchAlloc       int
+	BranchInuse       int
+	LeafAlloc         int
+	LeafInuse         int
+	BucketN           int
+	InlineBucketN     int
+	InlineBucketInuse int
+}
+
+type bucketData struct {
+	keys     [][]byte
+	values   [][]byte
+	sequence uint64
+}
+
+func (d *bucketData) clone() *bucketData {
+	return &bucketData{
+		keys:     append([][]byte(nil), d.keys...),
+		values:   append([][]byte(nil), d.values...),
+		sequence: d.sequence,
+	}
+}
+
+// position of the first key >= key
+func (d *bucketData) search(key []byte) int {
+	return sort.Search(len(d.keys), func(i int) bool {
+		return bytes.Compare(d.keys[i], key) >= 0
+	})
+}
+
+type DB struct {
+	// accepted for compatibility with bolt.DB; nothing is written to disk
+	NoSync        bool
+	NoGrowSync    bool
+	MaxBatchSize  int
+	MaxBatchDelay time.Duration
+	AllocSize     int
+
+	path     string
+	readOnly bool
+
+	writer sync.Mutex // held by the open write transaction
+
+	mu      sync.RWMutex // protects the fields below
+	buckets map[string]*bucketData
+	opened  bool
+	stats   Stats
+}
+
+func Open(path string, mode os.FileMode, options *Options) (*DB, error) {
+	db := &DB{path: path, opened: true}
+	db.buckets = make(map[string]*bucketData)
+	if options != nil {
+		db.readOnly = options.ReadOnly
+	}
+	return db, nil
+}
+
+func (db *DB) Path() string {
+	return db.path
+}
+
+func (db *DB) String() string {
+	return `DB<"` + db.path + `">`
+}
+
+func (db *DB) IsReadOnly() bool {
+	return db.readOnly
+}
+
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.opened = false
+	return nil
+}
+
+// Sync exists for compatibility with bolt; there is nothing to sync
+func (db *DB) Sync() error {
+	return nil
+}
+
+func (db *DB) Stats() Stats {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.stats
+}
+
+func (db *DB) Begin(writable bool) (*Tx, error) {
+	if writable {
+		if db.readOnly {
+			return nil, ErrDatabaseReadOnly
+		}
+		db.writer.Lock()
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if !db.opened {
+		if writable {
+			db.writer.Unlock()
+		}
+		return nil, ErrDatabaseNotOpen
+	}
+
+	tx := &Tx{db: db, writable: writable}
+	// the map is never mutated after being installed, so sharing it is a snapshot
+	tx.buckets = db.buckets
+	db.stats.OpenTxN++
+	return tx, nil
+}
+
+func (db *DB) Update(fn func(*Tx) error) error {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Batch exists for compatibility with bolt; there are no fsyncs to save, so
+// it's just Update
+func (db *DB) Batch(fn func(*Tx) error) error {
+	return db.Update(fn)
+}
+
+func (db *DB) View(fn func(*Tx) error) error {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return fn(tx)
+}
+
+type Tx struct {
+	db       *DB
+	writable bool
+	closed   bool
+
+	buckets map[string]*bucketData
+	copied  bool            // whether tx.buckets is our own copy of the map
+	owned   map[string]bool // buckets whose data was copied by this tx
+	handles map[string]*Bucket
+}
+
+func (tx *Tx) DB() *DB {
+	return tx.db
+}
+
+func (tx *Tx) Writable() bool {
+	return tx.writable
+}
+
+func (tx *Tx) Size() int64 {
+	var size int64
+	for _, data := range tx.buckets {
+		for i := range data.keys {
+			size += int64(len(data.keys[i]) + len(data.values[i]))
+		}
+	}
+	return size
+}
+
+// Check exists for compatibility with bolt; there are no pages to verify, so
+// the returned channel is closed right away.
+func (tx *Tx) Check() <-chan error {
+	ch := make(chan error)
+	close(ch)
+	return ch
+}
+
+func (tx *Tx) close() {
+	if tx.closed {
+		return
+	}
+	tx.closed = true
+	tx.db.mu.Lock()
+	tx.db.stats.OpenTxN--
+	tx.db.stats.TxN++
+	tx.db.mu.Unlock()
+	if tx.writable {
+		tx.db.writer.Unlock()
+	}
+}
+
+func (tx *Tx) Rollback() error {
+	if tx.closed {
+		return ErrTxClosed
+	}
+	tx.close()
+	return nil
+}
+
+func (tx *Tx) Commit() error {
+	if tx.closed {
+		return ErrTxClosed
+	}
+	if !tx.writable {
+		return ErrTxNotWritable
+	}
+	tx.db.mu.Lock()
+	tx.db.buckets = tx.buckets
+	tx.db.mu.Unlock()
+	tx.close()
+	return nil
+}
+
+// make sure tx.buckets is private to this tx before mutating it
+func (tx *Tx) copyMap() {
+	if tx.copied {
+		return
+	}
+	buckets := make(map[string]*bucketData, len(tx.buckets)+1)
+	for name, data := range tx.buckets {
+		buckets[name] = data
+	}
+	tx.buckets = buckets
+	tx.copied = true
+	tx.owned = make(map[string]bool)
+}
+
+func (tx *Tx) bucketHandle(name string) *Bucket {
+	if tx.handles == nil {
+		tx.handles = make(map[string]*Bucket)
+	}
+	b := tx.handles[name]
+	if b == nil {
+		b = &Bucket{tx: tx, name: name}
+		tx.handles[name] = b
+	}
+	return b
+}
+
+func (tx *Tx) Bucket(name []byte) *Bucket {
+	if _, ok := tx.buckets[string(name)]; !ok {
+		return nil
+	}
+	return tx.bucketHandle(string(name))
+}
+
+func (tx *Tx) CreateBucket(name []byte) (*Bucket, error) {
+	if tx.closed {
+		return nil, ErrTxClosed
+	}
+	if !tx.writable {
+		return nil, ErrTxNotWritable
+	}
+	if len(name) == 0 {
+		return nil, ErrBucketNameRequired
+	}
+	if _, ok := tx.buckets[string(name)]; ok {
+		return nil, ErrBucketExists
+	}
+	tx.copyMap()
+	tx.buckets[string(name)] = new(bucketData)
+	tx.owned[string(name)] = true
+	return tx.bucketHandle(string(name)), nil
+}
+
+func (tx *Tx) CreateBucketIfNotExists(name []byte) (*Bucket, error) {
+	if b := tx.Bucket(name); b != nil {
+		return b, nil
+	}
+	return tx.CreateBucket(name)
+}
+
+func (tx *Tx) DeleteBucket(name []byte) error {
+	if tx.closed {
+		return ErrTxClosed
+	}
+	if !tx.writable {
+		return ErrTxNotWritable
+	}
+	if _, ok := tx.buckets[string(name)]; !ok {
+		return ErrBucketNotFound
+	}
+	tx.copyMap()
+	delete(tx.buckets, string(name))
+	delete(tx.owned, string(name))
+	delete(tx.handles, string(name))
+	return nil
+}
+
+// ForEach visits buckets in name order
+func (tx *Tx) ForEach(fn func(name []byte, b *Bucket) error) error {
+	names := make([]string, 0, len(tx.buckets))
+	for name := range tx.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fn([]byte(name), tx.bucketHandle(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Bucket struct {
+	tx   *Tx
+	name string
+}
+
+func (b *Bucket) Tx() *Tx {
+	return b.tx
+}
+
+func (b *Bucket) Writable() bool {
+	return b.tx.writable
+}
+
+// false for handles whose bucket was deleted during the tx, even if one with
+// the same name was created since
+func (b *Bucket) live() bool {
+	return b.tx.handles[b.name] == b
+}
+
+func (b *Bucket) data() *bucketData {
+	data := b.tx.buckets[b.name]
+	if data == nil || !b.live() {
+		// deleted during the tx; behave like an empty bucket
+		return new(bucketData)
+	}
+	return data
+}
+
+// returns the bucket data after making sure it's safe to mutate
+func (b *Bucket) mutableData() (*bucketData, error) {
+	if b.tx.closed {
+		return nil, ErrTxClosed
+	}
+	if !b.tx.writable {
+		return nil, ErrTxNotWritable
+	}
+	if !b.live() {
+		// writing would bring the deleted bucket back
+		return nil, ErrBucketNotFound
+	}
+	b.tx.copyMap()
+	if !b.tx.owned[b.name] {
+		b.tx.buckets[b.name] = b.data().clone()
+		b.tx.owned[b.name] = true
+	}
+	return b.tx.buckets[b.name], nil
+}
+
+func (b *Bucket) Get(key []byte) []byte {
+	data := b.data()
+	i := data.search(key)
+	if i < len(data.keys) && bytes.Equal(data.keys[i], key) {
+		return data.values[i]
+	}
+	return nil
+}
+
+func (b *Bucket) Put(key []byte, value []byte) error {
+	if len(key) == 0 {
+		return ErrKeyRequired
+	}
+	data, err := b.mutableData()
+	if err != nil {
+		return err
+	}
+	// copy; the caller is free to reuse its buffers after Put returns
+	key = append([]byte(nil), key...)
+	value = append([]byte{}, value...)
+
+	i := data.search(key)
+	if i < len(data.keys) && bytes.Equal(data.keys[i], key) {
+		data.values[i] = value
+		return nil
+	}
+	data.keys = append(data.keys, nil)
+	data.values = append(data.values, nil)
+	copy(data.keys[i+1:], data.keys[i:])
+	copy(data.values[i+1:], data.values[i:])
+	data.keys[i] = key
+	data.values[i] = value
+	return nil
+}
+
+func (b *Bucket) Delete(key []byte) error {
+	data, err := b.mutableData()
+	if err != nil {
+		return err
+	}
+	i := data.search(key)
+	if i < len(data.keys) && bytes.Equal(data.keys[i], key) {
+		data.keys = append(data.keys[:i], data.keys[i+1:]...)
+		data.values = append(data.values[:i], data.values[i+1:]...)
+	}
+	return nil
+}
+
+func (b *Bucket) Sequence() uint64 {
+	return b.data().sequence
+}
+
+func (b *Bucket) SetSequence(v uint64) error {
+	data, err := b.mutableData()
+	if err != nil {
+		return err
+	}
+	data.sequence = v
+	return nil
+}
+
+func (b *Bucket) NextSequence() (uint64, error) {
+	data, err := b.mutableData()
+	if err != nil {
+		return 0, err
+	}
+	data.sequence++
+	return data.sequence, nil
+}
+
+func (b *Bucket) ForEach(fn func(k, v []byte) error) error {
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bucket) Stats() (s BucketStats) {
+	data := b.data()
+	s.KeyN = len(data.keys)
+	s.Depth = 1
+	for i := range data.keys {
+		s.LeafInuse += len(data.keys[i]) + len(data.values[i])
+	}
+	s.LeafAlloc = s.LeafInuse
+	return
+}
+
+func (b *Bucket) Cursor() *Cursor {
+	return &Cursor{bucket: b, pos: -1}
+}
+
+// Cursor positions are indexes into the sorted keys of the bucket.
+// As with bolt, mutating the bucket while iterating may skip or repeat items,
+// except for deleting the current item via Cursor.Delete
+type Cursor struct {
+	bucket *Bucket
+	pos    int
+}
+
+func (c *Cursor) Bucket() *Bucket {
+	return c.bucket
+}
+
+func (c *Cursor) at() ([]byte, []byte) {
+	data := c.bucket.data()
+	if c.pos < 0 || c.pos >= len(data.keys) {
+		return nil, nil
+	}
+	return data.keys[c.pos], data.values[c.pos]
+}
+
+func (c *Cursor) First() ([]byte, []byte) {
+	c.pos = 0
+	return c.at()
+}
+
+func (c *Cursor) Last() ([]byte, []byte) {
+	c.pos = len(c.bucket.data().keys) - 1
+	return c.at()
+}
+
+func (c *Cursor) Next() ([]byte, []byte) {
+	if n := len(c.bucket.data().keys); c.pos < n {
+		c.pos++
+	}
+	return c.at()
+}
+
+func (c *Cursor) Prev() ([]byte, []byte) {
+	if c.pos >= 0 {
+		c.pos--
+	}
+	return c.at()
+}
+
+// Seek moves to the first key >= seek. If there's no such key, the cursor is
+// placed past the end so that Prev returns the last item
+func (c *Cursor) Seek(seek []byte) ([]byte, []byte) {
+	c.pos = c.bucket.data().search(seek)
+	return c.at()
+}
+
+// Delete removes the current item; the next call to Next returns the item after it
+func (c *Cursor) Delete() error {
+	k, _ := c.at()
+	if k == nil {
+		return nil
+	}
+	if err := c.bucket.Delete(k); err != nil {
+		return err
+	}
+	c.pos--
+	return nil
+}
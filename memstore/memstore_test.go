@@ -0,0 +1,116 @@
+package memstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func mustPut(t *testing.T, b *Bucket, key string, value string) {
+	if err := b.Put([]byte(key), []byte(value)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCursorOrderAndSeek(t *testing.T) {
+	db, _ := Open("mem", 0644, nil)
+	tx, _ := db.Begin(true)
+	b, _ := tx.CreateBucket([]byte("b"))
+	for _, k := range []string{"d", "b", "a", "c"} {
+		mustPut(t, b, k, "v"+k)
+	}
+
+	var keys []string
+	b.ForEach(func(k, v []byte) error {
+		keys = append(keys, string(k))
+		return nil
+	})
+	if got := strings.Join(keys, ""); got != "abcd" {
+		t.Fatalf("unexpected order: %s", got)
+	}
+
+	c := b.Cursor()
+	if k, _ := c.Seek([]byte("bb")); string(k) != "c" {
+		t.Fatalf("seek: expected c, got %q", k)
+	}
+	if k, _ := c.Seek([]byte("z")); k != nil {
+		t.Fatalf("seek past end: expected nil, got %q", k)
+	}
+	if k, _ := c.Prev(); string(k) != "d" {
+		t.Fatalf("prev after seeking past end: expected d, got %q", k)
+	}
+
+	c.First()
+	c.Delete()
+	if k, _ := c.Next(); string(k) != "b" {
+		t.Fatalf("next after delete: expected b, got %q", k)
+	}
+	tx.Commit()
+}
+
+func TestSnapshotIsolation(t *testing.T) {
+	db, _ := Open("mem", 0644, nil)
+	db.Update(func(tx *Tx) error {
+		b, _ := tx.CreateBucket([]byte("b"))
+		mustPut(t, b, "k", "1")
+		return nil
+	})
+
+	reader, _ := db.Begin(false)
+	defer reader.Rollback()
+
+	db.Update(func(tx *Tx) error {
+		mustPut(t, tx.Bucket([]byte("b")), "k", "2")
+		return nil
+	})
+
+	if v := reader.Bucket([]byte("b")).Get([]byte("k")); string(v) != "1" {
+		t.Fatalf("reader saw a later commit: %q", v)
+	}
+
+	// rolled back writes are not visible
+	tx, _ := db.Begin(true)
+	mustPut(t, tx.Bucket([]byte("b")), "k", "3")
+	tx.Rollback()
+
+	db.View(func(tx *Tx) error {
+		if v := tx.Bucket([]byte("b")).Get([]byte("k")); string(v) != "2" {
+			t.Fatalf("expected 2, got %q", v)
+		}
+		return nil
+	})
+
+	if err := reader.Bucket([]byte("b")).Put([]byte("k"), nil); err != ErrTxNotWritable {
+		t.Fatalf("expected ErrTxNotWritable, got %v", err)
+	}
+}
+
+func TestDeletedBucketHandle(t *testing.T) {
+	db, _ := Open("mem", 0644, nil)
+	tx, _ := db.Begin(true)
+	defer tx.Rollback()
+	stale, _ := tx.CreateBucket([]byte("b"))
+	mustPut(t, stale, "k", "1")
+
+	if err := tx.DeleteBucket([]byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := stale.Put([]byte("k"), []byte("2")); err != ErrBucketNotFound {
+		t.Fatalf("put through a deleted bucket's handle: expected ErrBucketNotFound, got %v", err)
+	}
+	if tx.Bucket([]byte("b")) != nil {
+		t.Fatalf("the put brought the deleted bucket back")
+	}
+
+	// the old handle doesn't see or touch a new bucket of the same name
+	fresh, _ := tx.CreateBucket([]byte("b"))
+	mustPut(t, fresh, "k", "3")
+	if v := stale.Get([]byte("k")); v != nil {
+		t.Fatalf("the deleted bucket's handle read %q", v)
+	}
+	if err := stale.Delete([]byte("k")); err != ErrBucketNotFound {
+		t.Fatalf("delete through a deleted bucket's handle: expected ErrBucketNotFound, got %v", err)
+	}
+	if v := fresh.Get([]byte("k")); string(v) != "3" {
+		t.Fatalf("expected 3, got %q", v)
+	}
+}
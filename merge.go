@@ -0,0 +1,302 @@
+package vbolt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	MergeDB copies the buckets and indexes registered in an Info from one
+	database into another, e.g. to consolidate per-device or per-environment
+	files into one.
+
+	Keys that exist on both sides with different values are resolved by the
+	MergePolicy. For MergePreferNewer, the buckets must track timestamps (see
+	TrackTimestamps); records without timestamps on both sides keep the dst
+	value. The timestamps of a record move along with it.
+
+	Index entries are merged per target, and follow the record they were
+	computed from: a target whose encoded key is a record key that took the
+	src value in the bucket merge takes its src terms, and one that kept the
+	dst value keeps its dst terms. Other targets (not record keys, or a key
+	that went different ways in different buckets) present on both sides
+	with different terms are passed to Resolve if set, and otherwise keep
+	their dst terms with MergePreferDst and take their src terms with the
+	other policies (index entries have no timestamps of their own).
+
+	Keys are not renumbered, as the ids stored inside other records can't be
+	found and rewritten. If both files allocated ids from NextSequence for a
+	bucket, the same id may refer to different records, so a key with
+	different values on both sides fails the merge with ErrMergeCollision;
+	set SameIds if the files are copies of the same database, where an id
+	means the same record on both sides. The bucket sequences are raised to
+	the larger of the two, so ids allocated after the merge don't collide
+	with either side.
+
+	Collections are not merged.
+*/
+
+type MergePrefer uint8
+
+const (
+	MergePreferDst MergePrefer = iota
+	MergePreferSrc
+	MergePreferNewer
+)
+
+type MergePolicy struct {
+	Prefer MergePrefer
+
+	// if set, called instead of Prefer with the encoded values on both sides;
+	// returns the value to keep. For an index, key is the encoded target and
+	// the values are its encoded (term, priority) pairs, in term order; the
+	// returned value must be one of the two.
+	Resolve func(structure string, key []byte, dstValue []byte, srcValue []byte) []byte
+
+	// resolve differing values under ids allocated from the sequence on both
+	// sides, instead of failing with ErrMergeCollision
+	SameIds bool
+}
+
+var ErrMergeCollision = errors.New("vbolt: both databases allocated the same id to different records")
+
+// MergeDB merges src into dst in a single write transaction; see the
+// overview above
+func MergeDB(dst *DB, src *DB, info *Info, policy MergePolicy) (err error) {
+	srcTx := ReadTx(src)
+	defer TxClose(srcTx)
+	dstTx, err := _BeginWriteTx(dst)
+	if err != nil {
+		return err
+	}
+	defer TxClose(dstTx)
+
+	decided := make(_MergeDecisions)
+	for _, name := range info.BucketList {
+		if strings.HasSuffix(name, ".meta") && info.Infos[strings.TrimSuffix(name, ".meta")] != nil {
+			continue // merged along with its bucket
+		}
+		if err = _MergeBucket(dstTx, srcTx, info, name, policy, decided); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range info.IndexList {
+		merger, ok := info.Infos[name].(_IndexMerger)
+		if !ok {
+			continue
+		}
+		if err = merger._MergeIndex(dstTx, srcTx, policy, decided); err != nil {
+			return err
+		}
+	}
+
+	return TxCommitE(dstTx)
+}
+
+// which side each record key merged from a src bucket took its value from,
+// so the index entries of the record can follow it
+type _MergeDecisions map[string]uint8
+
+const (
+	_MergeTookSrc uint8 = 1 << iota
+	_MergeKeptDst
+	// the key went different ways in different buckets
+	_MergeAmbiguous = _MergeTookSrc | _MergeKeptDst
+)
+
+func _MergeBucket(dstTx *Tx, srcTx *Tx, info *Info, name string, policy MergePolicy, decided _MergeDecisions) error {
+	srcBkt := srcTx.Bucket([]byte(name))
+	if srcBkt == nil {
+		return nil
+	}
+	dstBkt, err := _TxWriteBucketE(dstTx, name)
+	if err != nil {
+		return err
+	}
+	_MarkDirty(dstTx, name)
+
+	metaName := name + ".meta"
+	var srcMeta, dstMeta *BBucket
+	if info.Infos[metaName] != nil {
+		srcMeta = srcTx.Bucket([]byte(metaName))
+		if dstMeta, err = _TxWriteBucketE(dstTx, metaName); err != nil {
+			return err
+		}
+	}
+
+	sequenced := srcBkt.Sequence() > 0 && dstBkt.Sequence() > 0 && !policy.SameIds
+	err = srcBkt.ForEach(func(key []byte, srcValue []byte) error {
+		dstValue := dstBkt.Get(key)
+		value := srcValue
+		if dstValue != nil {
+			if bytes.Equal(dstValue, srcValue) {
+				return nil
+			}
+			if sequenced {
+				return fmt.Errorf("%w: bucket %s, key %x", ErrMergeCollision, name, key)
+			}
+			value = _MergeResolve(name, key, dstValue, srcValue, dstMeta, srcMeta, policy)
+		}
+		if bytes.Equal(value, srcValue) {
+			decided[string(key)] |= _MergeTookSrc
+		} else {
+			decided[string(key)] |= _MergeKeptDst
+		}
+		if err := _Put(dstTx, dstBkt, name, key, value); err != nil {
+			return _RecordErr("merge", name, key, err)
+		}
+		_CountWrite(name, len(value))
+		if srcMeta != nil && bytes.Equal(value, srcValue) {
+			if meta := srcMeta.Get(key); meta != nil {
+				return _Put(dstTx, dstMeta, metaName, key, meta)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if seq := srcBkt.Sequence(); seq > dstBkt.Sequence() {
+		return _SetSequence(dstTx, dstBkt, name, seq)
+	}
+	return nil
+}
+
+func _MergeResolve(name string, key []byte, dstValue []byte, srcValue []byte, dstMeta *BBucket, srcMeta *BBucket, policy MergePolicy) []byte {
+	if policy.Resolve != nil {
+		return policy.Resolve(name, key, dstValue, srcValue)
+	}
+	switch policy.Prefer {
+	case MergePreferSrc:
+		return srcValue
+	case MergePreferNewer:
+		if srcMeta == nil {
+			break
+		}
+		var dstTime, srcTime RecordMeta
+		if !vpack.FromBytesInto(dstMeta.Get(key), &dstTime, PackRecordMeta) ||
+			!vpack.FromBytesInto(srcMeta.Get(key), &srcTime, PackRecordMeta) {
+			break
+		}
+		if srcTime.Updated.After(dstTime.Updated) {
+			return srcValue
+		}
+	}
+	return dstValue
+}
+
+type _IndexMerger interface {
+	_MergeIndex(dstTx *Tx, srcTx *Tx, policy MergePolicy, decided _MergeDecisions) error
+}
+
+// the (term, priority) pairs of the target entries under prefix, as given to
+// MergePolicy.Resolve
+func _EncodeTargetEntries(bkt *BBucket, prefix []byte) (encoded []byte) {
+	RawIterate(bkt, prefix, Window{}, func(key []byte, value []byte) bool {
+		encoded = append(encoded, key[len(prefix):]...)
+		encoded = append(encoded, value...)
+		return true
+	})
+	return
+}
+
+func (indexInfo *IndexInfo[K, T, P]) _MergeIndex(dstTx *Tx, srcTx *Tx, policy MergePolicy, decided _MergeDecisions) error {
+	srcBkt := srcTx.Bucket([]byte(indexInfo.Name))
+	if srcBkt == nil {
+		return nil
+	}
+	dstBkt, err := _TxWriteBucketE(dstTx, indexInfo.Name)
+	if err != nil {
+		return err
+	}
+
+	// target entries are sorted by target, so the terms of each target are
+	// consecutive
+	var target K
+	var terms map[T]P
+	var srcEncoded []byte
+	var prefixLen int
+	seen := make(map[string]bool)
+	flush := func() error {
+		if terms == nil {
+			return nil
+		}
+		defer func() { terms, srcEncoded = nil, nil }()
+		prefix := _TargetKeyPrefix(indexInfo, &target)
+		seen[string(prefix[1:])] = true
+		dstEncoded := _EncodeTargetEntries(dstBkt, prefix)
+		if bytes.Equal(dstEncoded, srcEncoded) {
+			return nil
+		}
+		switch decided[string(prefix[1:])] {
+		case _MergeTookSrc:
+		case _MergeKeptDst:
+			return nil
+		default:
+			if dstEncoded == nil {
+				break
+			}
+			keepDst := policy.Prefer == MergePreferDst
+			if policy.Resolve != nil {
+				resolved := policy.Resolve(indexInfo.Name, prefix[1:], dstEncoded, srcEncoded)
+				keepDst = !bytes.Equal(resolved, srcEncoded)
+			}
+			if keepDst {
+				return nil
+			}
+		}
+		return SetTargetTermsE(dstTx, indexInfo, target, terms)
+	}
+
+	var iterErr error
+	RawIterate(srcBkt, []byte{IndexTargetPrefix}, Window{}, func(key []byte, value []byte) bool {
+		entryTarget, term := _ReadTargetTerm(indexInfo, key)
+		if terms != nil && entryTarget != target {
+			if iterErr = flush(); iterErr != nil {
+				return false
+			}
+		}
+		if terms == nil {
+			target = entryTarget
+			terms = make(map[T]P)
+			prefixLen = len(_TargetKeyPrefix(indexInfo, &target))
+		}
+		var priority P
+		vpack.FromBytesInto(value, &priority, indexInfo.PriorityPackFn)
+		terms[term] = priority
+		srcEncoded = append(srcEncoded, key[prefixLen:]...)
+		srcEncoded = append(srcEncoded, value...)
+		return true
+	})
+	if iterErr != nil {
+		return iterErr
+	}
+	if err = flush(); err != nil {
+		return err
+	}
+
+	// records that took the src value without any src terms drop their dst terms
+	for key, decision := range decided {
+		if decision != _MergeTookSrc || seen[key] {
+			continue
+		}
+		var target K
+		if !vpack.FromBytesInto([]byte(key), &target, indexInfo.TargetPackFn) {
+			continue
+		}
+		prefix := _TargetKeyPrefix(indexInfo, &target)
+		if string(prefix[1:]) != key || _EncodeTargetEntries(dstBkt, prefix) == nil {
+			continue
+		}
+		if err = SetTargetTermsE(dstTx, indexInfo, target, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
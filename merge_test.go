@@ -0,0 +1,112 @@
+package vbolt_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestMergeDB(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+	vbolt.TrackTimestamps(&dbInfo, posts)
+	tags := vbolt.Index(&dbInfo, "post_tags", vpack.String, vpack.FInt)
+
+	type post struct {
+		content string
+		tags    string // space separated, sorted
+	}
+
+	cases := []struct {
+		name     string
+		prefer   vbolt.MergePrefer
+		expected map[int]post
+	}{
+		{"dst", vbolt.MergePreferDst, map[int]post{
+			1: {"dst 1", "d1"},
+			2: {"src 2", "s2"},
+			3: {"dst 3", "d3"},
+			4: {"dst 4", "d4"},
+		}},
+		{"src", vbolt.MergePreferSrc, map[int]post{
+			1: {"src 1", "s1"},
+			2: {"src 2", "s2"},
+			3: {"src 3", "s3"},
+			4: {"src 4", ""},
+		}},
+		// the record written last wins, and its terms come with it
+		{"newer", vbolt.MergePreferNewer, map[int]post{
+			1: {"src 1", "s1"},
+			2: {"src 2", "s2"},
+			3: {"dst 3", "d3"},
+			4: {"src 4", ""},
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clock := vbolttest.UseFakeClock(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			dst := vbolttest.NewTestDB(t, &dbInfo)
+			src := vbolttest.NewTestDB(t, &dbInfo)
+
+			write := func(db *vbolt.DB, id int, content string, terms ...string) {
+				vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+					vbolt.Write(tx, posts, id, &content)
+					vbolt.SetTargetTermsPlain(tx, tags, id, terms)
+				})
+			}
+			write(dst, 1, "dst 1", "d1")
+			write(dst, 4, "dst 4", "d4")
+			write(src, 3, "src 3", "s3")
+			clock.Advance(time.Hour)
+			write(src, 1, "src 1", "s1")
+			write(src, 2, "src 2", "s2")
+			write(src, 4, "src 4") // no terms: its dst terms must go if it wins
+			write(dst, 3, "dst 3", "d3")
+
+			if err := vbolt.MergeDB(dst, src, &dbInfo, vbolt.MergePolicy{Prefer: c.prefer}); err != nil {
+				t.Fatalf("merge failed: %v", err)
+			}
+
+			vbolttest.View(dst, func(tx *vbolt.Tx) {
+				for id, expected := range c.expected {
+					var content string
+					vbolt.Read(tx, posts, id, &content)
+					var terms []string
+					vbolt.IterateTarget(tx, tags, id, func(term string, priority uint16) bool {
+						terms = append(terms, term)
+						return true
+					})
+					sort.Strings(terms)
+					found := post{content, strings.Join(terms, " ")}
+					if found != expected {
+						t.Errorf("post %d: expected %v, found %v", id, expected, found)
+					}
+
+					// the term side of the index agrees with the target side
+					for _, term := range terms {
+						var targets []int
+						vbolt.ReadTermTargets(tx, tags, term, &targets, vbolt.Window{})
+						if len(targets) != 1 || targets[0] != id {
+							t.Errorf("term %q: expected target %d, found %v", term, id, targets)
+						}
+					}
+				}
+				for _, term := range []string{"d1", "d3", "d4", "s1", "s2", "s3"} {
+					var targets []int
+					vbolt.ReadTermTargets(tx, tags, term, &targets, vbolt.Window{})
+					id := int(term[1] - '0')
+					kept := strings.Contains(c.expected[id].tags, term)
+					if kept != (len(targets) > 0) {
+						t.Errorf("term %q: expected present=%v, found targets %v", term, kept, targets)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package vbolt
+
+import (
+	"time"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	Opt-in created/updated timestamps for the records of a bucket:
+
+		var Posts = vbolt.Bucket(&Info, "posts", vpack.FInt, PackPost)
+		var _ = vbolt.TrackTimestamps(&Info, Posts)
+
+	The timestamps are kept in a separate bucket (named after the bucket, with
+	a ".meta" suffix) with the same keys, maintained by Write and Delete, so
+	the records themselves are unchanged. Writes that bypass the typed api
+	(raw puts, restores) don't update them.
+*/
+
+type RecordMeta struct {
+	Created time.Time
+	Updated time.Time
+}
+
+func PackRecordMeta(self *RecordMeta, buf *vpack.Buffer) {
+	vpack.Version(1, buf)
+	vpack.UnixTime(&self.Created, buf)
+	vpack.UnixTime(&self.Updated, buf)
+}
+
+// TrackTimestamps registers the metadata bucket for bucketInfo and enables
+// the timestamps on it. Returns the metadata bucket.
+func TrackTimestamps[K comparable, T any](dbInfo *Info, bucketInfo *BucketInfo[K, T]) *BucketInfo[K, RecordMeta] {
+	bucketInfo.Meta = Bucket(dbInfo, bucketInfo.Name+".meta", bucketInfo.KeyPackFn, PackRecordMeta)
+	return bucketInfo.Meta
+}
+
+// ReadMeta returns the timestamps of the record at id. ok is false if the
+// bucket doesn't track timestamps or the record was never written with
+// tracking enabled.
+func ReadMeta[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K) (meta RecordMeta, ok bool) {
+	if bucketInfo.Meta == nil {
+		return
+	}
+	return Get(tx, bucketInfo.Meta, id)
+}
+
+// id must not be the zero value
+func _TouchMeta[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K) error {
+	if bucketInfo.Meta == nil {
+		return nil
+	}
+	now := Now()
+	var meta RecordMeta
+	if !_ReadKey(TxRawBucket(tx, bucketInfo.Meta.Name), bucketInfo.Meta, id, &meta) {
+		meta = RecordMeta{Created: now}
+	}
+	meta.Updated = now
+	return _Write(tx, bucketInfo.Meta, id, &meta)
+}
+
+func _DeleteMeta[K, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K) error {
+	if bucketInfo.Meta == nil {
+		return nil
+	}
+	return DeleteE(tx, bucketInfo.Meta, id)
+}
@@ -0,0 +1,113 @@
+package vbolt
+
+import (
+	"reflect"
+
+	"go.hasen.dev/generic"
+)
+
+// Method versions of the common operations, so they show up in autocompletion
+// after the info variable. They just call the free functions.
+//
+// BucketInfo allows any key type, and a method can't tighten the type
+// parameters of its receiver, so the methods whose functions need comparable
+// keys (to skip the zero id) check for it with reflection instead.
+
+// id == zero, for keys that may not be comparable
+func _IsZeroKey[K any](id K) bool {
+	return reflect.ValueOf(&id).Elem().IsZero()
+}
+
+func (b *BucketInfo[K, T]) Read(tx *Tx, id K, item *T) bool {
+	if _IsZeroKey(id) {
+		return false
+	}
+	return _ReadKey(TxRawBucket(tx, b.Name), b, id, item)
+}
+
+func (b *BucketInfo[K, T]) Get(tx *Tx, id K) (item T, ok bool) {
+	if ok = b.Read(tx, id, &item); !ok {
+		var zero T
+		item = zero
+	}
+	return
+}
+
+func (b *BucketInfo[K, T]) ReadRaw(tx *Tx, id K, buf []byte) ([]byte, bool) {
+	return ReadRaw(tx, b, id, buf)
+}
+
+func (b *BucketInfo[K, T]) Write(tx *Tx, id K, item *T) {
+	generic.MustOK(b.WriteE(tx, id, item))
+}
+
+func (b *BucketInfo[K, T]) WriteE(tx *Tx, id K, item *T) error {
+	if _IsZeroKey(id) {
+		return nil
+	}
+	return _Write(tx, b, id, item)
+}
+
+func (b *BucketInfo[K, T]) HasKey(tx *Tx, id K) bool {
+	return HasKey(tx, b, id)
+}
+
+func (b *BucketInfo[K, T]) Delete(tx *Tx, id K) {
+	Delete(tx, b, id)
+}
+
+func (b *BucketInfo[K, T]) NextIntId(tx *Tx) int {
+	return NextIntId(tx, b)
+}
+
+func (b *BucketInfo[K, T]) IterateAll(tx *Tx, visitFn func(key K, item T) bool, opts ...IterOption) Continuation {
+	return IterateAll(tx, b, visitFn, opts...)
+}
+
+func (idx *IndexInfo[K, T, P]) SetTerms(tx *Tx, target K, terms map[T]P) {
+	SetTargetTerms(tx, idx, target, terms)
+}
+
+func (idx *IndexInfo[K, T, P]) SetTermsPlain(tx *Tx, target K, terms []T) {
+	SetTargetTermsPlain(tx, idx, target, terms)
+}
+
+func (idx *IndexInfo[K, T, P]) DeleteTarget(tx *Tx, target K) {
+	DeleteTargetTerms(tx, idx, target)
+}
+
+func (idx *IndexInfo[K, T, P]) IterateTerm(tx *Tx, term T, visitFn func(target K, priority P) bool, opts ...IterOption) Continuation {
+	return IterateTerm(tx, idx, term, visitFn, opts...)
+}
+
+func (idx *IndexInfo[K, T, P]) IterateTarget(tx *Tx, target K, visitFn func(term T, priority P) bool) Continuation {
+	return IterateTarget(tx, idx, target, visitFn)
+}
+
+func (idx *IndexInfo[K, T, P]) ReadTargets(tx *Tx, term T, targets *[]K, window Window) Continuation {
+	return ReadTermTargets(tx, idx, term, targets, window)
+}
+
+func (idx *IndexInfo[K, T, P]) ReadCount(tx *Tx, term T, count *int) bool {
+	return ReadTermCount(tx, idx, &term, count)
+}
+
+func (c *CollectionInfo[K, O, I]) Add(tx *Tx, key K, order O, item I) {
+	CollectionAddEntry(tx, c, key, order, item)
+}
+
+func (c *CollectionInfo[K, O, I]) Remove(tx *Tx, key K, item I) {
+	CollectionRemoveEntry(tx, c, key, item)
+}
+
+func (c *CollectionInfo[K, O, I]) Iterate(tx *Tx, key K, visit func(key K, order O, item I) bool, opts ...IterOption) Continuation {
+	return IterateCollection(tx, c, key, visit, opts...)
+}
+
+func (c *CollectionInfo[K, O, I]) Read(tx *Tx, key K, items *[]I, count int) Continuation {
+	return ReadCollection(tx, c, key, items, count)
+}
+
+func (c *CollectionInfo[K, O, I]) ReadCount(tx *Tx, key K) int {
+	return ReadCollectionCount(tx, c, key)
+}
@@ -1,7 +1,7 @@
 package vbolt
 
 import (
-	"log"
+	"errors"
 	"sync"
 	"time"
 
@@ -26,31 +26,152 @@ func TxWriteBatches[Key, Struct any](db *DB, info *BucketInfo[Key, Struct], batc
 var dbInfo Info
 var DBProcesses = Bucket(&dbInfo, "proc", vpack.StringZ, vpack.UnixTime)
 
+// system bucket: where batched processes (like MigrateCollectionToIndex)
+// record how far they got, so they can resume if interrupted
+var ProcessProgress = Bucket(&dbInfo, "progress", vpack.StringZ, vpack.Bytes)
+
+// the last run of a process, kept even when the process is invalidated
+type ProcessRun struct {
+	Started  time.Time
+	Duration time.Duration
+	Runs     int
+}
+
+func PackProcessRun(self *ProcessRun, buf *vpack.Buffer) {
+	vpack.Version(1, buf)
+	vpack.UnixTime(&self.Started, buf)
+	ms := int(self.Duration / time.Millisecond)
+	vpack.Int(&ms, buf)
+	self.Duration = time.Duration(ms) * time.Millisecond
+	vpack.Int(&self.Runs, buf)
+}
+
+// system bucket: the history of processes run by ApplyDBProcess
+var ProcessHistory = Bucket(&dbInfo, "proc_history", vpack.StringZ, PackProcessRun)
+
 var _takeTurns sync.Mutex
 
+// name => func(), registered by ApplyDBProcessWithDown
+var _processDowns sync.Map
+
+var ErrNoDown = errors.New("vbolt: the process has no down function registered")
+
 // kind of like a migration, but mostly we expect it to be about recreating indecies and stuff
 func ApplyDBProcess(db *DB, name string, processFn func()) {
+	_ApplyDBProcess(db, name, false, processFn)
+}
+
+// ApplyDBProcessWithDown is like ApplyDBProcess, and registers downFn as the
+// way to reverse the process with RevertDBProcess (e.g. before rolling back
+// the deploy that added it). The registration only lives in this process, so
+// it's made whether or not the process runs.
+func ApplyDBProcessWithDown(db *DB, name string, processFn func(), downFn func()) {
+	_processDowns.Store(name, downFn)
+	_ApplyDBProcess(db, name, false, processFn)
+}
+
+// RevertDBProcess runs the down function of an applied process and clears
+// its applied mark, so the next ApplyDBProcess runs it again. Does nothing if
+// the process isn't applied; returns ErrNoDown if it was applied without a
+// down function.
+func RevertDBProcess(db *DB, name string) error {
+	_takeTurns.Lock()
+	defer _takeTurns.Unlock()
+
+	var applied bool
+	WithReadTx(db, func(tx *Tx) {
+		applied = HasKey(tx, DBProcesses, name)
+	})
+	if !applied {
+		return nil
+	}
+	downFn, ok := _processDowns.Load(name)
+	if !ok {
+		return ErrNoDown
+	}
+
+	startTime := time.Now()
+	Logf("Process: %s :: REVERT START", name)
+	downFn.(func())()
+	Logf("Process: %s :: REVERT END     [%s]", name, time.Since(startTime))
+	InvalidateDBProcess(db, name)
+	return nil
+}
+
+// RerunDBProcess runs the process even if it was already applied, and
+// records it as applied again
+func RerunDBProcess(db *DB, name string, processFn func()) {
+	_ApplyDBProcess(db, name, true, processFn)
+}
+
+// InvalidateDBProcess clears the applied mark of the process, so the next
+// ApplyDBProcess with that name runs it again (e.g. on the next startup).
+// Its history is kept.
+func InvalidateDBProcess(db *DB, name string) {
+	WithWriteTx(db, func(tx *Tx) {
+		Delete(tx, DBProcesses, name)
+		TxCommit(tx)
+	})
+}
+
+func _ApplyDBProcess(db *DB, name string, force bool, processFn func()) {
 	_takeTurns.Lock()
 	defer _takeTurns.Unlock()
 
-	shouldRun := false
+	shouldRun := force
 	WithReadTx(db, func(tx *Tx) {
 		var ts time.Time
-		shouldRun = !Read(tx, DBProcesses, name, &ts)
+		shouldRun = shouldRun || !Read(tx, DBProcesses, name, &ts)
 	})
 	if !shouldRun {
 		return
 	}
 
 	startTime := time.Now()
-	log.Printf("Process: %s :: START", name)
+	Logf("Process: %s :: START", name)
 	processFn()
-	log.Printf("Process: %s :: END     [%s]", name, time.Since(startTime))
+	duration := time.Since(startTime)
+	Logf("Process: %s :: END     [%s]", name, duration)
 	WithWriteTx(db, func(tx *Tx) {
-		ts := time.Now()
+		ts := Now()
 		Write(tx, DBProcesses, name, &ts)
-		tx.Commit()
+
+		var run ProcessRun
+		Read(tx, ProcessHistory, name, &run)
+		run.Started = ts.Add(-duration)
+		run.Duration = duration
+		run.Runs++
+		Write(tx, ProcessHistory, name, &run)
+		TxCommit(tx)
+	})
+}
+
+type ProcessInfo struct {
+	Name       string
+	Applied    time.Time // zero if invalidated since its last run
+	ProcessRun           // zero for processes applied before the history was kept
+}
+
+// ListDBProcesses returns the applied processes, followed by the invalidated
+// ones (which will run again), in name order
+func ListDBProcesses(db *DB) (list []ProcessInfo) {
+	WithReadTx(db, func(tx *Tx) {
+		var pending []ProcessInfo
+		IterateAll(tx, DBProcesses, func(name string, applied time.Time) bool {
+			info := ProcessInfo{Name: name, Applied: applied}
+			Read(tx, ProcessHistory, name, &info.ProcessRun)
+			list = append(list, info)
+			return true
+		})
+		IterateAll(tx, ProcessHistory, func(name string, run ProcessRun) bool {
+			if !HasKey(tx, DBProcesses, name) {
+				pending = append(pending, ProcessInfo{Name: name, ProcessRun: run})
+			}
+			return true
+		})
+		list = append(list, pending...)
 	})
+	return
 }
 
 func RunProcess(label string, processFn func()) {
@@ -58,9 +179,9 @@ func RunProcess(label string, processFn func()) {
 	defer _takeTurns.Unlock()
 
 	startTime := time.Now()
-	log.Printf("%s :: START", label)
+	Logf("%s :: START", label)
 	processFn()
-	log.Printf("%s :: END    [%s]", label, time.Since(startTime))
+	Logf("%s :: END    [%s]", label, time.Since(startTime))
 }
 
 func InitBuckets(db *DB, infos ...*Info) {
@@ -69,6 +190,6 @@ func InitBuckets(db *DB, infos ...*Info) {
 		for _, info := range infos {
 			EnsureBuckets(tx, info)
 		}
-		tx.Commit()
+		TxCommit(tx)
 	})
 }
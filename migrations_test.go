@@ -0,0 +1,64 @@
+package vbolt_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestRevertDBProcess(t *testing.T) {
+	var dbInfo vbolt.Info
+	flags := vbolt.Bucket(&dbInfo, "flags", vpack.StringZ, vpack.StringZ)
+	db := vbolttest.NewTestDB(t, &dbInfo)
+
+	read := func() (value string) {
+		vbolttest.View(db, func(tx *vbolt.Tx) {
+			vbolt.Read(tx, flags, "mode", &value)
+		})
+		return
+	}
+	set := func(value string) func() {
+		return func() {
+			vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+				vbolt.Write(tx, flags, "mode", &value)
+			})
+		}
+	}
+
+	ups, downs := 0, 0
+	apply := func() {
+		vbolt.ApplyDBProcessWithDown(db, "test_revert_mode",
+			func() { ups++; set("new")() },
+			func() { downs++; set("old")() })
+	}
+
+	apply()
+	apply() // already applied
+	if ups != 1 || read() != "new" {
+		t.Fatalf("after applying: %d runs, mode %q", ups, read())
+	}
+
+	if err := vbolt.RevertDBProcess(db, "test_revert_mode"); err != nil {
+		t.Fatalf("revert failed: %v", err)
+	}
+	if err := vbolt.RevertDBProcess(db, "test_revert_mode"); err != nil {
+		t.Fatalf("reverting a reverted process: %v", err)
+	}
+	if downs != 1 || read() != "old" {
+		t.Fatalf("after reverting: %d runs, mode %q", downs, read())
+	}
+
+	// the next apply runs it again
+	apply()
+	if ups != 2 || read() != "new" {
+		t.Fatalf("after applying again: %d runs, mode %q", ups, read())
+	}
+
+	vbolt.ApplyDBProcess(db, "test_revert_no_down", set("other"))
+	if err := vbolt.RevertDBProcess(db, "test_revert_no_down"); !errors.Is(err, vbolt.ErrNoDown) {
+		t.Errorf("expected ErrNoDown, got: %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package vbolt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Helpers for scripts and migrations, where any failure should stop
+// everything. They panic with errors that say what was being done.
+
+var ErrNotFound = errors.New("vbolt: not found")
+
+// MustRead returns the item stored at id, and panics if there is none
+func MustRead[K comparable, T any](tx *Tx, bucketInfo *BucketInfo[K, T], id K) T {
+	item, ok := Get(tx, bucketInfo, id)
+	if !ok {
+		panic(fmt.Errorf("%w: %s: key %v", ErrNotFound, bucketInfo.Name, id))
+	}
+	return item
+}
+
+// MustWriteTx starts a write tx, and panics if it can't
+func MustWriteTx(db *DB) *Tx {
+	tx, err := _BeginWriteTx(db)
+	if err != nil {
+		panic(fmt.Errorf("vbolt: starting write tx on %s: %w", db.Path(), err))
+	}
+	return tx
+}
+
+// MustCommit commits the tx, and panics if the commit fails
+func MustCommit(tx *Tx) {
+	if err := TxCommitE(tx); err != nil {
+		panic(fmt.Errorf("vbolt: commit on %s: %w", tx.DB().Path(), err))
+	}
+}
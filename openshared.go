@@ -0,0 +1,131 @@
+package vbolt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+/*
+	Only one process can open a bolt file for writing; others wait for the
+	file lock and then fail with a bare timeout. OpenShared retries according
+	to a policy, and when it gives up, says which process holds the lock.
+
+	The holder is identified through a small json file next to the database
+	(path + ".lockinfo") that OpenShared writes after opening, and
+	CloseShared removes. If the holder crashed, the file may be stale; the
+	file lock itself is released by the OS, so the next open succeeds anyway.
+*/
+
+type OpenPolicy struct {
+	Timeout    time.Duration // per attempt; defaults to 1s
+	Retries    int
+	RetryDelay time.Duration
+
+	// if the file can't be opened for writing, try opening it read-only.
+	// this only helps if the other processes also have it open read-only:
+	// bolt's read-only mode still waits for a writer's lock.
+	ReadOnlyFallback bool
+}
+
+type FileLockHolder struct {
+	PID   int
+	Host  string
+	Since time.Time
+}
+
+var ErrLocked = errors.New("vbolt: database is locked by another process")
+
+type LockedError struct {
+	Path   string
+	Holder *FileLockHolder // nil if unknown
+}
+
+func (e *LockedError) Error() string {
+	if e.Holder == nil {
+		return fmt.Sprintf("%v: %s (holder unknown)", ErrLocked, e.Path)
+	}
+	return fmt.Sprintf("%v: %s (pid %d on %s, since %s)", ErrLocked, e.Path, e.Holder.PID, e.Holder.Host, e.Holder.Since.Format(time.RFC3339))
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+func _LockInfoPath(filename string) string {
+	return filename + ".lockinfo"
+}
+
+// ReadFileLockHolder returns the process that last opened the file with
+// OpenShared and hasn't closed it with CloseShared
+func ReadFileLockHolder(filename string) (holder *FileLockHolder) {
+	data, err := os.ReadFile(_LockInfoPath(filename))
+	if err != nil {
+		return nil
+	}
+	holder = new(FileLockHolder)
+	if json.Unmarshal(data, holder) != nil {
+		return nil
+	}
+	return holder
+}
+
+// OpenShared opens the file like Open, but returns an error (a *LockedError
+// if another process holds the file) instead of panicking.
+func OpenShared(filename string, policy OpenPolicy) (db *DB, readOnly bool, err error) {
+	var options Options
+	options.Timeout = policy.Timeout
+	if options.Timeout <= 0 {
+		options.Timeout = time.Second
+	}
+	options.InitialMmapSize = 1024 * 1024 * 1024
+
+	for attempt := 0; attempt <= policy.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(policy.RetryDelay)
+		}
+		db, err = _OpenBackend(filename, 0644, &options)
+		if err == nil {
+			_WriteLockInfo(filename)
+			return db, false, nil
+		}
+		if !errors.Is(err, ErrOpenTimeout) {
+			return nil, false, err
+		}
+	}
+
+	if policy.ReadOnlyFallback {
+		options.ReadOnly = true
+		if db, err = _OpenBackend(filename, 0644, &options); err == nil {
+			return db, true, nil
+		}
+	}
+	if errors.Is(err, ErrOpenTimeout) {
+		err = &LockedError{Path: filename, Holder: ReadFileLockHolder(filename)}
+	}
+	return nil, false, err
+}
+
+func _WriteLockInfo(filename string) {
+	var holder FileLockHolder
+	holder.PID = os.Getpid()
+	holder.Host, _ = os.Hostname()
+	holder.Since = Now()
+	data, _ := json.Marshal(holder)
+	if err := os.WriteFile(_LockInfoPath(filename), data, 0644); err != nil {
+		Logf("vbolt: writing lock info for %s: %v", filename, err)
+	}
+}
+
+// CloseShared closes a database opened with OpenShared (writable), and
+// removes its lock info
+func CloseShared(db *DB) error {
+	path := db.Path()
+	err := Close(db)
+	if holder := ReadFileLockHolder(path); holder != nil && holder.PID == os.Getpid() {
+		os.Remove(_LockInfoPath(path))
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package vbolt
+
+/*
+	Ops accumulates writes to be applied together in one write tx:
+
+		var ops vbolt.Ops
+		vbolt.OpWrite(&ops, Users, user.Id, &user)
+		vbolt.OpSetTargetTerms(&ops, UsersByEmail, user.Id, vbolt.UniformTerms([]string{user.Email}, uint16(0)))
+		vbolt.OpDelete(&ops, Invites, inviteId)
+		err := ops.Apply(db)
+
+	Items passed to OpWrite are copied (shallowly) when added, so later
+	changes to the variable don't affect the change set.
+*/
+
+type Ops struct {
+	Steps []func(tx *Tx) error
+}
+
+func (ops *Ops) Len() int {
+	return len(ops.Steps)
+}
+
+// Do adds an arbitrary step
+func (ops *Ops) Do(fn func(tx *Tx) error) {
+	ops.Steps = append(ops.Steps, fn)
+}
+
+// Append adds the steps of other after the steps of ops
+func (ops *Ops) Append(other *Ops) {
+	ops.Steps = append(ops.Steps, other.Steps...)
+}
+
+func OpWrite[K comparable, T any](ops *Ops, bucketInfo *BucketInfo[K, T], id K, item *T) {
+	value := *item
+	ops.Do(func(tx *Tx) error {
+		return WriteE(tx, bucketInfo, id, &value)
+	})
+}
+
+func OpDelete[K, T any](ops *Ops, bucketInfo *BucketInfo[K, T], id K) {
+	ops.Do(func(tx *Tx) error {
+		return DeleteE(tx, bucketInfo, id)
+	})
+}
+
+func OpSetTargetTerms[K, T, P comparable](ops *Ops, indexInfo *IndexInfo[K, T, P], target K, terms map[T]P) {
+	ops.Do(func(tx *Tx) error {
+		return SetTargetTermsE(tx, indexInfo, target, terms)
+	})
+}
+
+// ApplyTx runs the steps in order in tx, stopping at the first error.
+// Does not commit.
+func (ops *Ops) ApplyTx(tx *Tx) error {
+	for _, step := range ops.Steps {
+		if err := step(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply runs the steps in a new write tx and commits it. If any step fails,
+// the tx is rolled back and nothing is written.
+func (ops *Ops) Apply(db *DB) error {
+	tx, err := _BeginWriteTx(db)
+	if err != nil {
+		return err
+	}
+	defer TxClose(tx)
+	if err = ops.ApplyTx(tx); err != nil {
+		return err
+	}
+	return TxCommitE(tx)
+}
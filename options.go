@@ -0,0 +1,78 @@
+package vbolt
+
+import "go.hasen.dev/vpack"
+
+// Options for IterateAll, IterateTerm, and IterateCollection:
+//
+//	vbolt.IterateTerm(tx, PostsByTag, "go", visit, vbolt.Reverse(), vbolt.WithLimit(20))
+type IterOption func(o *IterOptions)
+
+type IterOptions struct {
+	Window
+	Prefix []byte // raw bytes the keys must start with, after the structure's own prefix
+
+	// raw bounds, after the structure's own prefix (and Prefix); only used by IterateTerm
+	Lower []byte // inclusive
+	Upper []byte // exclusive
+}
+
+func _IterOptions(base Window, opts []IterOption) (o IterOptions) {
+	o.Window = base
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return
+}
+
+func WithLimit(limit int) IterOption {
+	return func(o *IterOptions) { o.Limit = limit }
+}
+
+func WithOffset(offset int) IterOption {
+	return func(o *IterOptions) { o.Offset = offset }
+}
+
+func Reverse() IterOption {
+	return func(o *IterOptions) { o.Direction = IterateReverse }
+}
+
+// From continues an earlier iteration; see Continuation
+func From(cursor []byte) IterOption {
+	return func(o *IterOptions) { o.Cursor = cursor }
+}
+
+// Prefix restricts the iteration to the keys that start with prefix, after
+// the structure's own prefix: the encoded key for buckets, the encoded term
+// (so the prefix applies to the priority) for index terms, and the encoded
+// key (so the prefix applies to the order) for collections.
+func Prefix(prefix []byte) IterOption {
+	return func(o *IterOptions) { o.Prefix = prefix }
+}
+
+func WithWindow(window Window) IterOption {
+	return func(o *IterOptions) { o.Window = window }
+}
+
+// MinPriority makes IterateTerm skip the targets with a priority below min.
+// Priorities are compared by their packed bytes (see IterateTermRange), and
+// the skipped entries are never read.
+func MinPriority[K, T, P comparable](indexInfo *IndexInfo[K, T, P], min P) IterOption {
+	lower := vpack.ToBytes(&min, indexInfo.PriorityPackFn)
+	return func(o *IterOptions) { o.Lower = lower }
+}
+
+// MaxPriority makes IterateTerm skip the targets with a priority above max;
+// see MinPriority
+func MaxPriority[K, T, P comparable](indexInfo *IndexInfo[K, T, P], max P) IterOption {
+	upper := _NextPrefix(vpack.ToBytes(&max, indexInfo.PriorityPackFn))
+	return func(o *IterOptions) { o.Upper = upper }
+}
+
+// PriorityRange is MinPriority and MaxPriority together
+func PriorityRange[K, T, P comparable](indexInfo *IndexInfo[K, T, P], min P, max P) IterOption {
+	minOpt, maxOpt := MinPriority(indexInfo, min), MaxPriority(indexInfo, max)
+	return func(o *IterOptions) {
+		minOpt(o)
+		maxOpt(o)
+	}
+}
@@ -0,0 +1,448 @@
+package vbolt
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	A typed query builder over a bucket and the indexes that target its keys:
+
+		var posts []Post
+		next, err := vbolt.Select(Posts).
+			WhereIndex(PostsByTag, "go").
+			WhereIndex(PostsByAuthor, authorId).
+			Filter(func(id int, p *Post) bool { return !p.Draft }).
+			Window(vbolt.Window{Limit: 20, Direction: vbolt.IterateReverse}).
+			Fetch(tx, &posts)
+
+	Planning:
+
+	- Without conditions, the bucket is scanned in key order.
+	- With conditions, the term with the fewest targets (according to the
+	  stored counts) drives the iteration, and each candidate is checked
+	  against the other terms using the target->term entries.
+	- Expressions (Where) can also union terms. A union visits its parts one
+	  after the other, skipping the targets an earlier part matched, so
+	  nothing is held in memory; an intersection is driven by its smallest
+	  part like the conditions are. Cursors are not supported with
+	  expressions; page with the offset instead.
+	- Filters are applied after loading the record.
+	- Without SortBy, results are streamed in iteration order, and the window
+	  applies to the matching results; Fetch returns a cursor for the next page.
+	- With SortBy, all matches are loaded and sorted in memory (keeping only
+	  offset+limit of them when there's a limit), then the window's offset
+	  and limit are applied. Cursors are not supported.
+*/
+
+type QueryCondition struct {
+	Index     GenericIndexInfo
+	Term      any
+	TermBytes []byte
+}
+
+// QueryExpr combines index terms; exactly one of the fields is set
+type QueryExpr struct {
+	Term *QueryCondition
+	And  []QueryExpr
+	Or   []QueryExpr
+}
+
+// QueryPlan is the untyped part of a query, and what runs it. QueryBuilder
+// wraps one with the types of its bucket; ParseQuery and the json Query of
+// QueryHandler compile to one.
+type QueryPlan struct {
+	BucketName string           // scanned when there are no conditions
+	Conditions []QueryCondition // intersected
+	Exprs      []QueryExpr      // intersected with the conditions
+	Paging     Window
+}
+
+type QueryBuilder[K comparable, T any] struct {
+	QueryPlan
+	Bucket  *BucketInfo[K, T]
+	Filters []func(key K, item *T) bool
+	Less    func(a, b *T) bool
+
+	Error error // from building the query; returned by Fetch
+}
+
+func Select[K comparable, T any](bucketInfo *BucketInfo[K, T]) *QueryBuilder[K, T] {
+	return &QueryBuilder[K, T]{Bucket: bucketInfo, QueryPlan: QueryPlan{BucketName: bucketInfo.Name}}
+}
+
+// QueryTerm makes the condition for the targets of term in the index.
+// indexInfo must be an *IndexInfo, and term must be of its term type.
+func QueryTerm(indexInfo any, term any) (c QueryCondition, err error) {
+	g, ok := AsGenericIndex(indexInfo)
+	if !ok {
+		return c, fmt.Errorf("%w: not an index: %T", ErrInvalidQuery, indexInfo)
+	}
+	termValue := reflect.ValueOf(term)
+	if !termValue.IsValid() || termValue.Type() != g.TermType {
+		return c, fmt.Errorf("%w: index %s expects %v terms, got %T", ErrInvalidQuery, g.Name, g.TermType, term)
+	}
+	termPtr := reflect.New(g.TermType)
+	termPtr.Elem().Set(termValue)
+	c.Index = g
+	c.Term = term
+	c.TermBytes = reflectPack(g.TermPackFn, termPtr.Interface())
+	return
+}
+
+func TermExpr(c QueryCondition) QueryExpr {
+	return QueryExpr{Term: &c}
+}
+
+func AllOf(exprs ...QueryExpr) QueryExpr {
+	return QueryExpr{And: exprs}
+}
+
+func AnyOf(exprs ...QueryExpr) QueryExpr {
+	return QueryExpr{Or: exprs}
+}
+
+// WhereIndex restricts the results to the targets of term in the index.
+// Multiple conditions are intersected.
+//
+// indexInfo must be an *IndexInfo whose targets are the keys of the bucket, and
+// term must be of the index's term type.
+func (q *QueryBuilder[K, T]) WhereIndex(indexInfo any, term any) *QueryBuilder[K, T] {
+	c, err := QueryTerm(indexInfo, term)
+	if err != nil {
+		ChannelError(&q.Error, err)
+		return q
+	}
+	return q.Where(TermExpr(c))
+}
+
+// Where restricts the results to the targets matching expr; e.g. the ones
+// with either of two tags:
+//
+//	Where(vbolt.AnyOf(vbolt.TermExpr(goTag), vbolt.TermExpr(dbTag)))
+//
+// Conditions and expressions are intersected.
+func (q *QueryBuilder[K, T]) Where(expr QueryExpr) *QueryBuilder[K, T] {
+	keyType := reflect.TypeOf((*K)(nil)).Elem()
+	if err := _CheckExpr(&expr, keyType); err != nil {
+		ChannelError(&q.Error, fmt.Errorf("%w (bucket %s)", err, q.Bucket.Name))
+		return q
+	}
+	q.QueryPlan._Add(expr)
+	return q
+}
+
+// Filter drops records for which fn returns false
+func (q *QueryBuilder[K, T]) Filter(fn func(key K, item *T) bool) *QueryBuilder[K, T] {
+	q.Filters = append(q.Filters, fn)
+	return q
+}
+
+// SortBy sorts the results in memory
+func (q *QueryBuilder[K, T]) SortBy(less func(a, b *T) bool) *QueryBuilder[K, T] {
+	q.Less = less
+	return q
+}
+
+func (q *QueryBuilder[K, T]) Window(window Window) *QueryBuilder[K, T] {
+	q.Paging = window
+	return q
+}
+
+// checks that expr is well formed and that its indexes target keyType
+func _CheckExpr(expr *QueryExpr, keyType reflect.Type) error {
+	switch {
+	case expr.Term != nil:
+		if expr.Term.Index.TargetType != keyType {
+			return fmt.Errorf("%w: index %s does not target %v keys", ErrInvalidQuery, expr.Term.Index.Name, keyType)
+		}
+		return nil
+	case len(expr.And) > 0 || len(expr.Or) > 0:
+		if len(expr.And) > 0 && len(expr.Or) > 0 {
+			return fmt.Errorf("%w: expression with both and and or", ErrInvalidQuery)
+		}
+		for i := range expr.And {
+			if err := _CheckExpr(&expr.And[i], keyType); err != nil {
+				return err
+			}
+		}
+		for i := range expr.Or {
+			if err := _CheckExpr(&expr.Or[i], keyType); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: empty expression", ErrInvalidQuery)
+}
+
+// terms and intersections of terms become plain conditions, so they can be
+// counted and paged with cursors
+func (p *QueryPlan) _Add(expr QueryExpr) {
+	if expr.Term != nil {
+		p.Conditions = append(p.Conditions, *expr.Term)
+		return
+	}
+	if len(expr.Or) == 1 {
+		p._Add(expr.Or[0])
+		return
+	}
+	if len(expr.Or) > 0 {
+		p.Exprs = append(p.Exprs, expr)
+		return
+	}
+	for _, e := range expr.And {
+		p._Add(e)
+	}
+}
+
+// index of the condition with the fewest targets. when continuing from a
+// cursor, the condition that produced it is kept, even if the counts changed
+func _QueryDriver(tx *Tx, conditions []QueryCondition, cursor []byte) int {
+	if len(cursor) > 0 {
+		for i, c := range conditions {
+			if bytes.HasPrefix(cursor, _Concat(IndexTermPrefix, c.TermBytes)) {
+				return i
+			}
+		}
+	}
+	driver, best := 0, -1
+	for i, c := range conditions {
+		var count int
+		if bkt := TxRawBucket(tx, c.Index.Name); bkt != nil {
+			vpack.FromBytesInto(bkt.Get(_Concat(IndexCountPrefix, c.TermBytes)), &count, PackCountFn)
+		}
+		if best == -1 || count < best {
+			driver, best = i, count
+		}
+	}
+	return driver
+}
+
+// visits the raw targets of the condition's term in index order
+func _IterateCondition(tx *Tx, c *QueryCondition, window Window, visitFn func(key []byte, target []byte) bool) []byte {
+	bkt := TxRawBucket(tx, c.Index.Name)
+	return RawIterate(bkt, _Concat(IndexTermPrefix, c.TermBytes), window, func(key []byte, _ []byte) bool {
+		parts, ok := _SplitKey(key, c.Index.TermPackFn, c.Index.PriorityPackFn, c.Index.TargetPackFn)
+		if !ok {
+			return true
+		}
+		return visitFn(key, parts[2])
+	})
+}
+
+func _ConditionMatches(tx *Tx, c *QueryCondition, target []byte) bool {
+	return RawHasKey(TxRawBucket(tx, c.Index.Name), _Concat(IndexTargetPrefix, target, c.TermBytes))
+}
+
+// the stored count of a term; the smallest part of an intersection, and the
+// sum of the parts of a union
+func _ExprEstimate(tx *Tx, expr *QueryExpr) int {
+	switch {
+	case expr.Term != nil:
+		return _TermCountEstimate(tx, expr.Term.Index.Name, expr.Term.TermBytes)
+	case len(expr.And) > 0:
+		best := -1
+		for i := range expr.And {
+			if n := _ExprEstimate(tx, &expr.And[i]); best == -1 || n < best {
+				best = n
+			}
+		}
+		return best
+	}
+	sum := 0
+	for i := range expr.Or {
+		sum += _ExprEstimate(tx, &expr.Or[i])
+	}
+	return sum
+}
+
+func _ExprMatches(tx *Tx, expr *QueryExpr, target []byte) bool {
+	switch {
+	case expr.Term != nil:
+		return _ConditionMatches(tx, expr.Term, target)
+	case len(expr.And) > 0:
+		for i := range expr.And {
+			if !_ExprMatches(tx, &expr.And[i], target) {
+				return false
+			}
+		}
+		return true
+	}
+	for i := range expr.Or {
+		if _ExprMatches(tx, &expr.Or[i], target) {
+			return true
+		}
+	}
+	return false
+}
+
+// index of the part of an intersection with the fewest targets
+func _ExprDriver(tx *Tx, parts []QueryExpr) int {
+	driver, best := 0, -1
+	for i := range parts {
+		if n := _ExprEstimate(tx, &parts[i]); best == -1 || n < best {
+			driver, best = i, n
+		}
+	}
+	return driver
+}
+
+// visits the raw targets matching expr, without holding them in memory. An
+// intersection iterates its smallest part and checks the others; a union
+// iterates its parts in turn, skipping the targets an earlier part matched.
+// Returns false if visitFn stopped the iteration.
+func _ExprTargets(tx *Tx, expr *QueryExpr, direction IterationDirection, visitFn func(target []byte) bool) bool {
+	switch {
+	case expr.Term != nil:
+		done := true
+		_IterateCondition(tx, expr.Term, Window{Direction: direction}, func(_ []byte, target []byte) bool {
+			done = visitFn(target)
+			return done
+		})
+		return done
+
+	case len(expr.And) > 0:
+		driverIdx := _ExprDriver(tx, expr.And)
+		return _ExprTargets(tx, &expr.And[driverIdx], direction, func(target []byte) bool {
+			for i := range expr.And {
+				if i != driverIdx && !_ExprMatches(tx, &expr.And[i], target) {
+					return true
+				}
+			}
+			return visitFn(target)
+		})
+	}
+
+	visitPart := func(part int) bool {
+		return _ExprTargets(tx, &expr.Or[part], direction, func(target []byte) bool {
+			for i := 0; i < part; i++ {
+				if _ExprMatches(tx, &expr.Or[i], target) {
+					return true // visited (or will be) with the earlier part
+				}
+			}
+			return visitFn(target)
+		})
+	}
+	// in reverse, the parts are visited in reverse as well, so the order is
+	// exactly the reverse of the forward iteration
+	for n := range expr.Or {
+		part := n
+		if direction == IterateReverse {
+			part = len(expr.Or) - 1 - n
+		}
+		if !visitPart(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// visits the raw keys (and values, if available) of candidate records in
+// iteration order, starting from cursor. Returns the next cursor. Cursors are
+// only produced (and accepted) without expressions; with them, cursor is
+// always nil.
+func (p *QueryPlan) _Candidates(tx *Tx, cursor []byte, visitFn func(cursor []byte, key []byte, value []byte) bool) []byte {
+	window := Window{Cursor: cursor, Direction: p.Paging.Direction}
+
+	if len(p.Conditions) == 0 && len(p.Exprs) == 0 {
+		bkt := TxRawBucket(tx, p.BucketName)
+		return RawIterate(bkt, nil, window, func(key []byte, value []byte) bool {
+			return visitFn(key, key, value)
+		})
+	}
+
+	if len(p.Exprs) > 0 {
+		root := QueryExpr{And: append([]QueryExpr(nil), p.Exprs...)}
+		for i := range p.Conditions {
+			root.And = append(root.And, TermExpr(p.Conditions[i]))
+		}
+		_ExprTargets(tx, &root, window.Direction, func(target []byte) bool {
+			return visitFn(nil, target, nil)
+		})
+		return nil
+	}
+
+	driverIdx := _QueryDriver(tx, p.Conditions, cursor)
+	return _IterateCondition(tx, &p.Conditions[driverIdx], window, func(key []byte, target []byte) bool {
+		for i := range p.Conditions {
+			if i != driverIdx && !_ConditionMatches(tx, &p.Conditions[i], target) {
+				return true
+			}
+		}
+		return visitFn(key, target, nil)
+	})
+}
+
+// Fetch appends the matching records to out. Returns the cursor to pass in
+// the window to get the next page, or nil if there are no more results.
+func (q *QueryBuilder[K, T]) Fetch(tx *Tx, out *[]T) (next []byte, err error) {
+	if q.Error != nil {
+		return nil, q.Error
+	}
+	if q.Less != nil && len(q.Paging.Cursor) > 0 {
+		return nil, fmt.Errorf("%w: cursors can't be used with SortBy", ErrInvalidQuery)
+	}
+	if len(q.Exprs) > 0 && len(q.Paging.Cursor) > 0 {
+		return nil, fmt.Errorf("%w: cursors can't be used with expressions", ErrInvalidQuery)
+	}
+
+	bkt := TxRawBucket(tx, q.Bucket.Name)
+	if bkt == nil {
+		return nil, nil
+	}
+
+	offset := q.Paging.Offset
+	if len(q.Paging.Cursor) > 0 {
+		offset = 0 // as with Window in general, the cursor takes precedence
+	}
+
+	sorted := _TopK[T]{Better: q.Less}
+	if q.Paging.Limit > 0 {
+		sorted.N = offset + q.Paging.Limit
+	}
+	skipped := 0
+	count := 0
+	q._Candidates(tx, q.Paging.Cursor, func(cursor []byte, key []byte, value []byte) bool {
+		if value == nil {
+			if value = bkt.Get(key); value == nil {
+				return true // dangling index entry
+			}
+		}
+		var itemKey K
+		var item T
+		if !_DecodeRecord(q.Bucket, key, value, &itemKey, &item) {
+			return true
+		}
+		for _, filter := range q.Filters {
+			if !filter(itemKey, &item) {
+				return true
+			}
+		}
+
+		if q.Less != nil {
+			sorted.Add(item)
+			return true
+		}
+		if skipped < offset {
+			skipped++
+			return true
+		}
+		if q.Paging.Limit > 0 && count >= q.Paging.Limit {
+			// one more match than we need: this is where the next page starts
+			next = bytes.Clone(cursor)
+			return false
+		}
+		count++
+		*out = append(*out, item)
+		return true
+	})
+
+	if q.Less != nil {
+		*out = append(*out, sorted.Sorted(offset)...)
+	}
+	return
+}
@@ -0,0 +1,382 @@
+package vbolt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+/*
+	A small declarative query format for dashboards and support tooling.
+
+	Example:
+
+		{
+			"index": "posts_by_tag",
+			"bucket": "posts",
+			"where": {"and": [{"term": "go"}, {"or": [{"term": "db"}, {"term": "sql"}]}]},
+			"reverse": true,
+			"limit": 20
+		}
+
+	- With an index, "where" selects targets: a term matches its targets, "and"
+	  intersects, and "or" unions. If a bucket is also given, each target is
+	  used as a key to load the record from the bucket.
+	- Without an index, the bucket is scanned in key order.
+	- A term can name its own index, to combine terms from several indexes
+	  that have the same target type.
+
+	Terms are given as json and decoded into the term type of the index.
+
+	A Query compiles to a QueryPlan, and runs on the same engine as
+	QueryBuilder: the results are streamed in index order, "and" is driven by
+	its term with the fewest targets, and the window is applied while
+	iterating instead of after loading every match.
+*/
+
+type QueryFilter struct {
+	Index string          `json:"index,omitempty"` // defaults to the index of the query
+	Term  json.RawMessage `json:"term,omitempty"`
+	And   []QueryFilter   `json:"and,omitempty"`
+	Or    []QueryFilter   `json:"or,omitempty"`
+}
+
+type Query struct {
+	Bucket  string       `json:"bucket,omitempty"`
+	Index   string       `json:"index,omitempty"`
+	Where   *QueryFilter `json:"where,omitempty"`
+	Reverse bool         `json:"reverse,omitempty"`
+	Limit   int          `json:"limit,omitempty"`
+	Offset  int          `json:"offset,omitempty"`
+}
+
+type QueryResult struct {
+	Items []GenericItem `json:"items"`
+	Total int           `json:"total"` // number of matches before applying limit/offset; only for index queries
+
+	// counting stopped at QueryMaxCount; there are more than Total matches
+	TotalCapped bool `json:"total_capped,omitempty"`
+}
+
+// QueryMaxCount caps the matches an index query counts past its window to
+// fill QueryResult.Total, so a page over a popular term doesn't iterate the
+// whole index. 0 counts every match.
+var QueryMaxCount = 10000
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+// decodes a term given as json. for string terms, unquoted text is accepted
+// as is, which is what the text query syntax produces
+func _QueryDecodeTerm(g *GenericIndexInfo, raw json.RawMessage) (any, error) {
+	term := reflect.New(g.TermType)
+	err := json.Unmarshal(raw, term.Interface())
+	if err != nil && g.TermType.Kind() == reflect.String && len(raw) > 0 && raw[0] != '"' {
+		term.Elem().SetString(string(raw))
+		err = nil
+	}
+	return term.Interface(), err
+}
+
+// the condition for a term given as json, on the index registered under name
+func _QueryTermCondition(dbInfo *Info, name string, raw json.RawMessage) (c QueryCondition, err error) {
+	g, ok := AsGenericIndex(dbInfo.Infos[name])
+	if !ok {
+		return c, fmt.Errorf("%w: unknown index: %s", ErrInvalidQuery, name)
+	}
+	term, err := _QueryDecodeTerm(&g, raw)
+	if err != nil {
+		return c, fmt.Errorf("%w: term: %v", ErrInvalidQuery, err)
+	}
+	return QueryCondition{Index: g, Term: reflect.ValueOf(term).Elem().Interface(), TermBytes: reflectPack(g.TermPackFn, term)}, nil
+}
+
+// checks that the bucket (if any) is registered and keyed by the targets of
+// the plan's indexes, which must all agree
+func _CheckQueryPlan(dbInfo *Info, plan *QueryPlan) error {
+	var bucket GenericBucketInfo
+	if plan.BucketName != "" {
+		var ok bool
+		if bucket, ok = AsGenericBucket(dbInfo.Infos[plan.BucketName]); !ok {
+			return fmt.Errorf("%w: unknown bucket: %s", ErrInvalidQuery, plan.BucketName)
+		}
+	}
+	index := _QueryTargetIndex(plan)
+	if index == nil {
+		if plan.BucketName == "" {
+			return fmt.Errorf("%w: either bucket or index is required", ErrInvalidQuery)
+		}
+		return nil
+	}
+	for i := range plan.Exprs {
+		if err := _CheckExpr(&plan.Exprs[i], index.TargetType); err != nil {
+			return err
+		}
+	}
+	for _, c := range plan.Conditions {
+		if c.Index.TargetType != index.TargetType {
+			return fmt.Errorf("%w: index %s targets %v, not %v", ErrInvalidQuery, c.Index.Name, c.Index.TargetType, index.TargetType)
+		}
+	}
+	if plan.BucketName != "" && bucket.KeyType != index.TargetType {
+		return fmt.Errorf("%w: bucket %s is not keyed by the targets of the query's indexes", ErrInvalidQuery, bucket.Name)
+	}
+	return nil
+}
+
+// an index of the plan, to decode the targets with; nil for scans
+func _QueryTargetIndex(plan *QueryPlan) *GenericIndexInfo {
+	if len(plan.Conditions) > 0 {
+		return &plan.Conditions[0].Index
+	}
+	var find func(expr *QueryExpr) *GenericIndexInfo
+	find = func(expr *QueryExpr) *GenericIndexInfo {
+		if expr.Term != nil {
+			return &expr.Term.Index
+		}
+		for _, parts := range [][]QueryExpr{expr.And, expr.Or} {
+			for i := range parts {
+				if g := find(&parts[i]); g != nil {
+					return g
+				}
+			}
+		}
+		return nil
+	}
+	for i := range plan.Exprs {
+		if g := find(&plan.Exprs[i]); g != nil {
+			return g
+		}
+	}
+	return nil
+}
+
+// Compile resolves the names in the query against dbInfo and decodes its
+// terms, giving the plan that runs it
+func (query *Query) Compile(dbInfo *Info) (plan QueryPlan, err error) {
+	plan.BucketName = query.Bucket
+	plan.Paging = Window{Limit: query.Limit, Offset: query.Offset}
+	if query.Reverse {
+		plan.Paging.Direction = IterateReverse
+	}
+
+	if query.Where == nil {
+		if query.Index != "" {
+			return plan, fmt.Errorf("%w: index queries require a filter", ErrInvalidQuery)
+		}
+		return plan, _CheckQueryPlan(dbInfo, &plan)
+	}
+
+	var compile func(filter *QueryFilter) (QueryExpr, error)
+	compile = func(filter *QueryFilter) (expr QueryExpr, err error) {
+		switch {
+		case len(filter.Term) > 0:
+			name := filter.Index
+			if name == "" {
+				name = query.Index
+			}
+			if name == "" {
+				return expr, fmt.Errorf("%w: term without an index", ErrInvalidQuery)
+			}
+			c, err := _QueryTermCondition(dbInfo, name, filter.Term)
+			return TermExpr(c), err
+		case len(filter.And) > 0 && len(filter.Or) > 0:
+			return expr, fmt.Errorf("%w: filter with both and and or", ErrInvalidQuery)
+		case len(filter.And) > 0 || len(filter.Or) > 0:
+			list, combine := filter.And, AllOf
+			if len(filter.Or) > 0 {
+				list, combine = filter.Or, AnyOf
+			}
+			parts := make([]QueryExpr, len(list))
+			for i := range list {
+				if parts[i], err = compile(&list[i]); err != nil {
+					return
+				}
+			}
+			return combine(parts...), nil
+		}
+		return expr, fmt.Errorf("%w: empty filter", ErrInvalidQuery)
+	}
+	expr, err := compile(query.Where)
+	if err != nil {
+		return
+	}
+	plan._Add(expr)
+	return plan, _CheckQueryPlan(dbInfo, &plan)
+}
+
+// ExecQuery runs the query against the structures registered in dbInfo
+func ExecQuery(tx *Tx, dbInfo *Info, query *Query) (result QueryResult, err error) {
+	plan, err := query.Compile(dbInfo)
+	if err != nil {
+		return
+	}
+	return ExecPlan(tx, dbInfo, &plan)
+}
+
+// ExecPlan runs a plan (from ParseQuery or Query.Compile) against the
+// structures registered in dbInfo. With a bucket, each target is used as a
+// key to load the record; without one, the items only have keys.
+func ExecPlan(tx *Tx, dbInfo *Info, plan *QueryPlan) (result QueryResult, err error) {
+	result.Items = []GenericItem{}
+	result.Total, result.TotalCapped, err = _ExecPlanCore(tx, dbInfo, plan, func(item GenericItem) bool {
+		result.Items = append(result.Items, item)
+		return true
+	})
+	return
+}
+
+func _ExecQueryCore(tx *Tx, dbInfo *Info, query *Query, visitFn func(item GenericItem) bool) (total int, capped bool, err error) {
+	plan, err := query.Compile(dbInfo)
+	if err != nil {
+		return
+	}
+	return _ExecPlanCore(tx, dbInfo, &plan, visitFn)
+}
+
+// visits the items of the plan's window in order, until visitFn returns
+// false. For index queries, returns the number of matches ignoring the
+// window; counting them iterates the remaining targets (up to QueryMaxCount,
+// or the end of the window if that's further), but doesn't load their
+// records.
+func _ExecPlanCore(tx *Tx, dbInfo *Info, plan *QueryPlan, visitFn func(item GenericItem) bool) (total int, capped bool, err error) {
+	if err = _CheckQueryPlan(dbInfo, plan); err != nil {
+		return
+	}
+	var bucket GenericBucketInfo
+	var bkt *BBucket
+	if plan.BucketName != "" {
+		bucket, _ = AsGenericBucket(dbInfo.Infos[plan.BucketName])
+		bkt = TxRawBucket(tx, bucket.Name)
+	}
+	index := _QueryTargetIndex(plan)
+	if index == nil && bkt == nil {
+		return
+	}
+
+	window := plan.Paging
+	if len(window.Cursor) > 0 {
+		window.Offset = 0 // as with Window in general, the cursor takes precedence
+	}
+	matches := 0
+	visiting := true
+	plan._Candidates(tx, window.Cursor, func(_ []byte, key []byte, value []byte) bool {
+		matches++
+		if visiting && window.Limit > 0 && matches > window.Offset+window.Limit {
+			visiting = false
+			if index == nil {
+				return false
+			}
+		}
+		if !visiting {
+			// keep counting the index matches
+			if QueryMaxCount > 0 && matches > QueryMaxCount {
+				capped = true
+				return false
+			}
+			return true
+		}
+		if matches <= window.Offset {
+			return true
+		}
+		var item GenericItem
+		if index != nil {
+			item.Key = reflectUnpack(index.TargetPackFn, key)
+		} else {
+			item.Key = GenericUnpackKey(&bucket, key)
+		}
+		if bkt != nil {
+			if value == nil {
+				value = bkt.Get(key)
+			}
+			if value != nil {
+				item.Value = GenericUnpackValue(&bucket, value)
+			}
+		}
+		if !visitFn(item) {
+			visiting = false
+			return false
+		}
+		return true
+	})
+	if capped {
+		matches-- // the one that went over
+	}
+	if index != nil {
+		total = matches
+	}
+	return
+}
+
+// QueryMaxLimit caps the number of items QueryHandler returns per request
+var QueryMaxLimit = 1000
+
+// QueryHandler serves queries against db. The query is taken from the request
+// body (POST), from the "q" url parameter (GET), or in the text syntax (see
+// ParseQuery) from the "text" url parameter (GET). The result is returned as json.
+// With the "explain" url parameter, the plan is returned instead.
+//
+// Pages are capped at QueryMaxLimit items, and the total at QueryMaxCount
+// matches.
+func QueryHandler(db *DB, dbInfo *Info) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var query Query
+		var plan QueryPlan
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+			if text := r.URL.Query().Get("text"); text != "" {
+				plan, err = ParseQuery(dbInfo, text)
+			} else if err = json.Unmarshal([]byte(r.URL.Query().Get("q")), &query); err == nil {
+				plan, err = query.Compile(dbInfo)
+			}
+		case http.MethodPost:
+			if err = json.NewDecoder(r.Body).Decode(&query); err == nil {
+				plan, err = query.Compile(dbInfo)
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			_WriteJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		if plan.Paging.Limit <= 0 || plan.Paging.Limit > QueryMaxLimit {
+			plan.Paging.Limit = QueryMaxLimit
+		}
+
+		var result any
+		WithReadTx(db, func(tx *Tx) {
+			if r.URL.Query().Get("explain") != "" {
+				result = plan.Explain(tx)
+			} else {
+				result, err = ExecPlan(tx, dbInfo, &plan)
+			}
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrInvalidQuery) {
+				status = http.StatusBadRequest
+			}
+			_WriteJSONError(w, status, err)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err = json.NewEncoder(&buf).Encode(result); err != nil {
+			_WriteJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(buf.Bytes())
+	})
+}
+
+func _WriteJSONError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
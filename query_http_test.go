@@ -0,0 +1,157 @@
+package vbolt_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestQuery(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+	tags := vbolt.Index(&dbInfo, "post_tags", vpack.String, vpack.FInt)
+
+	db := vbolttest.NewTestDB(t, &dbInfo)
+	vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+		for id, terms := range map[int][]string{
+			1: {"go", "db"},
+			2: {"go"},
+			3: {"db"},
+			4: {"go", "sql"},
+			5: {"sql"},
+			6: {"go", "db"},
+		} {
+			content := fmt.Sprintf("post %d", id)
+			vbolt.Write(tx, posts, id, &content)
+			vbolt.SetTargetTermsPlain(tx, tags, id, terms)
+		}
+	})
+	handler := vbolt.QueryHandler(db, &dbInfo)
+
+	term := func(term string) vbolt.QueryFilter {
+		return vbolt.QueryFilter{Term: json.RawMessage(`"` + term + `"`)}
+	}
+
+	// a union visits its parts one after the other
+	cases := []struct {
+		name     string
+		query    vbolt.Query
+		expected []int
+		total    int
+	}{
+		{"term", vbolt.Query{Where: &vbolt.QueryFilter{And: []vbolt.QueryFilter{term("go")}}}, []int{1, 2, 4, 6}, 4},
+		{"and", vbolt.Query{Where: &vbolt.QueryFilter{And: []vbolt.QueryFilter{term("go"), term("db")}}}, []int{1, 6}, 2},
+		{"or", vbolt.Query{Where: &vbolt.QueryFilter{Or: []vbolt.QueryFilter{term("db"), term("sql")}}}, []int{1, 3, 6, 4, 5}, 5},
+		{"and of or", vbolt.Query{Where: &vbolt.QueryFilter{And: []vbolt.QueryFilter{
+			term("go"),
+			{Or: []vbolt.QueryFilter{term("db"), term("sql")}},
+		}}}, []int{1, 4, 6}, 3},
+		{"reverse", vbolt.Query{Where: &vbolt.QueryFilter{And: []vbolt.QueryFilter{term("go")}}, Reverse: true}, []int{6, 4, 2, 1}, 4},
+		{"limit", vbolt.Query{Where: &vbolt.QueryFilter{And: []vbolt.QueryFilter{term("go")}}, Limit: 2}, []int{1, 2}, 4},
+		{"offset", vbolt.Query{Where: &vbolt.QueryFilter{And: []vbolt.QueryFilter{term("go")}}, Limit: 2, Offset: 1}, []int{2, 4}, 4},
+		{"reverse offset", vbolt.Query{Where: &vbolt.QueryFilter{And: []vbolt.QueryFilter{term("go")}}, Limit: 2, Offset: 1, Reverse: true}, []int{4, 2}, 4},
+		{"or offset", vbolt.Query{Where: &vbolt.QueryFilter{Or: []vbolt.QueryFilter{term("db"), term("sql")}}, Offset: 3}, []int{4, 5}, 5},
+		{"offset past the end", vbolt.Query{Where: &vbolt.QueryFilter{And: []vbolt.QueryFilter{term("go")}}, Offset: 10}, []int{}, 4},
+		// scans don't count
+		{"scan", vbolt.Query{Limit: 3, Reverse: true}, []int{6, 5, 4}, 0},
+	}
+
+	for _, c := range cases {
+		c.query.Bucket = "posts"
+		if c.query.Where != nil {
+			c.query.Index = "post_tags"
+		}
+		t.Run(c.name, func(t *testing.T) {
+			var keys []int
+			var result vbolt.QueryResult
+			var err error
+			vbolttest.View(db, func(tx *vbolt.Tx) {
+				result, err = vbolt.ExecQuery(tx, &dbInfo, &c.query)
+			})
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			for _, item := range result.Items {
+				key := *item.Key.(*int)
+				keys = append(keys, key)
+				if content := *item.Value.(*string); content != fmt.Sprintf("post %d", key) {
+					t.Errorf("item %d: loaded %q", key, content)
+				}
+			}
+			if fmt.Sprint(keys) != fmt.Sprint(c.expected) || result.Total != c.total {
+				t.Errorf("ExecQuery: expected %v (total %d), found %v (total %d)", c.expected, c.total, keys, result.Total)
+			}
+
+			body, _ := json.Marshal(c.query)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("handler: status %d: %s", rec.Code, rec.Body.String())
+			}
+			var response struct {
+				Items []struct {
+					Key   int
+					Value string
+				} `json:"items"`
+				Total int `json:"total"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("handler: bad response: %v", err)
+			}
+			keys = nil
+			for _, item := range response.Items {
+				keys = append(keys, item.Key)
+			}
+			if fmt.Sprint(keys) != fmt.Sprint(c.expected) || response.Total != c.total {
+				t.Errorf("handler: expected %v (total %d), found %v (total %d)", c.expected, c.total, keys, response.Total)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader([]byte(`{"index": "nope", "where": {"term": "go"}}`))))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, found %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("count cap", func(t *testing.T) {
+		defer func(prev int) { vbolt.QueryMaxCount = prev }(vbolt.QueryMaxCount)
+
+		capCases := []struct {
+			maxCount int
+			offset   int
+			total    int
+			capped   bool
+		}{
+			{0, 0, 4, false},
+			{2, 0, 2, true},
+			{4, 0, 4, false},
+			{2, 2, 3, true}, // the window is always counted
+		}
+		for _, c := range capCases {
+			vbolt.QueryMaxCount = c.maxCount
+			query := vbolt.Query{Bucket: "posts", Index: "post_tags", Where: &vbolt.QueryFilter{And: []vbolt.QueryFilter{term("go")}}, Limit: 1, Offset: c.offset}
+			var result vbolt.QueryResult
+			var err error
+			vbolttest.View(db, func(tx *vbolt.Tx) {
+				result, err = vbolt.ExecQuery(tx, &dbInfo, &query)
+			})
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if len(result.Items) != 1 || result.Total != c.total || result.TotalCapped != c.capped {
+				t.Errorf("max count %d, offset %d: expected 1 item, total %d (capped %v), found %d items, total %d (capped %v)",
+					c.maxCount, c.offset, c.total, c.capped, len(result.Items), result.Total, result.TotalCapped)
+			}
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package vbolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+	A text syntax for queries, for typing ad-hoc queries in tools and urls:
+
+		FROM posts idx:tags=go AND (idx:author=12 OR idx:author=15) LIMIT 20 DESC
+
+	- idx:NAME=VALUE matches the targets of VALUE in the index NAME. Values
+	  are json (numbers, "quoted strings"); anything else is taken as a string.
+	- AND binds tighter than OR; parentheses group.
+	- FROM names the bucket to load the targets from. Without any terms, the
+	  bucket is scanned.
+	- LIMIT n, OFFSET n, ASC and DESC (i.e. reverse) control the results.
+
+	Keywords are case insensitive.
+
+	The text is compiled to the QueryPlan that QueryBuilder runs on, with the
+	index names resolved against the Info.
+*/
+
+// ParseQuery parses the text syntax into a plan for ExecPlan
+func ParseQuery(dbInfo *Info, text string) (plan QueryPlan, err error) {
+	tokens, err := _TokenizeQuery(text)
+	if err != nil {
+		return
+	}
+	p := _QueryParser{Tokens: tokens, DBInfo: dbInfo}
+	hasWhere := false
+
+	for !p.Done() {
+		tok := p.Peek()
+		switch strings.ToUpper(tok) {
+		case "FROM":
+			p.Next()
+			if p.Done() {
+				return plan, fmt.Errorf("%w: FROM without a bucket name", ErrInvalidQuery)
+			}
+			plan.BucketName = p.Next()
+		case "LIMIT", "OFFSET":
+			p.Next()
+			n, convErr := strconv.Atoi(p.Next())
+			if convErr != nil || n < 0 {
+				return plan, fmt.Errorf("%w: %s expects a non-negative number", ErrInvalidQuery, strings.ToUpper(tok))
+			}
+			if strings.ToUpper(tok) == "LIMIT" {
+				plan.Paging.Limit = n
+			} else {
+				plan.Paging.Offset = n
+			}
+		case "ASC":
+			p.Next()
+			plan.Paging.Direction = IterateRegular
+		case "DESC":
+			p.Next()
+			plan.Paging.Direction = IterateReverse
+		default:
+			if hasWhere {
+				return plan, fmt.Errorf("%w: unexpected %q", ErrInvalidQuery, tok)
+			}
+			var expr QueryExpr
+			if expr, err = p.ParseOr(); err != nil {
+				return
+			}
+			plan._Add(expr)
+			hasWhere = true
+		}
+	}
+	err = _CheckQueryPlan(dbInfo, &plan)
+	return
+}
+
+func _TokenizeQuery(text string) (tokens []string, err error) {
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '"':
+			// quoted strings are kept (with the quotes) as part of the current token
+			j := i + 1
+			for ; j < len(text) && text[j] != '"'; j++ {
+				if text[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(text) {
+				return nil, fmt.Errorf("%w: unterminated string", ErrInvalidQuery)
+			}
+			current.WriteString(text[i : j+1])
+			i = j
+		case c == '(' || c == ')':
+			flush()
+			tokens = append(tokens, string(c))
+		case unicode.IsSpace(rune(c)):
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return
+}
+
+type _QueryParser struct {
+	Tokens []string
+	Pos    int
+	DBInfo *Info
+}
+
+func (p *_QueryParser) Done() bool {
+	return p.Pos >= len(p.Tokens)
+}
+
+func (p *_QueryParser) Peek() string {
+	if p.Done() {
+		return ""
+	}
+	return p.Tokens[p.Pos]
+}
+
+func (p *_QueryParser) Next() string {
+	tok := p.Peek()
+	p.Pos++
+	return tok
+}
+
+func (p *_QueryParser) ParseOr() (QueryExpr, error) {
+	first, err := p.ParseAnd()
+	if err != nil {
+		return first, err
+	}
+	exprs := []QueryExpr{first}
+	for strings.EqualFold(p.Peek(), "OR") {
+		p.Next()
+		next, err := p.ParseAnd()
+		if err != nil {
+			return next, err
+		}
+		exprs = append(exprs, next)
+	}
+	if len(exprs) == 1 {
+		return first, nil
+	}
+	return AnyOf(exprs...), nil
+}
+
+func (p *_QueryParser) ParseAnd() (QueryExpr, error) {
+	first, err := p.ParsePrimary()
+	if err != nil {
+		return first, err
+	}
+	exprs := []QueryExpr{first}
+	for strings.EqualFold(p.Peek(), "AND") {
+		p.Next()
+		next, err := p.ParsePrimary()
+		if err != nil {
+			return next, err
+		}
+		exprs = append(exprs, next)
+	}
+	if len(exprs) == 1 {
+		return first, nil
+	}
+	return AllOf(exprs...), nil
+}
+
+func (p *_QueryParser) ParsePrimary() (expr QueryExpr, err error) {
+	tok := p.Next()
+	if tok == "(" {
+		if expr, err = p.ParseOr(); err != nil {
+			return
+		}
+		if p.Next() != ")" {
+			return expr, fmt.Errorf("%w: missing )", ErrInvalidQuery)
+		}
+		return
+	}
+
+	name, value, ok := strings.Cut(strings.TrimPrefix(tok, "idx:"), "=")
+	if !strings.HasPrefix(tok, "idx:") || !ok || name == "" {
+		return expr, fmt.Errorf("%w: expected idx:NAME=VALUE, got %q", ErrInvalidQuery, tok)
+	}
+	raw := json.RawMessage(value)
+	if !json.Valid(raw) {
+		raw, _ = json.Marshal(value)
+	}
+	c, err := _QueryTermCondition(p.DBInfo, name, raw)
+	return TermExpr(c), err
+}
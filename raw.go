@@ -2,6 +2,7 @@ package vbolt
 
 import (
 	"bytes"
+	"time"
 
 	"go.hasen.dev/generic"
 	"go.hasen.dev/vpack"
@@ -20,18 +21,50 @@ func RawHasKey(bkt *BBucket, key []byte) bool {
 
 // Put an entry
 func RawMustPut(bkt *BBucket, key []byte, value []byte) {
-	generic.MustOK(bkt.Put(key, value))
+	generic.MustOK(RawPut(bkt, key, value))
+}
+
+// RawPut is like RawMustPut but returns the error instead of panicking.
+//
+// The bucket's name isn't known here, so it invalidates every cached query
+// result of the database (see QueryCache). It's journaled (see EnableJournal)
+// if the bucket was opened with TxRawBucket.
+func RawPut(bkt *BBucket, key []byte, value []byte) error {
+	if bkt == nil {
+		return ErrBucketMissing
+	}
+	_BumpGeneration(bkt.Tx(), _rawWrites)
+	if name, ok := _JournalName(bkt); ok {
+		return _Put(bkt.Tx(), bkt, name, key, value)
+	}
+	return bkt.Put(key, value)
 }
 
 func RawNextSequence(bucket *BBucket) uint64 {
-	return generic.Must(bucket.NextSequence())
+	return generic.Must(RawNextSequenceE(bucket))
+}
+
+// RawNextSequenceE is like RawNextSequence but returns the error instead of panicking
+func RawNextSequenceE(bucket *BBucket) (uint64, error) {
+	if bucket == nil {
+		return 0, ErrBucketMissing
+	}
+	seq, err := bucket.NextSequence()
+	if name, ok := _JournalName(bucket); ok && err == nil {
+		_JournalOp(bucket.Tx(), JournalOp{Kind: JournalSequence, Bucket: name, Seq: seq})
+	}
+	return seq, err
 }
 
 func RawSetSequenceCorrectly(bucket *BBucket) {
 	c := bucket.Cursor()
 	lastKeyBytes, _ := c.Last()
 	seq := vpack.FromBytes(lastKeyBytes, vpack.FUInt64)
-	bucket.SetSequence(*seq)
+	if name, ok := _JournalName(bucket); ok {
+		_SetSequence(bucket.Tx(), bucket, name, *seq)
+	} else {
+		bucket.SetSequence(*seq)
+	}
 }
 
 type IterationDirection uint8
@@ -93,19 +126,50 @@ func _CursorStep(c *Cursor, direction IterationDirection) (k []byte, v []byte) {
 
 type _RawIterationParams struct {
 	Prefix []byte
+	Name   string // for logging slow iterations
+
+	// optional bounds within the prefix, for range scans
+	Lower []byte // inclusive
+	Upper []byte // exclusive
+
 	Window
 }
 
+func (params *_RawIterationParams) _Contains(key []byte) bool {
+	if !bytes.HasPrefix(key, params.Prefix) {
+		return false
+	}
+	if params.Lower != nil && bytes.Compare(key, params.Lower) < 0 {
+		return false
+	}
+	if params.Upper != nil && bytes.Compare(key, params.Upper) >= 0 {
+		return false
+	}
+	return true
+}
+
+func _CursorStartPosForParams(c *Cursor, params *_RawIterationParams) (k []byte, v []byte) {
+	if len(params.Cursor) > 0 {
+		return _CursorStartPosForPrefix(c, params.Cursor, params.Direction)
+	}
+	if params.Direction == IterateRegular && params.Lower != nil {
+		return c.Seek(params.Lower)
+	}
+	if params.Direction == IterateReverse && params.Upper != nil {
+		if k, _ = c.Seek(params.Upper); k == nil {
+			return c.Last()
+		}
+		return c.Prev()
+	}
+	return _CursorStartPosForPrefix(c, params.Prefix, params.Direction)
+}
+
 // _RawIterateCore is the core function that iterates over a bucket and calls the visitFn for each key/value pair
 // returns the "next" key (if any) that would have been visited had the visitor not returned false
 // returns nil if the visitor exhausted all the keys that have the given prefix
 func _RawIterateCore(bkt *BBucket, window _RawIterationParams, visitFn func(key []byte, value []byte) bool) []byte {
 	crsr := bkt.Cursor()
-	start := window.Prefix
-	if len(window.Cursor) > 0 {
-		start = window.Cursor
-	}
-	key, value := _CursorStartPosForPrefix(crsr, start, window.Direction)
+	key, value := _CursorStartPosForParams(crsr, &window)
 
 	if window.Offset > 0 {
 		for i := 0; i < window.Offset; i++ {
@@ -117,7 +181,11 @@ func _RawIterateCore(bkt *BBucket, window _RawIterationParams, visitFn func(key
 	}
 
 	count := 0
-	for key != nil && bytes.HasPrefix(key, window.Prefix) {
+	if SlowOps.Iteration > 0 || SlowOps.IterationKeys > 0 {
+		start := time.Now()
+		defer func() { _LogSlowIteration(window.Name, window.Prefix, start, window.Offset+count) }()
+	}
+	for key != nil && window._Contains(key) {
 		if !visitFn(key, value) {
 			break
 		}
@@ -133,9 +201,37 @@ func _RawIterateCore(bkt *BBucket, window _RawIterationParams, visitFn func(key
 	if key != nil {
 		nextKey, _ = _CursorStep(crsr, window.Direction)
 	}
-	if !bytes.HasPrefix(nextKey, window.Prefix) {
+	if nextKey != nil && !window._Contains(nextKey) {
 		nextKey = nil
 	}
 
 	return nextKey
 }
+
+// Continuation is returned by the iteration functions: it's the next key that
+// would have been visited, or nil if the iteration went through everything.
+// To continue, pass it as the window's cursor (see Resume).
+type Continuation []byte
+
+func (c Continuation) Done() bool {
+	return len(c) == 0
+}
+
+// Resume returns the window with its cursor set to continue from c
+func (c Continuation) Resume(window Window) Window {
+	window.Cursor = c
+	window.Offset = 0
+	return window
+}
+
+// RawIterate visits the keys that start with prefix, according to the window.
+// Returns the key to pass as window.Cursor to continue the iteration, or nil if done.
+func RawIterate(bkt *BBucket, prefix []byte, window Window, visitFn func(key []byte, value []byte) bool) Continuation {
+	if bkt == nil {
+		return nil
+	}
+	var iterParams _RawIterationParams
+	iterParams.Prefix = prefix
+	iterParams.Window = window
+	return _RawIterateCore(bkt, iterParams, visitFn)
+}
@@ -0,0 +1,47 @@
+package vbolt
+
+import (
+	"errors"
+	"fmt"
+
+	"go.hasen.dev/vpack"
+)
+
+// RecordError wraps errors from operations on a specific record, so logs
+// say which record broke
+type RecordError struct {
+	Op        string // "write", "delete", "decode", "set terms"
+	Structure string // bucket or index name
+	Key       any    // the typed key (or target), or its hex encoding if it can't be decoded
+	Err       error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("vbolt: %s %s[%v]: %v", e.Op, e.Structure, e.Key, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// wraps err unless it's nil or already says which record it's about
+func _RecordErr(op string, structure string, key any, err error) error {
+	if err == nil {
+		return nil
+	}
+	var recordErr *RecordError
+	var constraintErr *ConstraintError
+	if errors.As(err, &recordErr) || errors.As(err, &constraintErr) {
+		return err
+	}
+	return &RecordError{Op: op, Structure: structure, Key: key, Err: err}
+}
+
+// the decoded key if it decodes cleanly, otherwise its hex encoding
+func _PrintableKey[K any](fn vpack.PackFn[K], raw []byte) any {
+	var key K
+	if _DecodeInto(raw, &key, fn) == nil {
+		return key
+	}
+	return fmt.Sprintf("%x", raw)
+}
@@ -0,0 +1,84 @@
+package vbolt
+
+import (
+	"bytes"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	Indexes and collections keep a count per term (or per collection key) that
+	is updated incrementally on every write. If the counts drift (because of
+	bugs in older versions, or writes done outside of the api), these functions
+	rebuild them from the actual entries.
+
+	They work in batches of separate write transactions, so they should be run
+	when nothing else is writing to the index or collection, e.g. from
+	ApplyDBProcess at startup.
+*/
+
+// RecountIndexTerms rebuilds all the term counts of the index from its term/target pairs
+func RecountIndexTerms[K, T, P comparable](db *DB, indexInfo *IndexInfo[K, T, P], batchSize int) {
+	_RecountPrefix(db, indexInfo.Name, IndexTermPrefix, IndexCountPrefix, batchSize, func(key []byte) []byte {
+		term, _, _ := _ReadTermTargetPriority(indexInfo, key)
+		return _TermCountKey(indexInfo, &term)
+	})
+}
+
+// RecountCollections rebuilds the item counts of all the keys in the collection
+func RecountCollections[K, O, I any](db *DB, info *CollectionInfo[K, O, I], batchSize int) {
+	_RecountPrefix(db, info.Name, CKeyPrefix, CCountPrefix, batchSize, func(key []byte) []byte {
+		cKey, _, _ := _ReadKeyOrderItem(info, key)
+		return _CCountKey(info, cKey)
+	})
+}
+
+// deletes all the count keys, then counts the entries (which are sorted by
+// what they are counted by), writing the counts as they're completed
+func _RecountPrefix(db *DB, name string, entryPrefix byte, countPrefix byte, batchSize int, countKeyFn func(entryKey []byte) []byte) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	for done := false; !done; {
+		WithWriteTx(db, func(tx *Tx) {
+			bkt := TxRawBucket(tx, name)
+			var keys [][]byte
+			RawIterate(bkt, []byte{countPrefix}, Window{Limit: batchSize}, func(key []byte, value []byte) bool {
+				keys = append(keys, bytes.Clone(key))
+				return true
+			})
+			for _, key := range keys {
+				_Del(tx, bkt, name, key)
+			}
+			_MarkDirty(tx, name)
+			done = len(keys) < batchSize
+			TxCommit(tx)
+		})
+	}
+
+	var countKey []byte
+	var count int
+	var cursor []byte
+	for done := false; !done; {
+		WithWriteTx(db, func(tx *Tx) {
+			bkt := TxRawBucket(tx, name)
+			counts := make(map[string]int)
+			cursor = RawIterate(bkt, []byte{entryPrefix}, Window{Limit: batchSize, Cursor: cursor}, func(key []byte, value []byte) bool {
+				if ck := countKeyFn(key); !bytes.Equal(ck, countKey) {
+					countKey, count = ck, 0
+				}
+				count++
+				counts[string(countKey)] = count
+				return true
+			})
+			// bolt cursors don't like writes during iteration, so write afterwards.
+			// the last count may be partial; the next batch overwrites it
+			for key, count := range counts {
+				RawMustPut(bkt, []byte(key), vpack.ToBytes(&count, PackCountFn))
+			}
+			done = cursor == nil
+			TxCommit(tx)
+		})
+	}
+}
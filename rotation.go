@@ -0,0 +1,256 @@
+package vbolt
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	Rotation is meant for append-only buckets (logs, events, audit trails)
+	where the keys are monotonically increasing (e.g. from NextIntId).
+
+	When the live bucket grows past the policy thresholds, its contents are
+	sealed into an archive file (using the backup format) and the bucket is
+	truncated. The list of sealed segments is kept in a manifest inside the
+	database, so IterateRotated can read across the sealed segments and the
+	live bucket as if nothing happened.
+
+	The bucket sequence is preserved across truncation so new ids keep
+	increasing.
+*/
+
+type RotationPolicy struct {
+	MaxItems   int           // 0 means no limit on item count
+	MaxAge     time.Duration // 0 means no limit on age of the live segment
+	ArchiveDir string
+}
+
+type SealedSegment struct {
+	Path     string
+	FirstKey []byte
+	LastKey  []byte
+	Count    int
+	SealedAt time.Time
+}
+
+type RotationManifest struct {
+	LiveSince time.Time
+	Segments  []SealedSegment
+}
+
+func PackSealedSegment(self *SealedSegment, buf *vpack.Buffer) {
+	vpack.Version(1, buf)
+	vpack.String(&self.Path, buf)
+	vpack.Bytes(&self.FirstKey, buf)
+	vpack.Bytes(&self.LastKey, buf)
+	vpack.Int(&self.Count, buf)
+	vpack.UnixTime(&self.SealedAt, buf)
+}
+
+func PackRotationManifest(self *RotationManifest, buf *vpack.Buffer) {
+	vpack.Version(1, buf)
+	vpack.UnixTime(&self.LiveSince, buf)
+	vpack.Slice(&self.Segments, PackSealedSegment, buf)
+}
+
+// system bucket: bucket name => rotation manifest
+var RotationManifests = Bucket(&dbInfo, "rotation", vpack.StringZ, PackRotationManifest)
+
+func _ShouldRotate(policy RotationPolicy, manifest *RotationManifest, count int, now time.Time) bool {
+	if count == 0 {
+		return false
+	}
+	if policy.MaxItems > 0 && count >= policy.MaxItems {
+		return true
+	}
+	if policy.MaxAge > 0 && now.Sub(manifest.LiveSince) >= policy.MaxAge {
+		return true
+	}
+	return false
+}
+
+// RotateBucket seals the live bucket into a new archive segment if it exceeds
+// the thresholds given by policy. Returns true if a rotation took place.
+//
+// Call it periodically (e.g. from a background goroutine or after writes).
+func RotateBucket[K, T any](db *DB, info *BucketInfo[K, T], policy RotationPolicy) (rotated bool, err error) {
+	tx := WriteTx(db)
+	defer TxClose(tx)
+
+	now := Now()
+	var manifest RotationManifest
+	Read(tx, RotationManifests, info.Name, &manifest)
+	if manifest.LiveSince.IsZero() {
+		// first time we see this bucket; start counting its age from now
+		manifest.LiveSince = now
+		Write(tx, RotationManifests, info.Name, &manifest)
+		return false, TxCommitE(tx)
+	}
+
+	bkt := TxRawBucket(tx, info.Name)
+	if !_ShouldRotate(policy, &manifest, _CountUpTo(bkt, policy.MaxItems), now) {
+		return false, nil
+	}
+
+	// a file at segmentPath that's not in the manifest is left over from a
+	// rotation that didn't commit, and is replaced
+	segmentPath := filepath.Join(policy.ArchiveDir, fmt.Sprintf("%s.%06d.vbak", info.Name, len(manifest.Segments)+1))
+	segment, err := _SealSegment(bkt, info.Name, segmentPath)
+	if err != nil {
+		return false, err
+	}
+	segment.SealedAt = now
+	defer func() {
+		if err != nil {
+			os.Remove(segmentPath)
+		}
+	}()
+
+	// truncate, but keep the sequence so ids continue to increase
+	seq := bkt.Sequence()
+	if err = _DropBucket(tx, info.Name); err != nil {
+		return false, err
+	}
+	_MarkDirty(tx, info.Name)
+	bkt = TxRawBucket(tx, info.Name)
+	if err = _SetSequence(tx, bkt, info.Name, seq); err != nil {
+		return false, err
+	}
+
+	manifest.Segments = append(manifest.Segments, segment)
+	manifest.LiveSince = now
+	Write(tx, RotationManifests, info.Name, &manifest)
+
+	if err = TxCommitE(tx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// counts the items of bkt, stopping at limit, so checking the policy doesn't
+// scan the whole bucket; with no limit, only checks that it's not empty
+func _CountUpTo(bkt *BBucket, limit int) (count int) {
+	if limit <= 0 {
+		limit = 1
+	}
+	crsr := bkt.Cursor()
+	for key, _ := crsr.First(); key != nil && count < limit; key, _ = crsr.Next() {
+		count++
+	}
+	return
+}
+
+// writes the content of the bucket to the given path in the backup format,
+// through a temp file that's synced and renamed, so path is never half written
+func _SealSegment(bkt *BBucket, name string, path string) (segment SealedSegment, err error) {
+	segment.Path = path
+
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer func() {
+		ChannelError(&err, file.Close())
+		if err == nil {
+			err = os.Rename(tmpPath, path)
+		}
+		if err != nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	var backup _BackupBuilder
+	backup.Output = bufio.NewWriter(file)
+	_BackupWriteBucketHeader(&backup, []byte(name))
+	bkt.ForEach(func(key []byte, value []byte) error {
+		if segment.FirstKey == nil {
+			segment.FirstKey = bytes.Clone(key)
+		}
+		segment.LastKey = key
+		segment.Count++
+		_BackupWriteItem(&backup, key, value)
+		return backup.Error
+	})
+	segment.LastKey = bytes.Clone(segment.LastKey)
+
+	_BackupFinish(&backup)
+	if backup.Error == nil {
+		ChannelError(&backup.Error, file.Sync())
+	}
+	err = backup.Error
+	return
+}
+
+// iterates the raw items stored in a sealed segment file
+func _ReadSegment(path string, visitFn func(key []byte, value []byte) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	errStop := errors.New("stop")
+	err = ReadBackup(file, func(rec BackupRecord) error {
+		if rec.Kind == ITEM_HEADER && !visitFn(rec.Key, rec.Value) {
+			return errStop
+		}
+		return nil
+	})
+	if err == errStop {
+		return nil
+	}
+	return err
+}
+
+// IterateRotated visits items with keys >= startKey, first from the sealed
+// segments (oldest first), then from the live bucket.
+func IterateRotated[K, T any](tx *Tx, info *BucketInfo[K, T], startKey K, visitFn func(key K, item T) bool) error {
+	start := vpack.ToBytes(&startKey, info.KeyPackFn)
+
+	var manifest RotationManifest
+	Read(tx, RotationManifests, info.Name, &manifest)
+
+	stopped := false
+	visitRaw := func(key []byte, value []byte) bool {
+		if bytes.Compare(key, start) < 0 {
+			return true
+		}
+		var itemKey K
+		var item T
+		vpack.FromBytesInto(key, &itemKey, info.KeyPackFn)
+		vpack.FromBytesInto(value, &item, info.ValuePackFn)
+		stopped = !visitFn(itemKey, item)
+		return !stopped
+	}
+
+	for _, segment := range manifest.Segments {
+		if bytes.Compare(segment.LastKey, start) < 0 {
+			continue
+		}
+		if err := _ReadSegment(segment.Path, visitRaw); err != nil {
+			return err
+		}
+		if stopped {
+			return nil
+		}
+	}
+
+	bkt := TxRawBucket(tx, info.Name)
+	if bkt == nil {
+		return nil
+	}
+	var iterParams _RawIterationParams
+	iterParams.Prefix = []byte{}
+	iterParams.Cursor = start
+	iterParams.Direction = IterateRegular
+	_RawIterateCore(bkt, iterParams, visitRaw)
+	return nil
+}
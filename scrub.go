@@ -0,0 +1,125 @@
+package vbolt
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	Scrubbing redacts records (emails, tokens, etc) on their way out of the
+	database, so a copy of production data can be shared with developers:
+
+		Users.Scrub = func(id int, user *User) {
+			user.Email = fmt.Sprintf("user%d@example.com", id)
+			user.PasswordHash = nil
+		}
+
+	BackupScrubbed and ExportScrubbed apply the Scrub func of every bucket
+	registered in dbInfo; everything else (other buckets, indexes, collections)
+	is copied as is, so the structure is preserved.
+
+	Note that index terms and collection keys are copied as is too: if an index
+	is keyed by a scrubbed field, leave it out and rebuild it after loading.
+*/
+
+type _RawScrubber interface {
+	_ScrubRaw(key []byte, value []byte) []byte
+}
+
+func (info *BucketInfo[K, T]) _ScrubRaw(key []byte, value []byte) []byte {
+	if info.Scrub == nil {
+		return value
+	}
+	var id K
+	var item T
+	vpack.FromBytesInto(key, &id, info.KeyPackFn)
+	vpack.FromBytesInto(value, &item, info.ValuePackFn)
+	info.Scrub(id, &item)
+	return vpack.ToBytes(&item, info.ValuePackFn)
+}
+
+// returns nil if the bucket has nothing to scrub
+func _Scrubber(dbInfo *Info, name string) func(key []byte, value []byte) []byte {
+	if dbInfo == nil {
+		return nil
+	}
+	scrubber, ok := dbInfo.Infos[name].(_RawScrubber)
+	if !ok {
+		return nil
+	}
+	return scrubber._ScrubRaw
+}
+
+// BackupScrubbed is BackupBuckets, with each record passed through the Scrub
+// func of its bucket (as registered in dbInfo)
+func BackupScrubbed(db *DB, dbInfo *Info, out io.Writer, bucketNames ...string) error {
+	return _BackupBucketsCore(db, out, dbInfo, bucketNames)
+}
+
+// ExportScrubbed writes a copy of all the buckets in db to a new database at
+// path, with each record passed through the Scrub func of its bucket (as
+// registered in dbInfo). Bucket sequences are preserved.
+func ExportScrubbed(db *DB, dbInfo *Info, path string) (err error) {
+	var options Options
+	options.Timeout = time.Second
+	target, err := _OpenBackend(path, 0644, &options)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ChannelError(&err, target.Close())
+	}()
+
+	src := ReadTx(db)
+	defer TxClose(src)
+
+	dst, err := _BeginWriteTx(target)
+	if err != nil {
+		return err
+	}
+	defer func() { // dst is replaced after each batch
+		TxClose(dst)
+	}()
+
+	var writesCount int
+	const txThreshold = 1024 * 4
+
+	err = src.ForEach(func(name []byte, bkt *BBucket) error {
+		scrub := _Scrubber(dbInfo, string(name))
+		out, err := dst.CreateBucketIfNotExists(name)
+		if err != nil {
+			return err
+		}
+		err = bkt.ForEach(func(key []byte, value []byte) error {
+			if scrub != nil {
+				value = scrub(key, value)
+			}
+			if err := RawPut(out, key, value); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			writesCount++
+			if writesCount > txThreshold {
+				if err := TxCommitE(dst); err != nil {
+					return err
+				}
+				if dst, err = _BeginWriteTx(target); err != nil {
+					return err
+				}
+				out = dst.Bucket(name)
+				writesCount = 0
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return dst.Bucket(name).SetSequence(bkt.Sequence())
+	})
+	if err != nil {
+		return err
+	}
+	return TxCommitE(dst)
+}
@@ -0,0 +1,89 @@
+//go:build !js && !vboltmem
+
+package vbolt
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// InstallShutdownSnapshot makes the process, on SIGINT or SIGTERM, write a
+// consistent copy of the database to path and close it before exiting:
+//
+//   - new write transactions are blocked
+//   - the open write transactions (if any) are allowed to finish
+//   - the copy is written to a temp file, synced, and renamed to path
+//   - the database is closed, and the signal is re-raised so the process
+//     exits as it would have without the hook
+//
+// If the application handles these signals itself, its handler runs at the
+// same time as this one; it should not expect to use the database.
+//
+// Returns a function that uninstalls the hook.
+func InstallShutdownSnapshot(db *DB, path string) (uninstall func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		var sig os.Signal
+		select {
+		case sig = <-signals:
+		case <-done:
+			return
+		}
+		signal.Stop(signals)
+
+		_WriteGate(db).Lock()
+		if err := _ShutdownSnapshot(db, path); err != nil {
+			Logf("vbolt: shutdown snapshot to %s failed: %v", path, err)
+		} else {
+			Logf("vbolt: shutdown snapshot written to %s", path)
+		}
+		if err := Close(db); err != nil {
+			Logf("vbolt: closing %s: %v", db.Path(), err)
+		}
+
+		if p, err := os.FindProcess(os.Getpid()); err != nil || p.Signal(sig) != nil {
+			os.Exit(1)
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// the caller holds the write gate exclusively, so every write tx has already
+// committed or rolled back, and none can start until the db is closed
+func _ShutdownSnapshot(db *DB, path string) error {
+	tx, err := _BeginWriteTxHeld(db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	return _WriteCopy(tx, path)
+}
+
+// writes a consistent copy of the database as seen by tx to path, through a
+// temp file that's synced and renamed, so path is never left half written
+func _WriteCopy(tx *Tx, path string) error {
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = tx.WriteTo(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,58 @@
+package vbolt
+
+import "sort"
+
+// SizeEntry is the approximate disk usage of one bucket, index, or collection
+type SizeEntry struct {
+	Name      string
+	Kind      string // "bucket", "index", "collection", "system", or "unregistered"
+	Keys      int
+	Pages     int // branch and leaf pages, including overflow pages
+	Allocated int // bytes of the pages
+	InUse     int // bytes used by keys, values, and page headers
+}
+
+// SizeBreakdown reports the disk usage of each bucket in the file, largest
+// first. The numbers come from bolt's page stats, so they are cheap to get
+// but approximate; free pages and the freelist are not attributed to anything.
+func SizeBreakdown(db *DB, info *Info) (entries []SizeEntry) {
+	kinds := make(map[string]string)
+	for _, name := range dbInfo.BucketList {
+		kinds[name] = "system"
+	}
+	if info != nil {
+		for _, name := range info.BucketList {
+			kinds[name] = "bucket"
+		}
+		for _, name := range info.IndexList {
+			kinds[name] = "index"
+		}
+		for _, name := range info.CollectionList {
+			kinds[name] = "collection"
+		}
+	}
+
+	WithReadTx(db, func(tx *Tx) {
+		tx.ForEach(func(name []byte, bkt *BBucket) error {
+			kind := kinds[string(name)]
+			if kind == "" {
+				kind = "unregistered"
+			}
+			stats := bkt.Stats()
+			entries = append(entries, SizeEntry{
+				Name:      string(name),
+				Kind:      kind,
+				Keys:      stats.KeyN,
+				Pages:     stats.BranchPageN + stats.BranchOverflowN + stats.LeafPageN + stats.LeafOverflowN,
+				Allocated: stats.BranchAlloc + stats.LeafAlloc,
+				InUse:     stats.BranchInuse + stats.LeafInuse,
+			})
+			return nil
+		})
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Allocated > entries[j].Allocated
+	})
+	return
+}
@@ -0,0 +1,80 @@
+package vbolt
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logf is used for vbolt's log messages; replace it to send them elsewhere
+var Logf func(format string, args ...any) = log.Printf
+
+// Operations that take longer (or visit more keys) than these are logged
+// with Logf. Zero disables the check.
+type SlowOpThresholds struct {
+	WriteTx        time.Duration // from WriteTx to TxClose, including the commit
+	SetTargetTerms time.Duration
+	Iteration      time.Duration
+	IterationKeys  int
+}
+
+var SlowOps SlowOpThresholds
+
+type _TxOps struct {
+	Start  time.Time
+	Writes map[string]int // structure name => number of writes
+}
+
+var _txOps sync.Map // *Tx => *_TxOps; only for write txs, when SlowOps.WriteTx is set
+
+func _TrackTxOps(tx *Tx) {
+	if tx != nil && SlowOps.WriteTx > 0 {
+		_txOps.Store(tx, &_TxOps{Start: time.Now(), Writes: make(map[string]int)})
+	}
+}
+
+func _NoteTxWrite(tx *Tx, name string) {
+	if ops, ok := _txOps.Load(tx); ok {
+		ops.(*_TxOps).Writes[name]++
+	}
+}
+
+func _LogSlowTx(tx *Tx) {
+	v, ok := _txOps.LoadAndDelete(tx)
+	if !ok {
+		return
+	}
+	ops := v.(*_TxOps)
+	elapsed := time.Since(ops.Start)
+	if elapsed < SlowOps.WriteTx {
+		return
+	}
+	names := make([]string, 0, len(ops.Writes))
+	for name := range ops.Writes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var writes strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			writes.WriteString(", ")
+		}
+		fmt.Fprintf(&writes, "%s=%d", name, ops.Writes[name])
+	}
+	Logf("vbolt: slow write tx: %s [writes: %s]", elapsed, writes.String())
+}
+
+func _LogSlowIteration(name string, prefix []byte, start time.Time, visited int) {
+	elapsed := time.Since(start)
+	slow := SlowOps.Iteration > 0 && elapsed >= SlowOps.Iteration
+	large := SlowOps.IterationKeys > 0 && visited >= SlowOps.IterationKeys
+	if slow || large {
+		if name == "" {
+			name = "?"
+		}
+		Logf("vbolt: slow iteration of %s: %d keys in %s (prefix %x)", name, visited, elapsed, prefix)
+	}
+}
@@ -0,0 +1,97 @@
+package vbolt
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+	A Snap holds a read tx open so several reads (possibly spread over time)
+	see the same consistent view of the database, e.g. for a multi-page
+	report. It replaces keeping raw read txs around.
+
+	While a read tx is open, bolt can't reuse the pages freed by writes after
+	it started, so the file grows; a Snap held longer than SnapWarnAfter is
+	logged (and again every SnapWarnAfter). Use Refresh to move to a newer
+	view, and Close when done.
+*/
+
+// SnapWarnAfter is how long a Snap can be held before it's logged; 0 disables the warning
+var SnapWarnAfter = time.Minute
+
+type Snap struct {
+	db      *DB
+	mu      sync.Mutex
+	tx      *Tx
+	started time.Time
+	warn    *time.Timer
+}
+
+// Snapshot starts a read tx on db and returns a handle to it
+func Snapshot(db *DB) *Snap {
+	snap := &Snap{db: db}
+	snap._Begin()
+	return snap
+}
+
+func (s *Snap) _Begin() {
+	s.tx = ReadTx(s.db)
+	s.started = time.Now()
+	if SnapWarnAfter > 0 {
+		started := s.started
+		var warn func()
+		warn = func() {
+			Logf("vbolt: snapshot of %s held for %s", s.db.Path(), time.Since(started).Truncate(time.Second))
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.tx != nil && s.started == started {
+				s.warn = time.AfterFunc(SnapWarnAfter, warn)
+			}
+		}
+		s.warn = time.AfterFunc(SnapWarnAfter, warn)
+	}
+}
+
+func (s *Snap) _End() {
+	if s.warn != nil {
+		s.warn.Stop()
+		s.warn = nil
+	}
+	TxClose(s.tx)
+	s.tx = nil
+}
+
+// View runs fn with the snapshot's tx. Calls are serialized, since a tx must
+// not be used from several goroutines at once. fn must not keep the tx, or
+// any slices it returned, after it returns.
+func (s *Snap) View(fn func(tx *Tx)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tx == nil {
+		panic(ErrTxClosed)
+	}
+	fn(s.tx)
+}
+
+// Started returns when the current view was taken
+func (s *Snap) Started() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started
+}
+
+// Refresh replaces the view with the current state of the database
+func (s *Snap) Refresh() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s._End()
+	s._Begin()
+}
+
+func (s *Snap) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tx != nil {
+		s._End()
+	}
+}
@@ -0,0 +1,124 @@
+package vbolt
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	SnapshotText writes a decoded, human readable dump of everything registered
+	in an Info. The output is deterministic (structures sorted by name, entries
+	in key order, values as json) so it can be used for golden file tests and for
+	diffing the data between environments.
+
+	Format:
+
+		== bucket users
+		1 => {"Name":"Alice"}
+		2 => {"Name":"Bob"}
+
+		== index users_by_name
+		"alice" [0] => 1
+		"bob" [0] => 2
+
+		== collection ...
+		"key" ["order"] => "item"
+*/
+
+func _SnapshotJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "!!" + err.Error()
+	}
+	return string(data)
+}
+
+func _SortedNames(lists ...[]string) []string {
+	var names []string
+	for _, list := range lists {
+		names = append(names, list...)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SnapshotText writes all the buckets, indexes, and collections registered in dbInfo to w
+func SnapshotText(db *DB, dbInfo *Info, w io.Writer) error {
+	var err error
+	WithReadTx(db, func(tx *Tx) {
+		err = SnapshotTextTx(tx, dbInfo, w)
+	})
+	return err
+}
+
+func SnapshotTextTx(tx *Tx, dbInfo *Info, w io.Writer) error {
+	out := bufio.NewWriter(w)
+	first := true
+	for _, name := range _SortedNames(dbInfo.BucketList, dbInfo.IndexList, dbInfo.CollectionList) {
+		if !first {
+			out.WriteString("\n")
+		}
+		first = false
+		_SnapshotStructure(tx, out, name, dbInfo.Infos[name])
+	}
+	return out.Flush()
+}
+
+// SnapshotBucketText writes a single structure (bucket, index, or collection) to w
+func SnapshotBucketText(tx *Tx, infoPtr any, w io.Writer) error {
+	out := bufio.NewWriter(w)
+	name := reflect.ValueOf(infoPtr).Elem().FieldByName("Name").String()
+	_SnapshotStructure(tx, out, name, infoPtr)
+	return out.Flush()
+}
+
+func _SnapshotStructure(tx *Tx, out *bufio.Writer, name string, infoPtr any) {
+	bkt := TxRawBucket(tx, name)
+	if g, ok := AsGenericBucket(infoPtr); ok {
+		out.WriteString("== bucket " + name + "\n")
+		if bkt == nil {
+			return
+		}
+		bkt.ForEach(func(key []byte, value []byte) error {
+			out.WriteString(_SnapshotJSON(GenericUnpackKey(&g, key)))
+			out.WriteString(" => ")
+			out.WriteString(_SnapshotJSON(GenericUnpackValue(&g, value)))
+			out.WriteString("\n")
+			return nil
+		})
+	} else if g, ok := AsGenericIndex(infoPtr); ok {
+		out.WriteString("== index " + name + "\n")
+		RawIterate(bkt, []byte{IndexTermPrefix}, Window{}, func(key []byte, value []byte) bool {
+			reader := vpack.NewReader(key)
+			reader.Pos++ // skip the IndexTermPrefix byte
+			term := reflectUnpackFrom(g.TermPackFn, reader)
+			priority := reflectUnpackFrom(g.PriorityPackFn, reader)
+			target := reflectUnpackFrom(g.TargetPackFn, reader)
+			out.WriteString(_SnapshotJSON(term) + " [" + _SnapshotJSON(priority) + "] => " + _SnapshotJSON(target) + "\n")
+			return true
+		})
+	} else if infoPtr != nil {
+		out.WriteString("== collection " + name + "\n")
+		v := reflect.ValueOf(infoPtr).Elem()
+		keyFn := v.FieldByName("KeyFn")
+		orderFn := v.FieldByName("OrderFn")
+		itemFn := v.FieldByName("ItemFn")
+		if !keyFn.IsValid() || !orderFn.IsValid() || !itemFn.IsValid() {
+			return
+		}
+		RawIterate(bkt, []byte{CKeyPrefix}, Window{}, func(key []byte, value []byte) bool {
+			reader := vpack.NewReader(key)
+			reader.Pos++ // skip the CKeyPrefix byte
+			cKey := reflectUnpackFrom(keyFn, reader)
+			order := reflectUnpackFrom(orderFn, reader)
+			item := reflectUnpackFrom(itemFn, reader)
+			out.WriteString(_SnapshotJSON(cKey) + " [" + _SnapshotJSON(order) + "] => " + _SnapshotJSON(item) + "\n")
+			return true
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package vbolt
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// keeps the best N items according to Better, using a heap whose root is the
+// worst of the kept items. With N <= 0, everything is kept.
+type _TopK[T any] struct {
+	Items  []T
+	N      int
+	Better func(a, b *T) bool
+}
+
+func (h *_TopK[T]) Len() int           { return len(h.Items) }
+func (h *_TopK[T]) Less(i, j int) bool { return h.Better(&h.Items[j], &h.Items[i]) }
+func (h *_TopK[T]) Swap(i, j int)      { h.Items[i], h.Items[j] = h.Items[j], h.Items[i] }
+func (h *_TopK[T]) Push(x any)         { h.Items = append(h.Items, x.(T)) }
+func (h *_TopK[T]) Pop() any {
+	last := h.Items[len(h.Items)-1]
+	h.Items = h.Items[:len(h.Items)-1]
+	return last
+}
+
+func (h *_TopK[T]) Add(item T) {
+	if h.N <= 0 {
+		h.Items = append(h.Items, item)
+		return
+	}
+	if len(h.Items) < h.N {
+		heap.Push(h, item)
+		return
+	}
+	if h.Better(&item, &h.Items[0]) {
+		h.Items[0] = item
+		heap.Fix(h, 0)
+	}
+}
+
+// returns the kept items in sorted order, after skipping offset of them
+func (h *_TopK[T]) Sorted(offset int) []T {
+	items := h.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return h.Better(&items[i], &items[j])
+	})
+	if offset >= len(items) {
+		return nil
+	}
+	return items[offset:]
+}
+
+// FetchSorted reads the records of the targets of term and sorts them by less
+// (or in reverse, if window.Direction is IterateReverse), appending the
+// window's offset and limit of them to out.
+//
+// With a limit, only offset+limit records are kept in memory at any time;
+// everything matching the term is still read. The window's cursor is not
+// supported.
+func FetchSorted[K, TT, P comparable, T any](tx *Tx, indexInfo *IndexInfo[K, TT, P], term TT, bucketInfo *BucketInfo[K, T], less func(a, b T) bool, window Window, out *[]T) {
+	bkt := TxRawBucket(tx, bucketInfo.Name)
+	if bkt == nil {
+		return
+	}
+	top := _TopK[T]{Better: func(a, b *T) bool { return less(*a, *b) }}
+	if window.Direction == IterateReverse {
+		top.Better = func(a, b *T) bool { return less(*b, *a) }
+	}
+	if window.Limit > 0 {
+		top.N = window.Offset + window.Limit
+	}
+
+	_IterateTermCore(tx, indexInfo, term, Window{}, func(target K, priority P) bool {
+		var item T
+		if _Read(bkt, bucketInfo, target, &item) {
+			top.Add(item)
+		}
+		return true
+	})
+	*out = append(*out, top.Sorted(window.Offset)...)
+}
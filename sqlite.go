@@ -0,0 +1,198 @@
+package vbolt
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.hasen.dev/vpack"
+)
+
+// SQLiteDriverName is the database/sql driver used by ExportSQLite.
+// The driver itself is not imported by this package; the application must
+// import one (e.g. github.com/mattn/go-sqlite3 registers "sqlite3", while
+// modernc.org/sqlite registers "sqlite").
+var SQLiteDriverName = "sqlite3"
+
+// ExportSQLite writes a snapshot of all the buckets and indexes registered in
+// dbInfo to a sqlite database file at path, for running ad-hoc SQL queries.
+//
+// Each bucket becomes a table with a "_key" column and either one column per
+// field (if the value is a flat struct with at least one exported field) or a
+// single "value" column holding the JSON representation. Field names are
+// exported, so they never start with an underscore, and can't collide with the
+// key column (SQLite column names are case insensitive). Each index becomes a table with (term, priority, target)
+// columns.
+func ExportSQLite(db *DB, dbInfo *Info, path string) error {
+	sqlDB, err := sql.Open(SQLiteDriverName, path)
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	sqlTx, err := sqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	tx := ReadTx(db)
+	defer TxClose(tx)
+
+	for _, name := range dbInfo.BucketList {
+		g, ok := AsGenericBucket(dbInfo.Infos[name])
+		if !ok {
+			continue
+		}
+		if err = _SQLExportBucket(tx, sqlTx, &g); err != nil {
+			return fmt.Errorf("exporting bucket %s: %w", name, err)
+		}
+	}
+
+	for _, name := range dbInfo.IndexList {
+		g, ok := AsGenericIndex(dbInfo.Infos[name])
+		if !ok {
+			continue
+		}
+		if err = _SQLExportIndex(tx, sqlTx, &g); err != nil {
+			return fmt.Errorf("exporting index %s: %w", name, err)
+		}
+	}
+
+	return sqlTx.Commit()
+}
+
+func _SQLQuote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+var _TimeType = reflect.TypeOf(time.Time{})
+
+func _SQLIsScalar(t reflect.Type) bool {
+	if t == _TimeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// returns the exported fields of t if t is a struct whose fields are all
+// scalars, and there is at least one
+func _SQLFlatFields(t reflect.Type) (fields []reflect.StructField, ok bool) {
+	if t.Kind() != reflect.Struct || t == _TimeType {
+		return nil, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if !_SQLIsScalar(field.Type) {
+			return nil, false
+		}
+		fields = append(fields, field)
+	}
+	return fields, len(fields) > 0
+}
+
+// converts v to something the sql driver can store; non-scalars become JSON text
+func _SQLValue(v reflect.Value) any {
+	if _SQLIsScalar(v.Type()) {
+		if v.Kind() == reflect.Uint64 || v.Kind() == reflect.Uint {
+			// sql drivers generally reject uint64 values with the high bit set
+			return int64(v.Uint())
+		}
+		return v.Interface()
+	}
+	data, _ := json.Marshal(v.Interface())
+	return string(data)
+}
+
+func _SQLExportBucket(tx *Tx, sqlTx *sql.Tx, g *GenericBucketInfo) error {
+	fields, flat := _SQLFlatFields(g.ValueType)
+
+	columns := []string{_SQLQuote("_key")}
+	if flat {
+		for _, field := range fields {
+			columns = append(columns, _SQLQuote(field.Name))
+		}
+	} else {
+		columns = append(columns, _SQLQuote("value"))
+	}
+
+	table := _SQLQuote(g.Name)
+	create := fmt.Sprintf("CREATE TABLE %s (%s PRIMARY KEY, %s)", table, columns[0], strings.Join(columns[1:], ", "))
+	if _, err := sqlTx.Exec(create); err != nil {
+		return err
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	stmt, err := sqlTx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), placeholders))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	bkt := TxRawBucket(tx, g.Name)
+	if bkt == nil {
+		return nil
+	}
+	return bkt.ForEach(func(key []byte, value []byte) error {
+		args := make([]any, 0, len(columns))
+		args = append(args, _SQLValue(reflect.ValueOf(GenericUnpackKey(g, key)).Elem()))
+		valueObj := reflect.ValueOf(GenericUnpackValue(g, value)).Elem()
+		if flat {
+			for _, field := range fields {
+				args = append(args, _SQLValue(valueObj.FieldByIndex(field.Index)))
+			}
+		} else {
+			args = append(args, _SQLValue(valueObj))
+		}
+		_, err := stmt.Exec(args...)
+		return err
+	})
+}
+
+func _SQLExportIndex(tx *Tx, sqlTx *sql.Tx, g *GenericIndexInfo) error {
+	table := _SQLQuote(g.Name)
+	create := fmt.Sprintf(`CREATE TABLE %s ("term", "priority", "target")`, table)
+	if _, err := sqlTx.Exec(create); err != nil {
+		return err
+	}
+	stmt, err := sqlTx.Prepare(fmt.Sprintf(`INSERT INTO %s ("term", "priority", "target") VALUES (?, ?, ?)`, table))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	bkt := TxRawBucket(tx, g.Name)
+	if bkt == nil {
+		return nil
+	}
+
+	var iterParams _RawIterationParams
+	iterParams.Prefix = []byte{IndexTermPrefix}
+	_RawIterateCore(bkt, iterParams, func(key []byte, value []byte) bool {
+		reader := vpack.NewReader(key)
+		reader.Pos++ // skip the IndexTermPrefix byte
+		term := reflectUnpackFrom(g.TermPackFn, reader)
+		priority := reflectUnpackFrom(g.PriorityPackFn, reader)
+		target := reflectUnpackFrom(g.TargetPackFn, reader)
+		_, err = stmt.Exec(
+			_SQLValue(reflect.ValueOf(term).Elem()),
+			_SQLValue(reflect.ValueOf(priority).Elem()),
+			_SQLValue(reflect.ValueOf(target).Elem()),
+		)
+		return err == nil
+	})
+	return err
+}
@@ -0,0 +1,57 @@
+package vbolt
+
+import "context"
+
+/*
+	StreamQuery runs a query in the background and delivers the items over a
+	channel, for export jobs and streaming endpoints that shouldn't hold the
+	whole result in memory.
+
+	The query runs in its own read transaction on a new goroutine. The channel
+	has StreamQueryBuffer capacity, so a slow consumer slows down the scan
+	instead of letting results pile up. The read transaction stays open until
+	the query is done or the context is cancelled; keep in mind that long read
+	transactions prevent bolt from reusing freed pages.
+
+	The consumer must either drain the channel or cancel the context.
+*/
+
+// StreamQueryBuffer is the capacity of the channels returned by StreamQuery
+var StreamQueryBuffer = 64
+
+type StreamResult struct {
+	Item GenericItem
+	Err  error // set on the last result if the query failed
+}
+
+// StreamQuery runs the query (see ExecQuery) and sends the items on the
+// returned channel, which is closed when the query is done. If the query
+// fails, the error is sent as the last result. If ctx is cancelled, the
+// channel is closed without an error result.
+func StreamQuery(ctx context.Context, db *DB, dbInfo *Info, query *Query) <-chan StreamResult {
+	ch := make(chan StreamResult, StreamQueryBuffer)
+	go func() {
+		defer close(ch)
+		send := func(r StreamResult) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			select {
+			case ch <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		var err error
+		WithReadTx(db, func(tx *Tx) {
+			_, _, err = _ExecQueryCore(tx, dbInfo, query, func(item GenericItem) bool {
+				return send(StreamResult{Item: item})
+			})
+		})
+		if err != nil {
+			send(StreamResult{Err: err})
+		}
+	}()
+	return ch
+}
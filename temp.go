@@ -0,0 +1,33 @@
+package vbolt
+
+import (
+	"os"
+	"time"
+
+	"go.hasen.dev/generic"
+)
+
+// OpenTemp opens a throwaway database in a new temp file, with a small mmap,
+// for tests and scratch work. remove closes the database and deletes the file.
+//
+// With the memstore backend (js/wasm or the vboltmem tag) nothing touches the disk.
+func OpenTemp() (db *DB, remove func()) {
+	opts := OpenOptions{Timeout: time.Second}
+	if BackendName == "memstore" {
+		db = generic.Must(OpenWithOptions("temp", opts))
+		return db, func() { Close(db) }
+	}
+
+	f := generic.Must(os.CreateTemp("", "vbolt-*.db"))
+	path := f.Name()
+	f.Close()
+	db, err := OpenWithOptions(path, opts)
+	if err != nil {
+		os.Remove(path)
+		panic(err)
+	}
+	return db, func() {
+		Close(db)
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,116 @@
+package vbolt
+
+import (
+	"fmt"
+	"sync"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	Compound index terms, e.g. posts by (category, status):
+
+		var PostsByCatStatus = vbolt.PairIndex(&Info, "posts_by_cat_status", vpack.FInt, vpack.String, vpack.FInt)
+
+		vbolt.IterateTerm(tx, PostsByCatStatus, vbolt.Pair[int, string]{catId, "published"}, visit)
+		vbolt.IterateTermFirst(tx, PostsByCatStatus, catId, visit) // any status
+
+	The elements are packed one after the other, so a query by the leading
+	elements is a prefix scan. That relies on the pack functions of the leading
+	elements being self delimiting, which is the case for the vpack ones
+	(strings are length prefixed, fixed ints are fixed size).
+
+	Entries are ordered by the full term first, so a leading-prefix query
+	visits the targets grouped by the remaining elements, and by priority
+	within each group.
+*/
+
+type Pair[A, B comparable] struct {
+	First  A
+	Second B
+}
+
+type Triple[A, B, C comparable] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+func PackPair[A, B comparable](firstFn vpack.PackFn[A], secondFn vpack.PackFn[B]) vpack.PackFn[Pair[A, B]] {
+	return func(self *Pair[A, B], buf *vpack.Buffer) {
+		firstFn(&self.First, buf)
+		secondFn(&self.Second, buf)
+	}
+}
+
+func PackTriple[A, B, C comparable](firstFn vpack.PackFn[A], secondFn vpack.PackFn[B], thirdFn vpack.PackFn[C]) vpack.PackFn[Triple[A, B, C]] {
+	return func(self *Triple[A, B, C], buf *vpack.Buffer) {
+		firstFn(&self.First, buf)
+		secondFn(&self.Second, buf)
+		thirdFn(&self.Third, buf)
+	}
+}
+
+// the pack functions of the leading elements, for prefix queries
+var _leadingFns sync.Map // *IndexInfo => _PairLeading or _TripleLeading
+
+type _PairLeading[A comparable] struct {
+	FirstFn vpack.PackFn[A]
+}
+
+type _TripleLeading[A, B comparable] struct {
+	FirstFn  vpack.PackFn[A]
+	SecondFn vpack.PackFn[B]
+}
+
+// PairIndex is Index with a Pair term, which can also be queried by its
+// first element (see IterateTermFirst)
+func PairIndex[K, A, B comparable](dbInfo *Info, name string, firstFn vpack.PackFn[A], secondFn vpack.PackFn[B], targetFn vpack.PackFn[K]) *IndexInfo[K, Pair[A, B], uint16] {
+	result := Index(dbInfo, name, PackPair(firstFn, secondFn), targetFn)
+	_leadingFns.Store(result, _PairLeading[A]{firstFn})
+	return result
+}
+
+// TripleIndex is Index with a Triple term, which can also be queried by its
+// first element or first two elements (see IterateTripleFirst and IterateTripleFirstTwo)
+func TripleIndex[K, A, B, C comparable](dbInfo *Info, name string, firstFn vpack.PackFn[A], secondFn vpack.PackFn[B], thirdFn vpack.PackFn[C], targetFn vpack.PackFn[K]) *IndexInfo[K, Triple[A, B, C], uint16] {
+	result := Index(dbInfo, name, PackTriple(firstFn, secondFn, thirdFn), targetFn)
+	_leadingFns.Store(result, _TripleLeading[A, B]{firstFn, secondFn})
+	return result
+}
+
+func _Leading[L any](indexInfo any, name string) L {
+	value, ok := _leadingFns.Load(indexInfo)
+	if !ok {
+		panic(fmt.Errorf("vbolt: index %s was not created with PairIndex or TripleIndex", name))
+	}
+	return value.(L)
+}
+
+// IterateTermFirst visits the entries of all the terms whose first element is first
+func IterateTermFirst[K, A, B, P comparable](tx *Tx, indexInfo *IndexInfo[K, Pair[A, B], P], first A, visitFn func(target K, term Pair[A, B], priority P) bool, opts ...IterOption) Continuation {
+	fns := _Leading[_PairLeading[A]](indexInfo, indexInfo.Name)
+	buf := vpack.NewWriter()
+	buf.WriteBytes(IndexTermPrefix)
+	fns.FirstFn(&first, buf)
+	return _IterateTermsPrefix(tx, indexInfo, buf.Data, opts, visitFn)
+}
+
+// IterateTripleFirst visits the entries of all the terms whose first element is first
+func IterateTripleFirst[K, A, B, C, P comparable](tx *Tx, indexInfo *IndexInfo[K, Triple[A, B, C], P], first A, visitFn func(target K, term Triple[A, B, C], priority P) bool, opts ...IterOption) Continuation {
+	fns := _Leading[_TripleLeading[A, B]](indexInfo, indexInfo.Name)
+	buf := vpack.NewWriter()
+	buf.WriteBytes(IndexTermPrefix)
+	fns.FirstFn(&first, buf)
+	return _IterateTermsPrefix(tx, indexInfo, buf.Data, opts, visitFn)
+}
+
+// IterateTripleFirstTwo visits the entries of all the terms whose first two elements are first and second
+func IterateTripleFirstTwo[K, A, B, C, P comparable](tx *Tx, indexInfo *IndexInfo[K, Triple[A, B, C], P], first A, second B, visitFn func(target K, term Triple[A, B, C], priority P) bool, opts ...IterOption) Continuation {
+	fns := _Leading[_TripleLeading[A, B]](indexInfo, indexInfo.Name)
+	buf := vpack.NewWriter()
+	buf.WriteBytes(IndexTermPrefix)
+	fns.FirstFn(&first, buf)
+	fns.SecondFn(&second, buf)
+	return _IterateTermsPrefix(tx, indexInfo, buf.Data, opts, visitFn)
+}
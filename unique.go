@@ -0,0 +1,75 @@
+package vbolt
+
+import "go.hasen.dev/vpack"
+
+/*
+	A unique index maps each term to at most one target, e.g. email => user id:
+
+		var UserByEmail = vbolt.UniqueIndex(&Info, "user_by_email", vpack.String, vpack.FInt)
+
+		owner, ok := vbolt.Claim(tx, UserByEmail, email, userId)
+		if !ok {
+			// taken by owner
+		}
+
+	Claims are atomic, since write transactions are serialized. When a target
+	changes its term, release the old one and claim the new one in the same
+	transaction.
+
+	It's stored as a bucket of term => target, so it can be read with the
+	bucket api too (through UniqueIndexInfo.Bucket).
+*/
+
+type UniqueIndexInfo[K, T comparable] struct {
+	Name   string
+	Bucket *BucketInfo[T, K]
+}
+
+func UniqueIndex[K, T comparable](dbInfo *Info, name string, termFn vpack.PackFn[T], targetFn vpack.PackFn[K]) *UniqueIndexInfo[K, T] {
+	return &UniqueIndexInfo[K, T]{
+		Name:   name,
+		Bucket: Bucket(dbInfo, name, termFn, targetFn),
+	}
+}
+
+// Claim assigns term to target, unless another target has it. Returns the
+// owner of the term after the call, and whether it's target. Claiming a term
+// that target already owns succeeds. The zero term can't be claimed.
+func Claim[K, T comparable](tx *Tx, index *UniqueIndexInfo[K, T], term T, target K) (owner K, ok bool) {
+	var zero T
+	if term == zero {
+		return
+	}
+	if owner, found := Get(tx, index.Bucket, term); found {
+		return owner, owner == target
+	}
+	Write(tx, index.Bucket, term, &target)
+	return target, true
+}
+
+// Release removes term from the index if target owns it. Returns whether it did
+func Release[K, T comparable](tx *Tx, index *UniqueIndexInfo[K, T], term T, target K) bool {
+	owner, found := Get(tx, index.Bucket, term)
+	if !found || owner != target {
+		return false
+	}
+	Delete(tx, index.Bucket, term)
+	return true
+}
+
+// Reclaim moves target from oldTerm to newTerm, e.g. when a user changes
+// their email. If newTerm is taken, nothing changes and ok is false.
+func Reclaim[K, T comparable](tx *Tx, index *UniqueIndexInfo[K, T], oldTerm T, newTerm T, target K) (owner K, ok bool) {
+	if owner, ok = Claim(tx, index, newTerm, target); !ok {
+		return
+	}
+	if oldTerm != newTerm {
+		Release(tx, index, oldTerm, target)
+	}
+	return
+}
+
+// Owner returns the target that has term
+func Owner[K, T comparable](tx *Tx, index *UniqueIndexInfo[K, T], term T) (owner K, ok bool) {
+	return Get(tx, index.Bucket, term)
+}
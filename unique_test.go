@@ -0,0 +1,81 @@
+package vbolt_test
+
+import (
+	"testing"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestUniqueIndex(t *testing.T) {
+	var dbInfo vbolt.Info
+	byEmail := vbolt.UniqueIndex(&dbInfo, "user_by_email", vpack.String, vpack.FInt)
+
+	db := vbolttest.NewTestDB(t, &dbInfo)
+
+	const (
+		claim = iota
+		reclaim
+		release
+	)
+
+	type step struct {
+		op      int
+		oldTerm string // reclaim only
+		term    string
+		target  int
+		owner   int
+		ok      bool
+	}
+
+	// each step runs in its own transaction; owner and ok are the expected results
+	steps := []step{
+		{claim, "", "a@x", 1, 1, true},
+		{claim, "", "a@x", 1, 1, true}, // already owns it
+		{claim, "", "a@x", 2, 1, false},
+		{claim, "", "", 2, 0, false}, // the zero term
+		{claim, "", "b@x", 2, 2, true},
+		{reclaim, "b@x", "a@x", 2, 1, false}, // taken, nothing changes
+		{reclaim, "b@x", "c@x", 2, 2, true},
+		{claim, "", "b@x", 3, 3, true}, // released by the reclaim
+		{reclaim, "c@x", "c@x", 2, 2, true},
+		{release, "", "a@x", 2, 0, false}, // not the owner
+		{release, "", "a@x", 1, 0, true},
+		{claim, "", "a@x", 2, 2, true},
+	}
+
+	expectedOwners := map[string]int{
+		"a@x": 2,
+		"b@x": 3,
+		"c@x": 2,
+		"d@x": 0,
+	}
+
+	for i, s := range steps {
+		vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+			var owner int
+			var ok bool
+			switch s.op {
+			case claim:
+				owner, ok = vbolt.Claim(tx, byEmail, s.term, s.target)
+			case reclaim:
+				owner, ok = vbolt.Reclaim(tx, byEmail, s.oldTerm, s.term, s.target)
+			case release:
+				ok = vbolt.Release(tx, byEmail, s.term, s.target)
+			}
+			if owner != s.owner || ok != s.ok {
+				t.Errorf("step %d: expected (%d, %v), got (%d, %v)", i, s.owner, s.ok, owner, ok)
+			}
+		})
+	}
+
+	vbolttest.View(db, func(tx *vbolt.Tx) {
+		for term, expected := range expectedOwners {
+			owner, ok := vbolt.Owner(tx, byEmail, term)
+			if owner != expected || ok != (expected != 0) {
+				t.Errorf("owner of %q: expected %d, got %d (%v)", term, expected, owner, ok)
+			}
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package vbolt
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrDuplicateName = errors.New("vbolt: name registered more than once")
+var ErrReservedName = errors.New("vbolt: name is reserved")
+
+/*
+	Reserved names and prefixes:
+
+	- The names of vbolt's own buckets (see the system Info in migrations.go,
+	  e.g. "proc") can't be used by applications, since they live in the
+	  same file. Empty names are rejected too.
+	- Inside index and collection buckets, every key starts with a prefix byte
+	  that says which structure it belongs to. The range below
+	  ReservedPrefixLimit is reserved for vbolt; keys there with an unknown
+	  prefix are reported by CheckInvariants.
+*/
+
+const ReservedPrefixLimit byte = 0x20
+
+// ValidateInfo reports problems with the registrations in info. Call it at
+// startup, after all the buckets, indexes, and collections are declared.
+//
+// Registering the same name twice (as the same kind or across kinds) makes
+// both share the same bolt bucket, and the keyspaces silently mix.
+func ValidateInfo(info *Info) error {
+	var errs []error
+
+	kinds := make(map[string]string)
+	check := func(kind string, names []string) {
+		for _, name := range names {
+			if prev, ok := kinds[name]; ok {
+				errs = append(errs, fmt.Errorf("%w: %q as %s and %s", ErrDuplicateName, name, prev, kind))
+				continue
+			}
+			kinds[name] = kind
+		}
+	}
+	check("bucket", info.BucketList)
+	check("index", info.IndexList)
+	check("collection", info.CollectionList)
+
+	for name := range kinds {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("%w: empty name", ErrReservedName))
+		}
+		if info != &dbInfo && dbInfo.Infos[name] != nil {
+			errs = append(errs, fmt.Errorf("%w: %q is used by vbolt itself", ErrReservedName, name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// called by Bucket, Index, and Collection before registering name
+func _WarnIfRegistered(dbInfo *Info, name string) {
+	if existing, ok := dbInfo.Infos[name]; ok {
+		Logf("vbolt: %q is already registered (as %T); see ValidateInfo", name, existing)
+	}
+}
@@ -0,0 +1,254 @@
+package vboltremote
+
+import (
+	"encoding/gob"
+	"errors"
+	"net"
+	"sync"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vpack"
+)
+
+type Client struct {
+	conn net.Conn
+	enc  *gob.Encoder
+	dec  *gob.Decoder
+	mu   sync.Mutex // one request in flight at a time
+}
+
+// Dial connects to a server and authenticates with token; see NewClient
+func Dial(network string, address string, token string) (*Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn, token)
+}
+
+// NewClient does the handshake over conn, sending token to the server's
+// Authorize function. conn is closed if the handshake fails.
+func NewClient(conn net.Conn, token string) (*Client, error) {
+	c := &Client{
+		conn: conn,
+		enc:  gob.NewEncoder(conn),
+		dec:  gob.NewDecoder(conn),
+	}
+	if _, err := _Call(c, &Request{Op: _OpHello, Token: token}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func _Call(c *Client, req *Request) (resp Response, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err = c.enc.Encode(req); err != nil {
+		return
+	}
+	if err = c.dec.Decode(&resp); err != nil {
+		return
+	}
+	if resp.Err != "" {
+		err = errors.New(resp.Err)
+	}
+	return
+}
+
+// Tx is a transaction held open on the server.
+//
+// The typed helpers don't return errors, to mirror the vbolt api. Instead, the
+// first error is kept in Err, subsequent operations on the tx do nothing, and
+// Commit returns the error.
+type Tx struct {
+	Client   *Client
+	Id       uint64
+	Writable bool
+	Err      error
+	done     bool
+}
+
+func Begin(c *Client, writable bool) (*Tx, error) {
+	resp, err := _Call(c, &Request{Op: _OpBegin, Writable: writable})
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Client: c, Id: resp.TxId, Writable: writable}, nil
+}
+
+func _TxCall(tx *Tx, req *Request) (resp Response) {
+	if tx.Err != nil {
+		return
+	}
+	if tx.done {
+		tx.Err = vbolt.ErrTxClosed
+		return
+	}
+	req.TxId = tx.Id
+	resp, tx.Err = _Call(tx.Client, req)
+	return
+}
+
+// Commit commits the tx, unless an error happened during the tx, in which
+// case the tx is rolled back and the error is returned
+func Commit(tx *Tx) error {
+	if tx.Err != nil {
+		Rollback(tx)
+		return tx.Err
+	}
+	_TxCall(tx, &Request{Op: _OpCommit})
+	tx.done = true
+	return tx.Err
+}
+
+// Rollback is safe to call on a closed tx, so it can be deferred
+func Rollback(tx *Tx) {
+	if tx == nil || tx.done {
+		return
+	}
+	tx.done = true
+	_Call(tx.Client, &Request{Op: _OpRollback, TxId: tx.Id})
+}
+
+// WithReadTx is like vbolt.WithReadTx; returns the first error encountered
+func WithReadTx(c *Client, fn func(tx *Tx)) error {
+	tx, err := Begin(c, false)
+	if err != nil {
+		return err
+	}
+	defer Rollback(tx)
+	fn(tx)
+	return tx.Err
+}
+
+// WithWriteTx is like vbolt.WithWriteTx; the caller must Commit explicitly
+func WithWriteTx(c *Client, fn func(tx *Tx)) error {
+	tx, err := Begin(c, true)
+	if err != nil {
+		return err
+	}
+	defer Rollback(tx)
+	fn(tx)
+	return tx.Err
+}
+
+func RawGet(tx *Tx, bucket string, key []byte) []byte {
+	resp := _TxCall(tx, &Request{Op: _OpGet, Bucket: bucket, Key: key})
+	if !resp.Found {
+		return nil
+	}
+	return resp.Value
+}
+
+func RawPut(tx *Tx, bucket string, key []byte, value []byte) {
+	_TxCall(tx, &Request{Op: _OpPut, Bucket: bucket, Key: key, Value: value})
+}
+
+func RawDelete(tx *Tx, bucket string, key []byte) {
+	_TxCall(tx, &Request{Op: _OpDelete, Bucket: bucket, Key: key})
+}
+
+// number of items fetched per round trip while iterating
+var ScanPageSize = 256
+
+// RawIterate is like vbolt.RawIterate, fetching items from the server in pages
+func RawIterate(tx *Tx, bucket string, prefix []byte, window vbolt.Window, visitFn func(key []byte, value []byte) bool) []byte {
+	remaining := window.Limit
+	for {
+		page := window
+		page.Limit = ScanPageSize
+		if remaining > 0 && remaining < page.Limit {
+			page.Limit = remaining
+		}
+		resp := _TxCall(tx, &Request{Op: _OpScan, Bucket: bucket, Prefix: prefix, Window: page})
+		if tx.Err != nil {
+			return nil
+		}
+		for i := range resp.Keys {
+			if !visitFn(resp.Keys[i], resp.Values[i]) {
+				// the server does not know where we stopped; the next key is the one after this
+				if i+1 < len(resp.Keys) {
+					return resp.Keys[i+1]
+				}
+				return resp.NextKey
+			}
+		}
+		if remaining > 0 {
+			remaining -= len(resp.Keys)
+			if remaining <= 0 {
+				return resp.NextKey
+			}
+		}
+		if resp.NextKey == nil {
+			return nil
+		}
+		window.Cursor = resp.NextKey
+		window.Offset = 0
+	}
+}
+
+func HasKey[K, T any](tx *Tx, info *vbolt.BucketInfo[K, T], id K) bool {
+	return RawGet(tx, info.Name, vpack.ToBytes(&id, info.KeyPackFn)) != nil
+}
+
+func Read[K comparable, T any](tx *Tx, info *vbolt.BucketInfo[K, T], id K, item *T) bool {
+	var zero K
+	if id == zero {
+		return false
+	}
+	data := RawGet(tx, info.Name, vpack.ToBytes(&id, info.KeyPackFn))
+	if data == nil {
+		return false
+	}
+	return vpack.FromBytesInto(data, item, info.ValuePackFn)
+}
+
+// Writes an item to a key. Note: does not write anything if id is the zero value
+func Write[K comparable, T any](tx *Tx, info *vbolt.BucketInfo[K, T], id K, item *T) {
+	var zero K
+	if id == zero {
+		return
+	}
+	RawPut(tx, info.Name, vpack.ToBytes(&id, info.KeyPackFn), vpack.ToBytes(item, info.ValuePackFn))
+}
+
+func Delete[K, T any](tx *Tx, info *vbolt.BucketInfo[K, T], id K) {
+	RawDelete(tx, info.Name, vpack.ToBytes(&id, info.KeyPackFn))
+}
+
+func NextIntId[K, T any](tx *Tx, info *vbolt.BucketInfo[K, T]) int {
+	resp := _TxCall(tx, &Request{Op: _OpNextSequence, Bucket: info.Name})
+	return int(resp.Sequence)
+}
+
+func IterateAll[K, T any](tx *Tx, info *vbolt.BucketInfo[K, T], visitFn func(key K, item T) bool) {
+	RawIterate(tx, info.Name, nil, vbolt.Window{}, func(key []byte, value []byte) bool {
+		var itemKey K
+		var item T
+		vpack.FromBytesInto(key, &itemKey, info.KeyPackFn)
+		vpack.FromBytesInto(value, &item, info.ValuePackFn)
+		return visitFn(itemKey, item)
+	})
+}
+
+func IterateTerm[K, T, P comparable](tx *Tx, info *vbolt.IndexInfo[K, T, P], term T, window vbolt.Window, visitFn func(target K, priority P) bool) []byte {
+	buf := vpack.NewWriter()
+	buf.WriteBytes(vbolt.IndexTermPrefix)
+	info.TermPackFn(&term, buf)
+
+	return RawIterate(tx, info.Name, buf.Data, window, func(key []byte, value []byte) bool {
+		var target K
+		var priority P
+		reader := vpack.NewReader(key)
+		reader.Pos++ // skip the IndexTermPrefix byte
+		info.TermPackFn(&term, reader)
+		info.PriorityPackFn(&priority, reader)
+		info.TargetPackFn(&target, reader)
+		return visitFn(target, priority)
+	})
+}
@@ -0,0 +1,312 @@
+// Package vboltremote lets a second process (an admin cli, a worker) access a
+// database file that is held open by a server process, without fighting over
+// the file lock.
+//
+// The server wraps a DB and executes raw bucket operations on behalf of
+// clients. The client side packs and unpacks keys and values itself, using the
+// same BucketInfo / IndexInfo definitions as the server, and exposes typed
+// helpers that mirror the vbolt ones.
+//
+// The protocol is a stream of gob encoded request/response pairs over any
+// net.Conn (tcp, unix sockets, or a websocket wrapped as a net.Conn).
+//
+// Clients get raw access: their puts and deletes skip the constraints, index
+// maintenance, and timestamps of the typed vbolt api. The first request on a
+// connection is a handshake with a token, which the server's Authorize
+// function turns into read or read-write access. Over tcp, use TokenAuth (and
+// a transport that keeps the token secret); AllowAll is meant for unix
+// sockets, where the file permissions decide who can connect.
+package vboltremote
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/gob"
+	"errors"
+	"net"
+	"time"
+
+	"go.hasen.dev/vbolt"
+)
+
+type _Op uint8
+
+const (
+	_OpBegin _Op = iota + 1
+	_OpCommit
+	_OpRollback
+	_OpGet
+	_OpPut
+	_OpDelete
+	_OpScan
+	_OpNextSequence
+	_OpHello
+)
+
+type Request struct {
+	Op       _Op
+	TxId     uint64
+	Writable bool
+	Bucket   string
+	Key      []byte
+	Value    []byte
+	Prefix   []byte
+	Window   vbolt.Window
+	Token    string // only for the handshake
+}
+
+type Response struct {
+	Err      string
+	TxId     uint64
+	Found    bool
+	Value    []byte
+	Keys     [][]byte
+	Values   [][]byte
+	NextKey  []byte
+	Sequence uint64
+}
+
+var ErrUnknownTx = errors.New("unknown transaction")
+var ErrUnknownOp = errors.New("unknown operation")
+var ErrWriteTxOpen = errors.New("the connection already has a write transaction open")
+var ErrUnauthorized = errors.New("unauthorized")
+var ErrReadOnly = errors.New("the connection has read-only access")
+
+type Access uint8
+
+const (
+	AccessNone Access = iota
+	AccessRead
+	AccessWrite
+)
+
+// Authorize decides what a connection may do, given the token the client
+// sent in its handshake
+type Authorize func(conn net.Conn, token string) Access
+
+// AllowAll gives every connection read-write access, whatever the token
+func AllowAll(conn net.Conn, token string) Access {
+	return AccessWrite
+}
+
+// TokenAuth gives read-write access to the clients that send token
+func TokenAuth(token string) Authorize {
+	return func(conn net.Conn, sent string) Access {
+		if token != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(token)) == 1 {
+			return AccessWrite
+		}
+		return AccessNone
+	}
+}
+
+// TxIdleTimeout is how long a transaction can go without a request before
+// it's rolled back. An idle write tx blocks every writer of the server
+// process, so this should be short. When the connection goes quiet with a
+// transaction open, it's closed once that transaction expires.
+var TxIdleTimeout = 30 * time.Second
+
+// ScanMaxLimit caps the items returned by one scan request; clients page
+// through larger scans with the returned NextKey
+var ScanMaxLimit = 1000
+
+// Serve accepts connections on listener and serves each one on its own
+// goroutine; see ServeConn
+func Serve(listener net.Listener, db *vbolt.DB, authorize Authorize) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go ServeConn(conn, db, authorize)
+	}
+}
+
+type _ConnState struct {
+	db      *vbolt.DB
+	access  Access
+	txs     map[uint64]*_ConnTx
+	nextId  uint64
+	writeId uint64 // the open write tx, 0 if none
+}
+
+type _ConnTx struct {
+	tx       *vbolt.Tx
+	lastUsed time.Time
+}
+
+func _CloseTx(state *_ConnState, id uint64) {
+	if ctx := state.txs[id]; ctx != nil {
+		vbolt.TxClose(ctx.tx)
+		delete(state.txs, id)
+	}
+	if state.writeId == id {
+		state.writeId = 0
+	}
+}
+
+// rolls back the transactions idle for longer than TxIdleTimeout, and
+// returns when the next one would expire (zero if there are none)
+func _ExpireTxs(state *_ConnState, now time.Time) (next time.Time) {
+	for id, ctx := range state.txs {
+		expires := ctx.lastUsed.Add(TxIdleTimeout)
+		if !now.Before(expires) {
+			_CloseTx(state, id)
+			continue
+		}
+		if next.IsZero() || expires.Before(next) {
+			next = expires
+		}
+	}
+	return
+}
+
+// HandshakeTimeout is how long a new connection has to send its handshake
+var HandshakeTimeout = 10 * time.Second
+
+// ServeConn handles requests from conn until it's closed; transactions left
+// open by the client are rolled back. A connection can have one write
+// transaction open at a time, and any number of read transactions.
+//
+// The connection is dropped unless its first request is a handshake that
+// authorize accepts; a nil authorize accepts none.
+func ServeConn(conn net.Conn, db *vbolt.DB, authorize Authorize) {
+	defer conn.Close()
+
+	state := _ConnState{db: db, txs: make(map[uint64]*_ConnTx)}
+	defer func() {
+		for id := range state.txs {
+			_CloseTx(&state, id)
+		}
+	}()
+
+	dec := gob.NewDecoder(conn)
+	enc := gob.NewEncoder(conn)
+
+	conn.SetReadDeadline(time.Now().Add(HandshakeTimeout))
+	var hello Request
+	if err := dec.Decode(&hello); err != nil {
+		return
+	}
+	if hello.Op == _OpHello && authorize != nil {
+		state.access = authorize(conn, hello.Token)
+	}
+	var resp Response
+	if state.access == AccessNone {
+		resp.Err = ErrUnauthorized.Error()
+	}
+	if err := enc.Encode(&resp); err != nil || state.access == AccessNone {
+		return
+	}
+
+	for {
+		// a read that times out can leave the stream mid message, so the
+		// connection is dropped instead of just the expired tx
+		conn.SetReadDeadline(_ExpireTxs(&state, time.Now()))
+		var req Request
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		_ExpireTxs(&state, time.Now())
+		var resp Response
+		if err := _Handle(&state, &req, &resp); err != nil {
+			resp.Err = err.Error()
+		}
+		if err := enc.Encode(&resp); err != nil {
+			return
+		}
+	}
+}
+
+func _Handle(state *_ConnState, req *Request, resp *Response) error {
+	if req.Op == _OpBegin {
+		var tx *vbolt.Tx
+		var err error
+		if req.Writable {
+			if state.access < AccessWrite {
+				return ErrReadOnly
+			}
+			// a second write tx would wait on the first forever, as both
+			// belong to this goroutine
+			if state.writeId != 0 {
+				return ErrWriteTxOpen
+			}
+			tx, err = vbolt.WriteTxE(state.db)
+		} else {
+			tx, err = state.db.Begin(false)
+		}
+		if err != nil {
+			return err
+		}
+		state.nextId++
+		state.txs[state.nextId] = &_ConnTx{tx: tx, lastUsed: time.Now()}
+		if req.Writable {
+			state.writeId = state.nextId
+		}
+		resp.TxId = state.nextId
+		return nil
+	}
+
+	ctx := state.txs[req.TxId]
+	if ctx == nil {
+		return ErrUnknownTx
+	}
+	ctx.lastUsed = time.Now()
+	tx := ctx.tx
+
+	switch req.Op {
+	case _OpCommit:
+		err := vbolt.TxCommitE(tx)
+		_CloseTx(state, req.TxId)
+		return err
+
+	case _OpRollback:
+		_CloseTx(state, req.TxId)
+		return nil
+
+	case _OpGet:
+		if bkt := tx.Bucket([]byte(req.Bucket)); bkt != nil {
+			value := bkt.Get(req.Key)
+			resp.Found = value != nil
+			resp.Value = bytes.Clone(value)
+		}
+		return nil
+
+	case _OpPut:
+		bkt, err := tx.CreateBucketIfNotExists([]byte(req.Bucket))
+		if err != nil {
+			return err
+		}
+		return bkt.Put(req.Key, req.Value)
+
+	case _OpDelete:
+		bkt := tx.Bucket([]byte(req.Bucket))
+		if bkt == nil {
+			return nil
+		}
+		return bkt.Delete(req.Key)
+
+	case _OpNextSequence:
+		bkt, err := tx.CreateBucketIfNotExists([]byte(req.Bucket))
+		if err != nil {
+			return err
+		}
+		resp.Sequence, err = bkt.NextSequence()
+		return err
+
+	case _OpScan:
+		window := req.Window
+		if window.Limit <= 0 || window.Limit > ScanMaxLimit {
+			window.Limit = ScanMaxLimit
+		}
+		bkt := tx.Bucket([]byte(req.Bucket))
+		next := vbolt.RawIterate(bkt, req.Prefix, window, func(key []byte, value []byte) bool {
+			resp.Keys = append(resp.Keys, bytes.Clone(key))
+			resp.Values = append(resp.Values, bytes.Clone(value))
+			return true
+		})
+		resp.NextKey = bytes.Clone(next)
+		return nil
+	}
+
+	return ErrUnknownOp
+}
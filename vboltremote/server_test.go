@@ -0,0 +1,112 @@
+package vboltremote_test
+
+import (
+	"net"
+	"testing"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vboltremote"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+	db := vbolttest.NewTestDB(t, &dbInfo)
+
+	connect := func(authorize vboltremote.Authorize, token string) (*vboltremote.Client, error) {
+		serverConn, clientConn := net.Pipe()
+		go vboltremote.ServeConn(serverConn, db, authorize)
+		client, err := vboltremote.NewClient(clientConn, token)
+		if client != nil {
+			t.Cleanup(func() { client.Close() })
+		}
+		return client, err
+	}
+
+	t.Run("handshake", func(t *testing.T) {
+		auth := vboltremote.TokenAuth("secret")
+		if _, err := connect(auth, "wrong"); err == nil {
+			t.Errorf("connected with the wrong token")
+		}
+		if _, err := connect(nil, "secret"); err == nil {
+			t.Errorf("connected without an authorize function")
+		}
+		if _, err := connect(vboltremote.TokenAuth(""), ""); err == nil {
+			t.Errorf("connected with an empty token")
+		}
+		if _, err := connect(auth, "secret"); err != nil {
+			t.Errorf("handshake failed: %v", err)
+		}
+	})
+
+	client, err := connect(vboltremote.TokenAuth("secret"), "secret")
+	if err != nil {
+		t.Fatalf("handshake failed: %v", err)
+	}
+
+	err = vboltremote.WithWriteTx(client, func(tx *vboltremote.Tx) {
+		for _, content := range []string{"one", "two", "three"} {
+			id := vboltremote.NextIntId(tx, posts)
+			vboltremote.Write(tx, posts, id, &content)
+		}
+		vboltremote.Delete(tx, posts, 2)
+		vboltremote.Commit(tx)
+	})
+	if err != nil {
+		t.Fatalf("write tx: %v", err)
+	}
+
+	// the server's db has the writes
+	vbolttest.View(db, func(tx *vbolt.Tx) {
+		var content string
+		if !vbolt.Read(tx, posts, 3, &content) || content != "three" {
+			t.Errorf("post 3 on the server: %q", content)
+		}
+		if vbolt.HasKey(tx, posts, 2) {
+			t.Errorf("post 2 wasn't deleted on the server")
+		}
+	})
+
+	err = vboltremote.WithReadTx(client, func(tx *vboltremote.Tx) {
+		var content string
+		if !vboltremote.Read(tx, posts, 1, &content) || content != "one" {
+			t.Errorf("read post 1: %q", content)
+		}
+		if vboltremote.HasKey(tx, posts, 2) {
+			t.Errorf("post 2 wasn't deleted")
+		}
+		var ids []int
+		vboltremote.IterateAll(tx, posts, func(id int, content string) bool {
+			ids = append(ids, id)
+			return true
+		})
+		if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+			t.Errorf("iterated %v, expected [1 3]", ids)
+		}
+	})
+	if err != nil {
+		t.Fatalf("read tx: %v", err)
+	}
+
+	t.Run("read only", func(t *testing.T) {
+		readOnly := func(conn net.Conn, token string) vboltremote.Access {
+			return vboltremote.AccessRead
+		}
+		client, err := connect(readOnly, "")
+		if err != nil {
+			t.Fatalf("handshake failed: %v", err)
+		}
+		if _, err := vboltremote.Begin(client, true); err == nil {
+			t.Errorf("began a write tx with read-only access")
+		}
+		err = vboltremote.WithReadTx(client, func(tx *vboltremote.Tx) {
+			content := "sneaky"
+			vboltremote.Write(tx, posts, 4, &content)
+		})
+		if err == nil {
+			t.Errorf("wrote in a read tx")
+		}
+	})
+}
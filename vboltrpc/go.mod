@@ -0,0 +1,18 @@
+// vboltrpc is its own module so that vbolt itself doesn't depend on grpc
+module go.hasen.dev/vbolt/vboltrpc
+
+go 1.21
+
+require (
+	go.hasen.dev/vbolt v0.0.0-20261016032607-873b21140281
+	google.golang.org/grpc v1.64.0
+	google.golang.org/protobuf v1.34.2
+)
+
+require (
+	github.com/boltdb/bolt v1.3.1 // indirect
+	golang.org/x/net v0.22.0 // indirect
+	golang.org/x/sys v0.27.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+)
@@ -0,0 +1,259 @@
+// Package vboltrpc exposes read and query operations of a vbolt database over
+// gRPC, so that services not written in Go can query the data.
+//
+// The service is defined in vbolt.proto; the pb package is generated from it.
+// vboltrpc is its own module, so importing vbolt doesn't pull in grpc.
+package vboltrpc
+
+//go:generate protoc --go_out=. --go_opt=module=go.hasen.dev/vbolt/vboltrpc --go-grpc_out=. --go-grpc_opt=module=go.hasen.dev/vbolt/vboltrpc vbolt.proto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vboltrpc/pb"
+	"go.hasen.dev/vpack"
+)
+
+// ScanList and IterateTerm pages default to ScanListDefault items when the
+// request doesn't give a count (or limit), and never exceed ScanListMax
+var ScanListDefault = 100
+var ScanListMax = 1000
+
+func pageSize(count int) int {
+	if count <= 0 {
+		count = ScanListDefault
+	}
+	if count > ScanListMax {
+		count = ScanListMax
+	}
+	return count
+}
+
+type Server struct {
+	pb.UnimplementedVBoltServer
+
+	DB   *vbolt.DB
+	Info *vbolt.Info
+}
+
+func NewServer(db *vbolt.DB, info *vbolt.Info) *Server {
+	return &Server{DB: db, Info: info}
+}
+
+// Register is a shortcut for creating a Server and registering it on s
+func Register(s grpc.ServiceRegistrar, db *vbolt.DB, info *vbolt.Info) {
+	pb.RegisterVBoltServer(s, NewServer(db, info))
+}
+
+func (s *Server) bucket(name string) (g vbolt.GenericBucketInfo, err error) {
+	g, ok := vbolt.AsGenericBucket(s.Info.Infos[name])
+	if !ok {
+		err = status.Errorf(codes.NotFound, "unknown bucket: %s", name)
+	}
+	return
+}
+
+func (s *Server) index(name string) (g vbolt.GenericIndexInfo, err error) {
+	g, ok := vbolt.AsGenericIndex(s.Info.Infos[name])
+	if !ok {
+		err = status.Errorf(codes.NotFound, "unknown index: %s", name)
+	}
+	return
+}
+
+// decodes data into a new *typ according to format and serializes it with packFn
+func packInput(format pb.Format, typ reflect.Type, packFn reflect.Value, data []byte) ([]byte, error) {
+	if format == pb.Format_VPACK {
+		return data, nil
+	}
+	ptr := reflect.New(typ)
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid json: %v", err)
+	}
+	return packValue(packFn, ptr.Interface()), nil
+}
+
+// serializes ptr (a *T) with packFn
+func packValue(packFn reflect.Value, ptr any) []byte {
+	buf := vpack.NewWriter()
+	packFn.Call([]reflect.Value{reflect.ValueOf(ptr), reflect.ValueOf(buf)})
+	return buf.Data
+}
+
+// encodes a decoded object (or the raw bytes) according to format
+func encodeOutput(format pb.Format, raw []byte, obj func() any) ([]byte, error) {
+	if format == pb.Format_VPACK {
+		return bytes.Clone(raw), nil
+	}
+	data, err := json.Marshal(obj())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encoding json: %v", err)
+	}
+	return data, nil
+}
+
+func encodeItem(format pb.Format, g *vbolt.GenericBucketInfo, key []byte, value []byte) (item *pb.Item, err error) {
+	item = new(pb.Item)
+	item.Key, err = encodeOutput(format, key, func() any { return vbolt.GenericUnpackKey(g, key) })
+	if err != nil {
+		return nil, err
+	}
+	item.Value, err = encodeOutput(format, value, func() any { return vbolt.GenericUnpackValue(g, value) })
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *Server) Read(ctx context.Context, req *pb.ReadRequest) (resp *pb.ReadResponse, err error) {
+	g, err := s.bucket(req.GetBucket())
+	if err != nil {
+		return nil, err
+	}
+	key, err := packInput(req.GetFormat(), g.KeyType, g.KeyPackFn, req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+
+	resp = new(pb.ReadResponse)
+	vbolt.WithReadTx(s.DB, func(tx *vbolt.Tx) {
+		bkt := vbolt.TxRawBucket(tx, g.Name)
+		if bkt == nil {
+			return
+		}
+		data := bkt.Get(key)
+		if data == nil {
+			return
+		}
+		resp.Found = true
+		resp.Value, err = encodeOutput(req.GetFormat(), data, func() any { return vbolt.GenericUnpackValue(&g, data) })
+	})
+	return resp, err
+}
+
+func (s *Server) ReadSlice(ctx context.Context, req *pb.ReadSliceRequest) (resp *pb.ReadSliceResponse, err error) {
+	g, err := s.bucket(req.GetBucket())
+	if err != nil {
+		return nil, err
+	}
+	keys := make([][]byte, 0, len(req.GetKeys()))
+	for _, k := range req.GetKeys() {
+		key, err := packInput(req.GetFormat(), g.KeyType, g.KeyPackFn, k)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	resp = new(pb.ReadSliceResponse)
+	vbolt.WithReadTx(s.DB, func(tx *vbolt.Tx) {
+		bkt := vbolt.TxRawBucket(tx, g.Name)
+		if bkt == nil {
+			return
+		}
+		for _, key := range keys {
+			data := bkt.Get(key)
+			if data == nil {
+				continue
+			}
+			var item *pb.Item
+			item, err = encodeItem(req.GetFormat(), &g, key, data)
+			if err != nil {
+				return
+			}
+			resp.Items = append(resp.Items, item)
+		}
+	})
+	return resp, err
+}
+
+func (s *Server) IterateTerm(ctx context.Context, req *pb.IterateTermRequest) (resp *pb.IterateTermResponse, err error) {
+	g, err := s.index(req.GetIndex())
+	if err != nil {
+		return nil, err
+	}
+	format := req.GetFormat()
+
+	var window vbolt.Window
+	window.Limit = pageSize(int(req.GetLimit()))
+	window.Offset = int(req.GetOffset())
+	window.Cursor = req.GetCursor()
+	if req.GetReverse() {
+		window.Direction = vbolt.IterateReverse
+	}
+
+	// the term has to be passed as a *T to GenericIterateTerm
+	term := reflect.New(g.TermType)
+	if format == pb.Format_VPACK {
+		g.TermPackFn.Call([]reflect.Value{term, reflect.ValueOf(vpack.NewReader(req.GetTerm()))})
+	} else if err := json.Unmarshal(req.GetTerm(), term.Interface()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid json: %v", err)
+	}
+
+	resp = new(pb.IterateTermResponse)
+	vbolt.WithReadTx(s.DB, func(tx *vbolt.Tx) {
+		next := vbolt.GenericIterateTerm(tx, &g, term.Interface(), window, func(target any, priority any) bool {
+			var match pb.TermMatch
+			match.Target, err = encodeOutput(format, packValue(g.TargetPackFn, target), func() any { return target })
+			if err == nil {
+				match.Priority, err = encodeOutput(format, packValue(g.PriorityPackFn, priority), func() any { return priority })
+			}
+			if err != nil {
+				return false
+			}
+			resp.Matches = append(resp.Matches, &match)
+			return true
+		})
+		resp.NextCursor = bytes.Clone(next)
+	})
+	return resp, err
+}
+
+func (s *Server) ScanList(ctx context.Context, req *pb.ScanListRequest) (resp *pb.ScanListResponse, err error) {
+	g, err := s.bucket(req.GetBucket())
+	if err != nil {
+		return nil, err
+	}
+	var start []byte
+	if len(req.GetStartKey()) > 0 {
+		start, err = packInput(req.GetFormat(), g.KeyType, g.KeyPackFn, req.GetStartKey())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	count := pageSize(int(req.GetCount()))
+
+	resp = new(pb.ScanListResponse)
+	resp.Done = true
+	vbolt.WithReadTx(s.DB, func(tx *vbolt.Tx) {
+		bkt := vbolt.TxRawBucket(tx, g.Name)
+		if bkt == nil {
+			return
+		}
+		crsr := bkt.Cursor()
+		key, value := crsr.Seek(start)
+		for n := 0; key != nil && n < count; n++ {
+			var item *pb.Item
+			item, err = encodeItem(req.GetFormat(), &g, key, value)
+			if err != nil {
+				return
+			}
+			resp.Items = append(resp.Items, item)
+			key, value = crsr.Next()
+		}
+		if key != nil {
+			resp.Done = false
+			resp.NextKey, err = encodeOutput(req.GetFormat(), key, func() any { return vbolt.GenericUnpackKey(&g, key) })
+		}
+	})
+	return resp, err
+}
@@ -0,0 +1,41 @@
+package vbolttest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"go.hasen.dev/vbolt"
+)
+
+// FakeClock is a vbolt.Clock that only moves when told to
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// UseFakeClock installs a FakeClock starting at start as the vbolt clock for
+// the duration of the test. Tests using it must not run in parallel.
+func UseFakeClock(t testing.TB, start time.Time) *FakeClock {
+	clock := &FakeClock{now: start}
+	t.Cleanup(vbolt.SetClock(clock))
+	return clock
+}
@@ -0,0 +1,113 @@
+package vbolttest
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.hasen.dev/vbolt"
+)
+
+// CrashWorkload performs writes against db. It should call crash at points
+// where a crash would be interesting (e.g. right before or after tx.Commit).
+// vbolt.TxCommit (and therefore RestoreBuckets) also counts as a crash point.
+//
+// The workload must release its transactions with defer (WithWriteTx,
+// TxClose, etc), since a simulated crash unwinds it with a panic.
+type CrashWorkload func(db *vbolt.DB, crash func(point string))
+
+type _CrashSignal struct{}
+
+// SimulateCrashes runs the workload once to completion to find its crash
+// points, then for each round runs it again on a fresh database and "crashes"
+// at a randomly chosen point: the database file is copied as it is on disk at
+// that moment and the workload is aborted. The copy is then reopened and
+// checked with vbolt.CheckInvariants.
+//
+// Because it sets vbolt.CrashHook, tests using it must not run in parallel.
+// With the in-memory backend there's no file to copy, so the test is skipped.
+func SimulateCrashes(t *testing.T, dbInfo *vbolt.Info, rounds int, seed int64, workload CrashWorkload) {
+	t.Helper()
+	if vbolt.BackendName == "memstore" {
+		t.Skip("crash simulation needs a database file; the memstore backend has none")
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	// dry run: count the crash points and check the happy path
+	var points int
+	_RunCrashWorkload(t, dbInfo, workload, func(path string, point string) { points++ })
+	if points == 0 {
+		t.Fatal("workload has no crash points")
+	}
+
+	for round := 0; round < rounds; round++ {
+		crashAt := rng.Intn(points) + 1
+		var seen int
+		var crashPath, crashLabel string
+		_RunCrashWorkload(t, dbInfo, workload, func(path string, point string) {
+			seen++
+			if seen != crashAt {
+				return
+			}
+			crashLabel = point
+			crashPath = _CopyDBFile(t, path, point)
+			panic(_CrashSignal{})
+		})
+		if crashPath == "" {
+			// the workload is not deterministic and took a shorter path this time
+			continue
+		}
+
+		db := vbolt.Open(crashPath)
+		vbolt.WithReadTx(db, func(tx *vbolt.Tx) {
+			for _, v := range vbolt.CheckInvariants(tx, dbInfo) {
+				t.Errorf("round %d, crash at #%d (%s): %s", round, crashAt, crashLabel, v)
+			}
+		})
+		vbolt.Close(db)
+	}
+}
+
+func _RunCrashWorkload(t *testing.T, dbInfo *vbolt.Info, workload CrashWorkload, hook func(path string, point string)) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "crash.bolt")
+	db := vbolt.Open(path)
+	defer vbolt.Close(db)
+	vbolt.InitBuckets(db, dbInfo)
+
+	crash := func(point string) {
+		hook(path, point)
+	}
+	defer func() {
+		vbolt.CrashHook = nil
+		if r := recover(); r != nil {
+			if _, ok := r.(_CrashSignal); !ok {
+				panic(r)
+			}
+		}
+	}()
+
+	vbolt.CrashHook = crash
+	workload(db, crash)
+	vbolt.CrashHook = nil
+
+	vbolt.WithReadTx(db, func(tx *vbolt.Tx) {
+		for _, v := range vbolt.CheckInvariants(tx, dbInfo) {
+			t.Errorf("no crash: %s", v)
+		}
+	})
+}
+
+// copies the database file as it is on disk right now
+func _CopyDBFile(t *testing.T, dbPath string, point string) string {
+	data, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("copying db at crash point %s: %v", point, err)
+	}
+	path := filepath.Join(t.TempDir(), "crashed.bolt")
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("copying db at crash point %s: %v", point, err)
+	}
+	return path
+}
@@ -0,0 +1,73 @@
+package vbolttest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.hasen.dev/vbolt"
+)
+
+// run tests with -vbolt.update to (re)write golden files instead of comparing against them
+var updateGolden = flag.Bool("vbolt.update", false, "update vbolt golden files")
+
+// AssertBucketEqualsGolden compares the content of a bucket (or index, or
+// collection) in the vbolt.SnapshotText format against the golden file.
+func AssertBucketEqualsGolden(t testing.TB, tx *vbolt.Tx, infoPtr any, goldenPath string) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := vbolt.SnapshotBucketText(tx, infoPtr, &buf); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	_AssertGolden(t, buf.Bytes(), goldenPath)
+}
+
+// AssertDBEqualsGolden is like AssertBucketEqualsGolden but for everything registered in dbInfo
+func AssertDBEqualsGolden(t testing.TB, tx *vbolt.Tx, dbInfo *vbolt.Info, goldenPath string) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := vbolt.SnapshotTextTx(tx, dbInfo, &buf); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	_AssertGolden(t, buf.Bytes(), goldenPath)
+}
+
+func _AssertGolden(t testing.TB, actual []byte, goldenPath string) {
+	t.Helper()
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(goldenPath, actual, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -vbolt.update to create it): %v", err)
+	}
+	if bytes.Equal(expected, actual) {
+		return
+	}
+
+	expectedLines := strings.Split(string(expected), "\n")
+	actualLines := strings.Split(string(actual), "\n")
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		var e, a string
+		if i < len(expectedLines) {
+			e = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			a = actualLines[i]
+		}
+		if e != a {
+			t.Errorf("%s differs at line %d:\n  expected: %s\n  actual:   %s", goldenPath, i+1, e, a)
+			return
+		}
+	}
+}
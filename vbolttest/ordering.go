@@ -0,0 +1,126 @@
+package vbolttest
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vpack"
+)
+
+/*
+	Bolt orders keys by their bytes. Iteration (and pagination, and range
+	reads) only match the logical order of the keys when the pack functions
+	produce order preserving encodings. These helpers catch pack functions that
+	don't, e.g. using a varint encoding for keys that are meant to be sorted
+	numerically.
+*/
+
+// compares two values by their natural order; ok is false for types without one
+func _LogicalCompare(a, b reflect.Value) (result int, ok bool) {
+	if a.Type() == reflect.TypeOf(time.Time{}) {
+		ta, tb := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case ta.Before(tb):
+			return -1, true
+		case ta.After(tb):
+			return 1, true
+		}
+		return 0, true
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return _Compare(a.Int(), b.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return _Compare(a.Uint(), b.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return _Compare(a.Float(), b.Float()), true
+	case reflect.String:
+		return _Compare(a.String(), b.String()), true
+	}
+	return 0, false
+}
+
+func _Compare[T int64 | uint64 | float64 | string](a, b T) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// AssertSortedByKey checks that the keys of the bucket iterate in their
+// logical order, and that each key re-encodes to the same bytes.
+//
+// Only keys with a natural order (numbers, strings, time) can be checked.
+func AssertSortedByKey[K, T any](t testing.TB, tx *vbolt.Tx, bucketInfo *vbolt.BucketInfo[K, T]) {
+	t.Helper()
+	var prev K
+	var hasPrev bool
+	vbolt.RawIterate(vbolt.TxRawBucket(tx, bucketInfo.Name), nil, vbolt.Window{}, func(raw []byte, value []byte) bool {
+		var key K
+		vpack.FromBytesInto(raw, &key, bucketInfo.KeyPackFn)
+		if repacked := vpack.ToBytes(&key, bucketInfo.KeyPackFn); !bytes.Equal(repacked, raw) {
+			t.Errorf("%s: key %v does not round trip: stored %x, re-encoded %x", bucketInfo.Name, key, raw, repacked)
+			return false
+		}
+		if hasPrev {
+			cmp, ok := _LogicalCompare(reflect.ValueOf(prev), reflect.ValueOf(key))
+			if !ok {
+				t.Fatalf("%s: key type %T has no natural order", bucketInfo.Name, key)
+			}
+			if cmp >= 0 {
+				t.Errorf("%s: key %v is iterated after %v", bucketInfo.Name, key, prev)
+				return false
+			}
+		}
+		prev, hasPrev = key, true
+		return true
+	})
+}
+
+type _TermMatch[K, P comparable] struct {
+	Target   K
+	Priority P
+}
+
+// AssertIterationStable checks that iterating the targets of a term is
+// repeatable, and that they come in logical (priority, target) order.
+func AssertIterationStable[K, T, P comparable](t testing.TB, tx *vbolt.Tx, indexInfo *vbolt.IndexInfo[K, T, P], term T) {
+	t.Helper()
+	var first, second []_TermMatch[K, P]
+	vbolt.IterateTerm(tx, indexInfo, term, func(target K, priority P) bool {
+		first = append(first, _TermMatch[K, P]{target, priority})
+		return true
+	})
+	vbolt.IterateTerm(tx, indexInfo, term, func(target K, priority P) bool {
+		second = append(second, _TermMatch[K, P]{target, priority})
+		return true
+	})
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("%s: iterating term %v twice gave different results", indexInfo.Name, term)
+	}
+
+	for i := 1; i < len(first); i++ {
+		a, b := first[i-1], first[i]
+		cmp, ok := _LogicalCompare(reflect.ValueOf(a.Priority), reflect.ValueOf(b.Priority))
+		if !ok {
+			t.Fatalf("%s: priority type %T has no natural order", indexInfo.Name, a.Priority)
+		}
+		if cmp == 0 {
+			cmp, ok = _LogicalCompare(reflect.ValueOf(a.Target), reflect.ValueOf(b.Target))
+			if !ok {
+				// targets without a natural order can't be checked
+				continue
+			}
+		}
+		if cmp >= 0 {
+			t.Errorf("%s: term %v: %v (priority %v) is iterated after %v (priority %v)",
+				indexInfo.Name, term, b.Target, b.Priority, a.Target, a.Priority)
+		}
+	}
+}
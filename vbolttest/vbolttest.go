@@ -0,0 +1,62 @@
+// Package vbolttest removes the boilerplate of setting up databases in tests.
+//
+// Tests that only exercise business logic can run entirely in memory by
+// building with the vboltmem tag (go test -tags vboltmem ./...), in which case
+// NewTestDB and NewTx do not create any files and tests can freely use t.Parallel.
+//
+// The tag is a switch for the whole build, not a per-test choice: vbolt.DB and
+// vbolt.Tx are aliases of the backend's types, so a test binary runs every
+// test on either bolt or the in-memory store. Without the tag, NewTestDB
+// creates a bolt file (see vbolt.OpenTemp). To keep bolt-only tests (e.g. of
+// file handling) out of in-memory runs, give their files a !vboltmem build
+// constraint, or skip when vbolt.BackendName is "memstore".
+package vbolttest
+
+import (
+	"testing"
+
+	"go.hasen.dev/vbolt"
+)
+
+// NewTestDB opens a fresh database in a temporary file (see vbolt.OpenTemp).
+// The database is closed and the file removed when the test finishes.
+//
+// If any infos are given, their buckets, indexes, and collections are created upfront.
+func NewTestDB(t testing.TB, infos ...*vbolt.Info) *vbolt.DB {
+	t.Helper()
+	db, remove := vbolt.OpenTemp()
+	t.Cleanup(remove)
+	if len(infos) > 0 {
+		vbolt.InitBuckets(db, infos...)
+	}
+	return db
+}
+
+// Commit runs fn in a write transaction and commits it, failing the test if the commit fails
+func Commit(t testing.TB, db *vbolt.DB, fn func(tx *vbolt.Tx)) {
+	t.Helper()
+	vbolt.WithWriteTx(db, func(tx *vbolt.Tx) {
+		fn(tx)
+		if err := vbolt.TxCommitE(tx); err != nil {
+			t.Fatalf("commit failed: %v", err)
+		}
+	})
+}
+
+// View runs fn in a read transaction
+func View(db *vbolt.DB, fn func(tx *vbolt.Tx)) {
+	vbolt.WithReadTx(db, fn)
+}
+
+// NewTx returns a write transaction on a fresh test database. The transaction
+// is rolled back when the test finishes; it's meant for unit tests that call
+// Read/Write/SetTargetTerms etc directly and don't care about commits.
+func NewTx(t testing.TB, infos ...*vbolt.Info) *vbolt.Tx {
+	t.Helper()
+	db := NewTestDB(t, infos...)
+	tx := vbolt.WriteTx(db)
+	t.Cleanup(func() {
+		vbolt.TxClose(tx)
+	})
+	return tx
+}
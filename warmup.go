@@ -0,0 +1,81 @@
+package vbolt
+
+import (
+	"sync"
+	"time"
+)
+
+type WarmupOptions struct {
+	Buckets  []string // buckets to warm; all the buckets in the file if empty
+	MaxBytes int64    // per bucket; 0 means no limit
+	Workers  int      // buckets are warmed in parallel by this many goroutines; defaults to 1
+}
+
+type WarmupStats struct {
+	Bucket    string
+	Keys      int
+	Bytes     int64 // keys and values read
+	Duration  time.Duration
+	Truncated bool // stopped at MaxBytes
+}
+
+// WarmTheCacheExt is like WarmTheCache, but can be limited to some buckets
+// and some amount of data, and can run in parallel. Each worker uses its own
+// read tx. Returns the stats in the order of the buckets.
+func WarmTheCacheExt(db *DB, opts WarmupOptions) []WarmupStats {
+	names := opts.Buckets
+	if len(names) == 0 {
+		WithReadTx(db, func(tx *Tx) {
+			tx.ForEach(func(name []byte, _ *BBucket) error {
+				names = append(names, string(name))
+				return nil
+			})
+		})
+	}
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	stats := make([]WarmupStats, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				WithReadTx(db, func(tx *Tx) {
+					stats[i] = _WarmBucket(tx, names[i], opts.MaxBytes)
+				})
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return stats
+}
+
+func _WarmBucket(tx *Tx, name string, maxBytes int64) (stats WarmupStats) {
+	stats.Bucket = name
+	start := time.Now()
+	defer func() { stats.Duration = time.Since(start) }()
+
+	bkt := tx.Bucket([]byte(name))
+	if bkt == nil {
+		return
+	}
+	c := bkt.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if maxBytes > 0 && stats.Bytes >= maxBytes {
+			stats.Truncated = true
+			break
+		}
+		stats.Keys++
+		stats.Bytes += int64(len(k) + len(v))
+	}
+	return
+}
@@ -0,0 +1,135 @@
+package vbolt
+
+import (
+	"sync"
+
+	"go.hasen.dev/vpack"
+)
+
+/*
+	Watch registers a callback for the changes made to a bucket by Write and
+	Delete. Changes are queued as they're made, and the callbacks run after the
+	transaction commits (through TxCommit/TxCommitE), in the order of the
+	changes; they never run for transactions that are rolled back.
+
+	Callbacks run synchronously on the goroutine that committed, so they
+	should be quick (e.g. invalidate a cache entry or push to a channel).
+	They may start new transactions: they run after the write gate is
+	released (for BulkLoad, once the whole load is done).
+
+	Raw writes, restores, and transactions committed with tx.Commit() directly
+	are not seen. Such transactions should still be passed to TxClose, which
+	drops the changes they queued.
+*/
+
+type _Watcher[K, T any] struct {
+	Filter func(key K) bool
+	Fn     func(key K, old *T, new *T)
+}
+
+type _BucketWatch[K, T any] struct {
+	mu       sync.Mutex
+	watchers []*_Watcher[K, T]
+}
+
+var _watches sync.Map // *BucketInfo[K, T] => *_BucketWatch[K, T]
+
+var _txChanges sync.Map // *Tx => []func()
+
+// Watch calls fn after every committed change to a record of bucketInfo.
+// old is nil for new records, new is nil for deleted ones.
+//
+// Returns a function that stops watching.
+func Watch[K, T any](bucketInfo *BucketInfo[K, T], fn func(key K, old *T, new *T)) (stop func()) {
+	return WatchWhere(bucketInfo, nil, fn)
+}
+
+// WatchWhere is like Watch, but only for the keys that filter accepts
+func WatchWhere[K, T any](bucketInfo *BucketInfo[K, T], filter func(key K) bool, fn func(key K, old *T, new *T)) (stop func()) {
+	value, _ := _watches.LoadOrStore(bucketInfo, new(_BucketWatch[K, T]))
+	watch := value.(*_BucketWatch[K, T])
+	watcher := &_Watcher[K, T]{Filter: filter, Fn: fn}
+
+	watch.mu.Lock()
+	watch.watchers = append(watch.watchers, watcher)
+	watch.mu.Unlock()
+
+	return func() {
+		watch.mu.Lock()
+		defer watch.mu.Unlock()
+		for i, w := range watch.watchers {
+			if w == watcher {
+				// copy, so queued changes keep the list they were queued with
+				watchers := make([]*_Watcher[K, T], 0, len(watch.watchers)-1)
+				watchers = append(watchers, watch.watchers[:i]...)
+				watch.watchers = append(watchers, watch.watchers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// returns nil if nobody watches the bucket
+func _Watching[K, T any](bucketInfo *BucketInfo[K, T]) *_BucketWatch[K, T] {
+	value, ok := _watches.Load(bucketInfo)
+	if !ok {
+		return nil
+	}
+	watch := value.(*_BucketWatch[K, T])
+	watch.mu.Lock()
+	defer watch.mu.Unlock()
+	if len(watch.watchers) == 0 {
+		return nil
+	}
+	return watch
+}
+
+// decodes the current value of key; must be called before it's overwritten,
+// as bolt reuses the page
+func (watch *_BucketWatch[K, T]) _Old(bkt *BBucket, bucketInfo *BucketInfo[K, T], key []byte) *T {
+	data := bkt.Get(key)
+	if data == nil {
+		return nil
+	}
+	old := new(T)
+	vpack.FromBytesInto(data, old, bucketInfo.ValuePackFn)
+	return old
+}
+
+func (watch *_BucketWatch[K, T]) _Queue(tx *Tx, id K, old *T, item *T) {
+	var newItem *T
+	if item != nil {
+		newItem = new(T)
+		*newItem = *item
+	}
+	watch.mu.Lock()
+	watchers := watch.watchers
+	watch.mu.Unlock()
+
+	change := func() {
+		for _, w := range watchers {
+			if w.Filter == nil || w.Filter(id) {
+				w.Fn(id, old, newItem)
+			}
+		}
+	}
+	changes, _ := _txChanges.Load(tx)
+	list, _ := changes.([]func())
+	_txChanges.Store(tx, append(list, change))
+}
+
+// removes the changes queued in tx, to be run with _RunChanges after a
+// successful commit, once the write gate is released
+func _TakeTxChanges(tx *Tx) []func() {
+	changes, ok := _txChanges.LoadAndDelete(tx)
+	if !ok {
+		return nil
+	}
+	return changes.([]func())
+}
+
+func _RunChanges(changes []func()) {
+	for _, change := range changes {
+		change()
+	}
+}
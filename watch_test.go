@@ -0,0 +1,177 @@
+package vbolt_test
+
+import (
+	"testing"
+	"time"
+
+	"go.hasen.dev/vbolt"
+	"go.hasen.dev/vbolt/vbolttest"
+	"go.hasen.dev/vpack"
+)
+
+type _Change struct {
+	Id  int
+	Old string // "-" for nil
+	New string
+}
+
+func TestWatch(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+	db := vbolttest.NewTestDB(t, &dbInfo)
+
+	// the callbacks run on the committing goroutine, so no locking
+	var all, even []_Change
+	record := func(changes *[]_Change) func(id int, old *string, new *string) {
+		return func(id int, old *string, new *string) {
+			change := _Change{Id: id, Old: "-", New: "-"}
+			if old != nil {
+				change.Old = *old
+			}
+			if new != nil {
+				change.New = *new
+			}
+			*changes = append(*changes, change)
+		}
+	}
+	stopAll := vbolt.Watch(posts, record(&all))
+	stopEven := vbolt.WatchWhere(posts, func(id int) bool { return id%2 == 0 }, record(&even))
+	defer stopEven()
+
+	expect := func(name string, changes *[]_Change, expected ..._Change) {
+		t.Helper()
+		if len(*changes) != len(expected) {
+			t.Errorf("%s: expected %v, got %v", name, expected, *changes)
+		} else {
+			for i := range expected {
+				if (*changes)[i] != expected[i] {
+					t.Errorf("%s: expected %v, got %v", name, expected, *changes)
+					break
+				}
+			}
+		}
+		*changes = nil
+	}
+	write := func(tx *vbolt.Tx, id int, content string) {
+		vbolt.Write(tx, posts, id, &content)
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+			write(tx, 1, "one")
+			write(tx, 2, "two")
+			if len(all) != 0 {
+				t.Errorf("fired before the commit: %v", all)
+			}
+		})
+		expect("all", &all, _Change{1, "-", "one"}, _Change{2, "-", "two"})
+		expect("even", &even, _Change{2, "-", "two"})
+
+		vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+			write(tx, 1, "one, edited")
+			vbolt.Delete(tx, posts, 2)
+		})
+		expect("all", &all, _Change{1, "one", "one, edited"}, _Change{2, "two", "-"})
+		expect("even", &even, _Change{2, "two", "-"})
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		vbolt.WithWriteTx(db, func(tx *vbolt.Tx) {
+			write(tx, 3, "three")
+			write(tx, 4, "four")
+		})
+		expect("all", &all)
+		expect("even", &even)
+
+		// the queued changes are dropped along with the tx; they don't leak
+		// into the next commit
+		vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+			write(tx, 5, "five")
+		})
+		expect("all", &all, _Change{5, "-", "five"})
+		expect("even", &even)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		stopAll()
+		stopAll() // already stopped
+		vbolttest.Commit(t, db, func(tx *vbolt.Tx) {
+			write(tx, 6, "six")
+		})
+		expect("all", &all)
+		expect("even", &even, _Change{6, "-", "six"})
+	})
+}
+
+// watchers run after the write gate is released, so they can write
+func TestWatchWrites(t *testing.T) {
+	var dbInfo vbolt.Info
+	posts := vbolt.Bucket(&dbInfo, "posts", vpack.FInt, vpack.String)
+	audit := vbolt.Bucket(&dbInfo, "audit", vpack.FInt, vpack.String)
+	db := vbolttest.NewTestDB(t, &dbInfo)
+
+	// with a writer waiting for the gate exclusively, a watcher that still
+	// held it shared would deadlock when it begins its own write tx
+	var waiting bool
+	stop := vbolt.Watch(posts, func(id int, old *string, new *string) {
+		if waiting {
+			go vbolt.BulkLoad(db, func(tx *vbolt.Tx) (bool, error) { return false, nil })
+			time.Sleep(10 * time.Millisecond)
+		}
+		// not vbolttest.Commit: this may run on another goroutine
+		vbolt.WithWriteTx(db, func(tx *vbolt.Tx) {
+			vbolt.Write(tx, audit, id, new)
+			vbolt.TxCommit(tx)
+		})
+	})
+	defer stop()
+
+	within := func(t *testing.T, name string, fn func() error) {
+		t.Helper()
+		done := make(chan error, 1)
+		go func() { done <- fn() }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: deadlocked", name)
+		}
+	}
+	audited := func(id int) (content string) {
+		vbolttest.View(db, func(tx *vbolt.Tx) {
+			vbolt.Read(tx, audit, id, &content)
+		})
+		return
+	}
+
+	t.Run("bulk load", func(t *testing.T) {
+		within(t, "bulk load", func() error {
+			id := 0
+			return vbolt.BulkLoad(db, func(tx *vbolt.Tx) (bool, error) {
+				id++
+				content := "loaded"
+				vbolt.Write(tx, posts, id, &content)
+				return id < 3, nil
+			})
+		})
+		for id := 1; id <= 3; id++ {
+			if content := audited(id); content != "loaded" {
+				t.Errorf("post %d: audited %q", id, content)
+			}
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		waiting = true
+		defer func() { waiting = false }()
+		within(t, "batch", func() error {
+			content := "batched"
+			return vbolt.BatchWrite(db, posts, 4, &content)
+		})
+		if content := audited(4); content != "batched" {
+			t.Errorf("post 4: audited %q", content)
+		}
+	})
+}